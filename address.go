@@ -0,0 +1,244 @@
+package vcard
+
+import (
+	"strings"
+)
+
+// An addressFormat describes how addresses are written in a country. It uses
+// the format of Google's libaddressinput data:
+//
+//   - %A: street address lines
+//   - %D: dependent locality, e.g. district
+//   - %C: locality, e.g. city
+//   - %S: administrative area, e.g. state or province
+//   - %Z: postal code
+//   - %n: new line
+//
+// Fields listed in upper are written in upper case.
+type addressFormat struct {
+	fmt   string
+	upper string
+}
+
+// defaultAddressFormat is used for countries without a known format. It
+// includes all the fields, so that no information is lost.
+var defaultAddressFormat = addressFormat{fmt: "%A%n%D%n%C %S %Z"}
+
+// addressFormats is derived from libaddressinput, without the name and
+// organization fields.
+var addressFormats = map[string]addressFormat{
+	"AR": {fmt: "%A%n%Z %C%n%S", upper: "ACZ"},
+	"AT": {fmt: "%A%n%Z %C"},
+	"AU": {fmt: "%A%n%C %S %Z", upper: "CS"},
+	"BE": {fmt: "%A%n%Z %C"},
+	"BR": {fmt: "%A%n%D%n%C-%S%n%Z", upper: "CS"},
+	"CA": {fmt: "%A%n%C %S %Z", upper: "ACSZ"},
+	"CH": {fmt: "%A%n%Z %C"},
+	"CN": {fmt: "%Z%n%S%C%D%n%A"},
+	"CZ": {fmt: "%A%n%Z %C"},
+	"DE": {fmt: "%A%n%Z %C"},
+	"DK": {fmt: "%A%n%Z %C"},
+	"ES": {fmt: "%A%n%Z %C %S", upper: "CS"},
+	"FI": {fmt: "%A%n%Z %C"},
+	"FR": {fmt: "%A%n%Z %C", upper: "C"},
+	"GB": {fmt: "%A%n%C%n%Z", upper: "CZ"},
+	"IE": {fmt: "%A%n%D%n%C%n%S%n%Z", upper: "CZ"},
+	"IN": {fmt: "%A%n%C %Z%n%S", upper: "C"},
+	"IT": {fmt: "%A%n%Z %C %S", upper: "CS"},
+	"JP": {fmt: "〒%Z%n%S%C%n%A"},
+	"KR": {fmt: "%S %C%D%n%A%n%Z"},
+	"MX": {fmt: "%A%n%D%n%Z %C, %S", upper: "CSZ"},
+	"NL": {fmt: "%A%n%Z %C"},
+	"NO": {fmt: "%A%n%Z %C"},
+	"NZ": {fmt: "%A%n%D%n%C %Z"},
+	"PL": {fmt: "%A%n%Z %C"},
+	"PT": {fmt: "%A%n%Z %C"},
+	"RU": {fmt: "%A%n%C%n%S%n%Z", upper: "AC"},
+	"SE": {fmt: "%A%n%Z %C", upper: "ZC"},
+	"US": {fmt: "%A%n%C, %S %Z", upper: "CS"},
+}
+
+// countryCodes maps common country names to ISO 3166-1 alpha-2 codes.
+var countryCodes = map[string]string{
+	"argentina":                "AR",
+	"australia":                "AU",
+	"austria":                  "AT",
+	"belgium":                  "BE",
+	"brasil":                   "BR",
+	"brazil":                   "BR",
+	"canada":                   "CA",
+	"china":                    "CN",
+	"czech republic":           "CZ",
+	"czechia":                  "CZ",
+	"denmark":                  "DK",
+	"deutschland":              "DE",
+	"españa":                   "ES",
+	"finland":                  "FI",
+	"france":                   "FR",
+	"germany":                  "DE",
+	"great britain":            "GB",
+	"india":                    "IN",
+	"ireland":                  "IE",
+	"italia":                   "IT",
+	"italy":                    "IT",
+	"japan":                    "JP",
+	"mexico":                   "MX",
+	"méxico":                   "MX",
+	"netherlands":              "NL",
+	"new zealand":              "NZ",
+	"norway":                   "NO",
+	"poland":                   "PL",
+	"portugal":                 "PT",
+	"russia":                   "RU",
+	"south korea":              "KR",
+	"spain":                    "ES",
+	"sweden":                   "SE",
+	"switzerland":              "CH",
+	"the netherlands":          "NL",
+	"uk":                       "GB",
+	"united kingdom":           "GB",
+	"united states":            "US",
+	"united states of america": "US",
+	"usa":                      "US",
+	"日本":                       "JP",
+	"中国":                       "CN",
+	"대한민국":                     "KR",
+}
+
+// Format formats the address as multi-line postal text, e.g. for a mailing
+// label. country is the ISO 3166-1 alpha-2 code of the country whose address
+// format is used, e.g. "US". If country is empty, it's guessed from the
+// Country component. The Country component, if any, is written on the last
+// line. If StreetAddress is empty, the street line is built from the
+// components defined in RFC 9554, such as StreetName and Floor.
+func (a *Address) Format(country string) string {
+	if country == "" {
+		country = addressCountryCode(a.Country)
+	}
+	format, ok := addressFormats[strings.ToUpper(country)]
+	if !ok {
+		format = defaultAddressFormat
+	}
+
+	var lines []string
+	for _, l := range strings.Split(format.fmt, "%n") {
+		if l == "%A" {
+			lines = append(lines, a.streetLines(format.upper)...)
+		} else if s := a.formatLine(l, format.upper); s != "" {
+			lines = append(lines, s)
+		}
+	}
+	if c := strings.TrimSpace(a.Country); c != "" {
+		lines = append(lines, c)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// FillLabel sets the ParamLabel parameter to the formatted address. See
+// Format.
+func (a *Address) FillLabel(country string) {
+	f := a.field()
+	if f.Params == nil {
+		f.Params = make(Params)
+	}
+	if label := a.Format(country); label != "" {
+		f.Params.Set(ParamLabel, label)
+	} else {
+		delete(f.Params, ParamLabel)
+	}
+}
+
+func (a *Address) streetLines(upper string) []string {
+	street := a.StreetAddress
+	if strings.TrimSpace(street) == "" {
+		// RFC 9554 components, in the order of the structured value
+		street = joinNonEmpty(", ", a.Room, a.Apartment, a.Floor,
+			joinNonEmpty(" ", a.StreetNumber, a.StreetName), a.Building,
+			a.Block, a.Landmark, a.Direction)
+	}
+
+	var lines []string
+	for _, s := range []string{a.PostOfficeBox, a.ExtendedAddress, street} {
+		for _, l := range strings.Split(s, "\n") {
+			if l = strings.TrimSpace(l); l != "" {
+				if strings.Contains(upper, "A") {
+					l = strings.ToUpper(l)
+				}
+				lines = append(lines, l)
+			}
+		}
+	}
+	return lines
+}
+
+// formatLine formats a line of an address format. Literal text is omitted
+// when the fields around it are empty.
+func (a *Address) formatLine(l, upper string) string {
+	var sb strings.Builder
+	var literal string
+	hasField, hasCode := false, false
+	for l != "" {
+		i := strings.IndexByte(l, '%')
+		if i < 0 || i+1 >= len(l) {
+			literal += l
+			break
+		}
+		literal += l[:i]
+		code := l[i+1]
+		l = l[i+2:]
+
+		v := strings.TrimSpace(a.addressField(code))
+		if v == "" {
+			// Drop the separator before the empty field
+			literal = ""
+			hasCode = true
+			continue
+		}
+		if strings.IndexByte(upper, code) >= 0 {
+			v = strings.ToUpper(v)
+		}
+		if hasField || !hasCode {
+			sb.WriteString(literal)
+		}
+		literal = ""
+		sb.WriteString(v)
+		hasField, hasCode = true, true
+	}
+	if hasField {
+		sb.WriteString(strings.TrimRight(literal, ", -"))
+	}
+	return strings.TrimSpace(sb.String())
+}
+
+func (a *Address) addressField(code byte) string {
+	switch code {
+	case 'C':
+		return a.Locality
+	case 'D':
+		return joinNonEmpty(" ", a.District, a.Subdistrict)
+	case 'S':
+		return a.Region
+	case 'Z':
+		return a.PostalCode
+	default:
+		return ""
+	}
+}
+
+func addressCountryCode(country string) string {
+	country = strings.TrimSpace(country)
+	if len(country) == 2 {
+		return strings.ToUpper(country)
+	}
+	return countryCodes[strings.ToLower(country)]
+}
+
+func joinNonEmpty(sep string, l ...string) string {
+	var parts []string
+	for _, s := range l {
+		if s = strings.TrimSpace(s); s != "" {
+			parts = append(parts, s)
+		}
+	}
+	return strings.Join(parts, sep)
+}
@@ -0,0 +1,121 @@
+package vcard
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestAddress_Format(t *testing.T) {
+	tests := []struct {
+		name    string
+		addr    Address
+		country string
+		want    string
+	}{
+		{
+			name:    "US",
+			addr:    Address{StreetAddress: "1600 Amphitheatre Parkway", Locality: "Mountain View", Region: "CA", PostalCode: "94043", Country: "USA"},
+			country: "",
+			want:    "1600 Amphitheatre Parkway\nMOUNTAIN VIEW, CA 94043\nUSA",
+		},
+		{
+			name:    "USWithoutRegion",
+			addr:    Address{PostOfficeBox: "PO Box 42", StreetAddress: "Main Street", Locality: "Springfield", PostalCode: "12345"},
+			country: "us",
+			want:    "PO Box 42\nMain Street\nSPRINGFIELD 12345",
+		},
+		{
+			name:    "DE",
+			addr:    Address{ExtendedAddress: "c/o Müller", StreetName: "Hauptstraße", StreetNumber: "5", Locality: "Berlin", PostalCode: "10115", Country: "Deutschland"},
+			country: "",
+			want:    "c/o Müller\n5 Hauptstraße\n10115 Berlin\nDeutschland",
+		},
+		{
+			name:    "GB",
+			addr:    Address{StreetAddress: "10 Downing Street", Locality: "London", PostalCode: "SW1A 2AA"},
+			country: "GB",
+			want:    "10 Downing Street\nLONDON\nSW1A 2AA",
+		},
+		{
+			name:    "JP",
+			addr:    Address{StreetAddress: "丸の内1-1", Locality: "千代田区", Region: "東京都", PostalCode: "100-0005", Country: "日本"},
+			country: "",
+			want:    "〒100-0005\n東京都千代田区\n丸の内1-1\n日本",
+		},
+		{
+			name:    "MXWithoutLocality",
+			addr:    Address{StreetAddress: "Av. Reforma 1", PostalCode: "06600", Region: "CDMX"},
+			country: "MX",
+			want:    "Av. Reforma 1\n06600, CDMX",
+		},
+		{
+			name:    "Unknown",
+			addr:    Address{StreetAddress: "1 Rue Principale\nBâtiment B", Locality: "Somewhere", PostalCode: "1234", Country: "Atlantis"},
+			country: "",
+			want:    "1 Rue Principale\nBâtiment B\nSomewhere 1234\nAtlantis",
+		},
+		{
+			name: "No country",
+			addr: Address{StreetAddress: "1 Main St", Locality: "Springfield", Region: "IL", PostalCode: "62701"},
+			want: "1 Main St\nSpringfield IL 62701",
+		},
+		{
+			name: "Singapore",
+			addr: Address{StreetAddress: "1 Raffles Place", PostalCode: "048616", Country: "Singapore"},
+			want: "1 Raffles Place\n048616\nSingapore",
+		},
+		{
+			name: "Unknown with district",
+			addr: Address{StreetAddress: "2 Main St", District: "Old Town", Locality: "Somewhere", Region: "North", Country: "Atlantis"},
+			want: "2 Main St\nOld Town\nSomewhere North\nAtlantis",
+		},
+		{
+			name:    "RFC 9554 components",
+			addr:    Address{Room: "Room 12", Apartment: "Apt 4", Floor: "Floor 2", StreetNumber: "10", StreetName: "Downing Street", Building: "Main Building", Block: "Block A", Landmark: "Near the park", Direction: "Second door on the left", Locality: "London", PostalCode: "SW1A 2AA"},
+			country: "GB",
+			want:    "Room 12, Apt 4, Floor 2, 10 Downing Street, Main Building, Block A, Near the park, Second door on the left\nLONDON\nSW1A 2AA",
+		},
+		{
+			name:    "Street address with RFC 9554 components",
+			addr:    Address{StreetAddress: "10 Downing Street", StreetNumber: "10", StreetName: "Downing Street", Floor: "Floor 2", Locality: "London"},
+			country: "GB",
+			want:    "10 Downing Street\nLONDON",
+		},
+		{
+			name: "Empty",
+			addr: Address{},
+			want: "",
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := tc.addr.Format(tc.country); got != tc.want {
+				t.Errorf("Format(%q) = %q, want %q", tc.country, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestAddress_FillLabel(t *testing.T) {
+	card := Card{FieldVersion: {{Value: "4.0"}}}
+	card.AddAddress(&Address{StreetAddress: "1 Main Street", Locality: "Springfield", Region: "IL", PostalCode: "62701"})
+
+	addr := card.Address()
+	addr.FillLabel("US")
+	want := "1 Main Street\nSPRINGFIELD, IL 62701"
+	if label := card[FieldAddress][0].Params.Get(ParamLabel); label != want {
+		t.Fatalf("FillLabel(): LABEL = %q, want %q", label, want)
+	}
+
+	var b bytes.Buffer
+	if err := NewEncoder(&b).Encode(card); err != nil {
+		t.Fatalf("Encode() = %v", err)
+	}
+	decoded, err := NewDecoder(&b).Decode()
+	if err != nil {
+		t.Fatalf("Decode() = %v", err)
+	}
+	if label := decoded[FieldAddress][0].Params.Get(ParamLabel); label != want {
+		t.Errorf("LABEL after encoding and decoding = %q, want %q", label, want)
+	}
+}
@@ -0,0 +1,440 @@
+package vcard
+
+import (
+	"errors"
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+	"unicode/utf8"
+)
+
+// An AddressBook is an in-memory collection of cards, indexed by UID, email
+// address, phone number and name. It is safe for concurrent use.
+//
+// Cards must not be modified after they have been added to the address book:
+// to update a card, call Put with a new card.
+type AddressBook struct {
+	mutex   sync.RWMutex
+	entries map[string]*addressBookEntry
+	// normalized member URIs of the UIDs, see uidKeys
+	uids   index
+	emails index
+	phones index
+	tokens index
+	// sorted list of the keys of tokens, used for prefix search
+	sortedTokens []string
+	// sorted keys of tokens by number of characters, used for fuzzy search
+	tokensByLength map[int][]string
+}
+
+type addressBookEntry struct {
+	card                   Card
+	emails, phones, tokens []string
+}
+
+// index maps a key to a set of UIDs.
+type index map[string]map[string]struct{}
+
+func (idx index) add(k, uid string) (created bool) {
+	uids, ok := idx[k]
+	if !ok {
+		uids = make(map[string]struct{})
+		idx[k] = uids
+	}
+	uids[uid] = struct{}{}
+	return !ok
+}
+
+func (idx index) remove(k, uid string) (deleted bool) {
+	uids := idx[k]
+	delete(uids, uid)
+	if len(uids) > 0 {
+		return false
+	}
+	delete(idx, k)
+	return true
+}
+
+// NewAddressBook creates a new empty address book.
+func NewAddressBook() *AddressBook {
+	return &AddressBook{
+		entries:        make(map[string]*addressBookEntry),
+		uids:           make(index),
+		emails:         make(index),
+		phones:         make(index),
+		tokens:         make(index),
+		tokensByLength: make(map[int][]string),
+	}
+}
+
+// Len returns the number of cards in the address book.
+func (ab *AddressBook) Len() int {
+	ab.mutex.RLock()
+	defer ab.mutex.RUnlock()
+	return len(ab.entries)
+}
+
+// Put adds a card to the address book. If a card with the same UID already
+// exists, it's replaced. The card must have a UID.
+func (ab *AddressBook) Put(card Card) error {
+	uid := card.Value(FieldUID)
+	if uid == "" {
+		return errors.New("vcard: cannot add card without UID to address book")
+	}
+
+	entry := &addressBookEntry{card: card}
+	for _, v := range card.Values(FieldEmail) {
+		if email := normalizeEmail(v); email != "" {
+			entry.emails = append(entry.emails, email)
+		}
+	}
+	for _, v := range card.Values(FieldTelephone) {
+		if tel := normalizePhone(v); tel != "" {
+			entry.phones = append(entry.phones, tel)
+		}
+	}
+	entry.tokens = nameTokens(card)
+
+	ab.mutex.Lock()
+	defer ab.mutex.Unlock()
+	ab.remove(uid)
+	ab.entries[uid] = entry
+	for _, k := range uidKeys(uid) {
+		ab.uids.add(k, uid)
+	}
+	for _, email := range entry.emails {
+		ab.emails.add(email, uid)
+	}
+	for _, tel := range entry.phones {
+		ab.phones.add(tel, uid)
+	}
+	for _, tok := range entry.tokens {
+		if ab.tokens.add(tok, uid) {
+			n := utf8.RuneCountInString(tok)
+			ab.sortedTokens = insertSorted(ab.sortedTokens, tok)
+			ab.tokensByLength[n] = insertSorted(ab.tokensByLength[n], tok)
+		}
+	}
+	return nil
+}
+
+// Delete removes the card with the specified UID. It returns false if there
+// was no such card.
+func (ab *AddressBook) Delete(uid string) bool {
+	ab.mutex.Lock()
+	defer ab.mutex.Unlock()
+	return ab.remove(uid)
+}
+
+func (ab *AddressBook) remove(uid string) bool {
+	entry, ok := ab.entries[uid]
+	if !ok {
+		return false
+	}
+	delete(ab.entries, uid)
+	for _, k := range uidKeys(uid) {
+		ab.uids.remove(k, uid)
+	}
+	for _, email := range entry.emails {
+		ab.emails.remove(email, uid)
+	}
+	for _, tel := range entry.phones {
+		ab.phones.remove(tel, uid)
+	}
+	for _, tok := range entry.tokens {
+		if ab.tokens.remove(tok, uid) {
+			n := utf8.RuneCountInString(tok)
+			ab.sortedTokens = removeSorted(ab.sortedTokens, tok)
+			if l := removeSorted(ab.tokensByLength[n], tok); len(l) > 0 {
+				ab.tokensByLength[n] = l
+			} else {
+				delete(ab.tokensByLength, n)
+			}
+		}
+	}
+	return true
+}
+
+// Get returns the card with the specified UID, or nil if there is no such
+// card.
+func (ab *AddressBook) Get(uid string) Card {
+	ab.mutex.RLock()
+	defer ab.mutex.RUnlock()
+	if entry, ok := ab.entries[uid]; ok {
+		return entry.card
+	}
+	return nil
+}
+
+// Cards returns all cards in the address book, sorted by UID.
+func (ab *AddressBook) Cards() []Card {
+	ab.mutex.RLock()
+	defer ab.mutex.RUnlock()
+	uids := make([]string, 0, len(ab.entries))
+	for uid := range ab.entries {
+		uids = append(uids, uid)
+	}
+	return ab.cardsByUID(uids)
+}
+
+// FindByEmail returns the cards with the specified email address. The
+// comparison is case-insensitive.
+func (ab *AddressBook) FindByEmail(email string) []Card {
+	return ab.find(ab.emails, normalizeEmail(email))
+}
+
+// FindByPhone returns the cards with the specified phone number. Phone
+// numbers are compared after removing formatting characters, e.g.
+// "+1 (555) 010-0000" matches "tel:+1-555-010-0000".
+func (ab *AddressBook) FindByPhone(tel string) []Card {
+	return ab.find(ab.phones, normalizePhone(tel))
+}
+
+func (ab *AddressBook) find(idx index, k string) []Card {
+	if k == "" {
+		return nil
+	}
+	ab.mutex.RLock()
+	defer ab.mutex.RUnlock()
+	var uids []string
+	for uid := range idx[k] {
+		uids = append(uids, uid)
+	}
+	return ab.cardsByUID(uids)
+}
+
+func (ab *AddressBook) cardsByUID(uids []string) []Card {
+	if len(uids) == 0 {
+		return nil
+	}
+	sort.Strings(uids)
+	cards := make([]Card, len(uids))
+	for i, uid := range uids {
+		cards[i] = ab.entries[uid].card
+	}
+	return cards
+}
+
+// Scores of a query token matching a name token.
+const (
+	scoreFuzzy  = 1
+	scorePrefix = 2
+	scoreExact  = 3
+)
+
+// A SearchResult is a card returned by AddressBook.Search.
+type SearchResult struct {
+	Card Card
+	// Score is the relevance of the card. Higher is better.
+	Score int
+}
+
+// Search returns the cards whose names match the query. The formatted name,
+// name components and nicknames are searched.
+//
+// Each word of the query must match a word of the card name, either exactly,
+// as a prefix, or with a few typos. Results are sorted by decreasing score.
+// If limit is positive, at most limit results are returned.
+func (ab *AddressBook) Search(query string, limit int) []SearchResult {
+	queryTokens := tokenize(query)
+	if len(queryTokens) == 0 {
+		return nil
+	}
+
+	ab.mutex.RLock()
+	defer ab.mutex.RUnlock()
+
+	var scores map[string]int
+	for _, q := range queryTokens {
+		tokenScores := ab.searchToken(q)
+		if scores == nil {
+			scores = tokenScores
+			continue
+		}
+		for uid, score := range scores {
+			if s, ok := tokenScores[uid]; ok {
+				scores[uid] = score + s
+			} else {
+				delete(scores, uid)
+			}
+		}
+	}
+
+	if len(scores) == 0 {
+		return nil
+	}
+	results := make([]SearchResult, 0, len(scores))
+	for uid, score := range scores {
+		results = append(results, SearchResult{ab.entries[uid].card, score})
+	}
+	sort.Slice(results, func(i, j int) bool {
+		a, b := results[i], results[j]
+		if a.Score != b.Score {
+			return a.Score > b.Score
+		}
+		if fa, fb := a.Card.Value(FieldFormattedName), b.Card.Value(FieldFormattedName); fa != fb {
+			return fa < fb
+		}
+		return a.Card.Value(FieldUID) < b.Card.Value(FieldUID)
+	})
+	if limit > 0 && len(results) > limit {
+		results = results[:limit]
+	}
+	return results
+}
+
+// searchToken returns the best score of the query token q for each card.
+func (ab *AddressBook) searchToken(q string) map[string]int {
+	scores := make(map[string]int)
+	match := func(tok string, score int) {
+		for uid := range ab.tokens[tok] {
+			if score > scores[uid] {
+				scores[uid] = score
+			}
+		}
+	}
+
+	for i := sort.SearchStrings(ab.sortedTokens, q); i < len(ab.sortedTokens); i++ {
+		tok := ab.sortedTokens[i]
+		if !strings.HasPrefix(tok, q) {
+			break
+		}
+		if tok == q {
+			match(tok, scoreExact)
+		} else {
+			match(tok, scorePrefix)
+		}
+	}
+
+	qr := []rune(q)
+	maxDist := maxEditDistance(len(qr))
+	if maxDist == 0 {
+		return scores
+	}
+	// Tokens whose length differs by more than maxDist are too far
+	for n := len(qr) - maxDist; n <= len(qr)+maxDist; n++ {
+		for _, tok := range ab.tokensByLength[n] {
+			if dist := editDistance(qr, []rune(tok)); dist > 0 && dist <= maxDist {
+				match(tok, scoreFuzzy)
+			}
+		}
+	}
+	return scores
+}
+
+// insertSorted inserts s in the sorted list l, which doesn't contain s.
+func insertSorted(l []string, s string) []string {
+	i := sort.SearchStrings(l, s)
+	l = append(l, "")
+	copy(l[i+1:], l[i:])
+	l[i] = s
+	return l
+}
+
+// removeSorted removes s from the sorted list l, which contains s.
+func removeSorted(l []string, s string) []string {
+	i := sort.SearchStrings(l, s)
+	return append(l[:i], l[i+1:]...)
+}
+
+// maxEditDistance returns the number of typos tolerated in a word of n
+// characters.
+func maxEditDistance(n int) int {
+	switch {
+	case n >= 8:
+		return 2
+	case n >= 4:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// editDistance returns the Levenshtein distance between a and b.
+func editDistance(a, b []rune) int {
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min3(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(b)]
+}
+
+func min3(a, b, c int) int {
+	if b < a {
+		a = b
+	}
+	if c < a {
+		a = c
+	}
+	return a
+}
+
+// nameTokens returns the distinct words of the card's names.
+func nameTokens(card Card) []string {
+	var values []string
+	values = append(values, card.Values(FieldFormattedName)...)
+	values = append(values, card.Values(FieldName)...)
+	values = append(values, card.Values(FieldNickname)...)
+
+	seen := make(map[string]bool)
+	var tokens []string
+	for _, v := range values {
+		for _, tok := range tokenize(v) {
+			if !seen[tok] {
+				seen[tok] = true
+				tokens = append(tokens, tok)
+			}
+		}
+	}
+	return tokens
+}
+
+func tokenize(s string) []string {
+	return strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+func normalizeEmail(s string) string {
+	s = strings.TrimSpace(s)
+	if len(s) > 7 && strings.EqualFold(s[:7], "mailto:") {
+		s = s[7:]
+	}
+	return strings.ToLower(s)
+}
+
+// normalizePhone strips the "tel:" prefix, URI parameters such as
+// extensions and visual separators from a phone number.
+func normalizePhone(s string) string {
+	s = strings.TrimSpace(s)
+	if len(s) > 4 && strings.EqualFold(s[:4], "tel:") {
+		s = s[4:]
+	}
+	if i := strings.IndexByte(s, ';'); i >= 0 {
+		s = s[:i]
+	}
+
+	var sb strings.Builder
+	for i, r := range s {
+		if r >= '0' && r <= '9' || r == '+' && i == 0 {
+			sb.WriteRune(r)
+		}
+	}
+	if sb.Len() == 1 && s[0] == '+' {
+		return ""
+	}
+	return sb.String()
+}
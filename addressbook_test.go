@@ -0,0 +1,162 @@
+package vcard
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+	"testing"
+)
+
+func newAddressBookTestCard(uid, fn string) Card {
+	card := Card{
+		FieldUID:           {{Value: uid}},
+		FieldFormattedName: {{Value: fn}},
+	}
+	return card
+}
+
+func addressBookUIDs(cards []Card) []string {
+	var uids []string
+	for _, card := range cards {
+		uids = append(uids, card.Value(FieldUID))
+	}
+	return uids
+}
+
+func TestAddressBook(t *testing.T) {
+	ab := NewAddressBook()
+
+	if err := ab.Put(Card{FieldFormattedName: {{Value: "No UID"}}}); err == nil {
+		t.Error("Put() with card without UID: expected an error")
+	}
+
+	alice := newAddressBookTestCard("alice", "Alice Liddell")
+	alice.AddValue(FieldEmail, "Alice@Example.org")
+	alice.AddValue(FieldTelephone, "tel:+1-555-010-0000;ext=12")
+	bob := newAddressBookTestCard("bob", "Bob Smith")
+	bob.AddValue(FieldEmail, "bob@example.org")
+	for _, card := range []Card{alice, bob} {
+		if err := ab.Put(card); err != nil {
+			t.Fatalf("Put() = %v", err)
+		}
+	}
+
+	if n := ab.Len(); n != 2 {
+		t.Errorf("Len() = %v, want 2", n)
+	}
+	if got := ab.Get("alice"); !reflect.DeepEqual(got, alice) {
+		t.Errorf("Get() = %v, want %v", got, alice)
+	}
+	if got, want := addressBookUIDs(ab.Cards()), []string{"alice", "bob"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Cards() = %v, want %v", got, want)
+	}
+	if got, want := addressBookUIDs(ab.FindByEmail("mailto:alice@EXAMPLE.org")), []string{"alice"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("FindByEmail() = %v, want %v", got, want)
+	}
+	if got, want := addressBookUIDs(ab.FindByPhone("+1 (555) 010 0000")), []string{"alice"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("FindByPhone() = %v, want %v", got, want)
+	}
+
+	// Replacing a card updates the indexes
+	alice2 := newAddressBookTestCard("alice", "Alice Kingsleigh")
+	if err := ab.Put(alice2); err != nil {
+		t.Fatalf("Put() = %v", err)
+	}
+	if got := ab.FindByEmail("alice@example.org"); got != nil {
+		t.Errorf("FindByEmail() after update = %v, want nil", addressBookUIDs(got))
+	}
+	if got := ab.Search("liddell", 0); got != nil {
+		t.Errorf("Search() after update = %v, want nil", got)
+	}
+	if got := ab.Search("kingsleigh", 0); len(got) != 1 {
+		t.Errorf("Search() after update = %v, want 1 result", got)
+	}
+
+	if !ab.Delete("bob") {
+		t.Error("Delete() = false, want true")
+	}
+	if ab.Delete("bob") {
+		t.Error("Delete() on missing card = true, want false")
+	}
+	if ab.Get("bob") != nil || ab.FindByEmail("bob@example.org") != nil || ab.Search("bob", 0) != nil {
+		t.Error("deleted card still in address book")
+	}
+}
+
+func TestAddressBook_Search(t *testing.T) {
+	ab := NewAddressBook()
+	for _, card := range []Card{
+		newAddressBookTestCard("1", "Jonathan Harker"),
+		newAddressBookTestCard("2", "John Seward"),
+		newAddressBookTestCard("3", "Johnny Cash"),
+		newAddressBookTestCard("4", "Mina Murray"),
+	} {
+		if err := ab.Put(card); err != nil {
+			t.Fatalf("Put() = %v", err)
+		}
+	}
+	nick := newAddressBookTestCard("5", "Abraham Van Helsing")
+	nick.AddValue(FieldNickname, "Jack")
+	if err := ab.Put(nick); err != nil {
+		t.Fatalf("Put() = %v", err)
+	}
+
+	tests := []struct {
+		query string
+		limit int
+		want  []string
+	}{
+		{"john", 0, []string{"2", "3"}},
+		{"jon", 0, []string{"1"}},
+		{"john", 1, []string{"2"}},
+		{"jo ha", 0, []string{"1"}},
+		{"jonathn", 0, []string{"1"}},
+		{"murry", 0, []string{"4"}},
+		{"jack", 0, []string{"5"}},
+		{"", 0, nil},
+		{"xyz", 0, nil},
+	}
+	for _, test := range tests {
+		results := ab.Search(test.query, test.limit)
+		var got []string
+		for _, res := range results {
+			got = append(got, res.Card.Value(FieldUID))
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("Search(%q, %v) = %v, want %v", test.query, test.limit, got, test.want)
+		}
+	}
+
+	ab.Delete("4")
+	if results := ab.Search("murry", 0); len(results) != 0 {
+		t.Errorf("Search() after Delete() = %v, want none", results)
+	}
+	for _, tok := range ab.tokensByLength[len("murray")] {
+		if tok == "murray" {
+			t.Errorf("Delete() didn't remove the token from the length index")
+		}
+	}
+}
+
+func TestAddressBook_concurrent(t *testing.T) {
+	ab := NewAddressBook()
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				uid := fmt.Sprintf("%v-%v", i, j)
+				ab.Put(newAddressBookTestCard(uid, "Card "+uid))
+				ab.Search("card", 10)
+				if j%2 == 0 {
+					ab.Delete(uid)
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+	if n := ab.Len(); n != 8*25 {
+		t.Errorf("Len() = %v, want %v", n, 8*25)
+	}
+}
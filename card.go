@@ -2,6 +2,7 @@
 package vcard
 
 import (
+	"sort"
 	"strconv"
 	"strings"
 	"time"
@@ -13,7 +14,32 @@ const (
 	Extension = "vcf"
 )
 
-const timestampLayout = "20060102T150405Z"
+const (
+	timestampLayout = "20060102T150405Z"
+	dateLayout      = "20060102"
+)
+
+var dateTimeLayouts = []string{
+	dateLayout,
+	"2006-01-02",
+	"20060102T150405Z0700",
+	"20060102T150405Z07",
+	"20060102T150405",
+	"2006-01-02T15:04:05Z07:00",
+}
+
+// parseDateTime parses a date or a date-time value. Truncated values, e.g.
+// dates without a year, aren't supported.
+func parseDateTime(s string) (time.Time, error) {
+	var err error
+	for _, layout := range dateTimeLayouts {
+		var t time.Time
+		if t, err = time.Parse(layout, s); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, err
+}
 
 // Card property parameters.
 const (
@@ -28,6 +54,26 @@ const (
 	ParamSortAs        = "SORT-AS"
 	ParamGeolocation   = "GEO"
 	ParamTimezone      = "TZ"
+	ParamLabel         = "LABEL"
+
+	// vCard 3.0 parameters, defined in RFC 2426
+	ParamEncoding = "ENCODING"
+	ParamCharset  = "CHARSET"
+
+	// Parameters defined in RFC 6715
+	ParamLevel = "LEVEL"
+	ParamIndex = "INDEX"
+
+	// Parameters defined in RFC 9554
+	ParamAuthor      = "AUTHOR"
+	ParamAuthorName  = "AUTHOR-NAME"
+	ParamCreated     = "CREATED"
+	ParamDerived     = "DERIVED"
+	ParamPhonetic    = "PHONETIC"
+	ParamPropID      = "PROP-ID"
+	ParamScript      = "SCRIPT"
+	ParamServiceType = "SERVICE-TYPE"
+	ParamUsername    = "USERNAME"
 )
 
 // Card properties.
@@ -48,6 +94,7 @@ const (
 
 	// Delivery Addressing Properties
 	FieldAddress = "ADR"
+	FieldLabel   = "LABEL" // vCard 3.0 only
 
 	// Communications Properties
 	FieldTelephone = "TEL"
@@ -85,6 +132,24 @@ const (
 	FieldFreeOrBusyURL      = "FBURL"
 	FieldCalendarAddressURI = "CALADRURI"
 	FieldCalendarURI        = "CALURI"
+
+	// Place and Date of Birth and Death Properties, defined in RFC 6474
+	FieldBirthPlace = "BIRTHPLACE"
+	FieldDeathPlace = "DEATHPLACE"
+	FieldDeathDate  = "DEATHDATE"
+
+	// Personal Information Properties, defined in RFC 6715
+	FieldExpertise    = "EXPERTISE"
+	FieldHobby        = "HOBBY"
+	FieldInterest     = "INTEREST"
+	FieldOrgDirectory = "ORG-DIRECTORY"
+
+	// Properties defined in RFC 9554
+	FieldCreated           = "CREATED"
+	FieldGrammaticalGender = "GRAMGENDER"
+	FieldDefaultLanguage   = "LANGUAGE"
+	FieldPronouns          = "PRONOUNS"
+	FieldSocialProfile     = "SOCIALPROFILE"
 )
 
 func maybeGet(l []string, i int) string {
@@ -322,6 +387,205 @@ func (c Card) SetRevision(t time.Time) {
 	c.SetValue(FieldRevision, t.Format(timestampLayout))
 }
 
+// Created returns the time the card was created, defined in RFC 9554.
+func (c Card) Created() (time.Time, error) {
+	created := c.Value(FieldCreated)
+	if created == "" {
+		return time.Time{}, nil
+	}
+	return time.Parse(timestampLayout, created)
+}
+
+// SetCreated sets the time the card was created.
+func (c Card) SetCreated(t time.Time) {
+	c.SetValue(FieldCreated, t.Format(timestampLayout))
+}
+
+// BirthPlace returns the place of birth of the object represented by this
+// card, defined in RFC 6474. It's either free-form text or a URI if the
+// ParamValue parameter is set to "uri".
+func (c Card) BirthPlace() string {
+	return c.PreferredValue(FieldBirthPlace)
+}
+
+// SetBirthPlace sets the place of birth of the object represented by this
+// card.
+func (c Card) SetBirthPlace(place string) {
+	c.SetValue(FieldBirthPlace, place)
+}
+
+// DeathPlace returns the place of death of the object represented by this
+// card, defined in RFC 6474. It's either free-form text or a URI if the
+// ParamValue parameter is set to "uri".
+func (c Card) DeathPlace() string {
+	return c.PreferredValue(FieldDeathPlace)
+}
+
+// SetDeathPlace sets the place of death of the object represented by this
+// card.
+func (c Card) SetDeathPlace(place string) {
+	c.SetValue(FieldDeathPlace, place)
+}
+
+// DeathDate returns the date of death of the object represented by this card,
+// defined in RFC 6474. If it isn't specified, it returns a zero time.
+func (c Card) DeathDate() (time.Time, error) {
+	v := c.PreferredValue(FieldDeathDate)
+	if v == "" {
+		return time.Time{}, nil
+	}
+	return parseDateTime(v)
+}
+
+// SetDeathDate sets the date of death of the object represented by this card.
+func (c Card) SetDeathDate(t time.Time) {
+	c.SetValue(FieldDeathDate, t.Format(dateLayout))
+}
+
+// Expertise returns the professional subject areas the object represented by
+// this card has knowledge of, defined in RFC 6715.
+func (c Card) Expertise() []*Interest {
+	return c.interests(FieldExpertise)
+}
+
+// AddExpertise adds a professional subject area to the card.
+func (c Card) AddExpertise(expertise *Interest) {
+	c.Add(FieldExpertise, expertise.field())
+}
+
+// Hobbies returns the recreational activities the object represented by this
+// card actively engages in, defined in RFC 6715.
+func (c Card) Hobbies() []*Interest {
+	return c.interests(FieldHobby)
+}
+
+// AddHobby adds a recreational activity to the card.
+func (c Card) AddHobby(hobby *Interest) {
+	c.Add(FieldHobby, hobby.field())
+}
+
+// Interests returns the recreational activities the object represented by
+// this card is interested in, but doesn't necessarily take part in, defined in
+// RFC 6715.
+func (c Card) Interests() []*Interest {
+	return c.interests(FieldInterest)
+}
+
+// AddInterest adds a recreational activity to the card.
+func (c Card) AddInterest(interest *Interest) {
+	c.Add(FieldInterest, interest.field())
+}
+
+func (c Card) interests(k string) []*Interest {
+	fields := sortByIndex(c[k])
+	if fields == nil {
+		return nil
+	}
+
+	interests := make([]*Interest, len(fields))
+	for i, f := range fields {
+		interests[i] = newInterest(f)
+	}
+	return interests
+}
+
+// OrgDirectories returns the URIs of the directories of the organizations the
+// object represented by this card belongs to, defined in RFC 6715. They are
+// sorted by their ParamIndex parameter.
+func (c Card) OrgDirectories() []string {
+	fields := sortByIndex(c[FieldOrgDirectory])
+	if fields == nil {
+		return nil
+	}
+
+	uris := make([]string, len(fields))
+	for i, f := range fields {
+		uris[i] = f.Value
+	}
+	return uris
+}
+
+// AddOrgDirectory adds an organization directory URI to the card.
+func (c Card) AddOrgDirectory(uri string) {
+	c.AddValue(FieldOrgDirectory, uri)
+}
+
+// GrammaticalGender returns the grammatical gender to use when addressing the
+// object represented by this card, defined in RFC 9554.
+func (c Card) GrammaticalGender() GrammaticalGender {
+	return GrammaticalGender(strings.ToLower(c.PreferredValue(FieldGrammaticalGender)))
+}
+
+// SetGrammaticalGender sets the grammatical gender to use when addressing the
+// object represented by this card.
+func (c Card) SetGrammaticalGender(gender GrammaticalGender) {
+	c.SetValue(FieldGrammaticalGender, string(gender))
+}
+
+// DefaultLanguage returns the language text values of this card are written
+// in when the ParamLanguage parameter is missing, defined in RFC 9554.
+func (c Card) DefaultLanguage() string {
+	return c.Value(FieldDefaultLanguage)
+}
+
+// SetDefaultLanguage sets the language text values of this card are written
+// in when the ParamLanguage parameter is missing.
+func (c Card) SetDefaultLanguage(lang string) {
+	c.SetValue(FieldDefaultLanguage, lang)
+}
+
+// Pronouns returns the preferred pronouns to use when addressing the object
+// represented by this card, defined in RFC 9554.
+func (c Card) Pronouns() string {
+	return c.PreferredValue(FieldPronouns)
+}
+
+// SetPronouns sets the pronouns to use when addressing the object represented
+// by this card.
+func (c Card) SetPronouns(pronouns string) {
+	c.SetValue(FieldPronouns, pronouns)
+}
+
+// SocialProfiles returns the social media profiles of the card, defined in
+// RFC 9554.
+func (c Card) SocialProfiles() []*SocialProfile {
+	fields := c[FieldSocialProfile]
+	if fields == nil {
+		return nil
+	}
+
+	profiles := make([]*SocialProfile, len(fields))
+	for i, f := range fields {
+		profiles[i] = newSocialProfile(f)
+	}
+	return profiles
+}
+
+// AddSocialProfile adds a social media profile to the card.
+func (c Card) AddSocialProfile(profile *SocialProfile) {
+	c.Add(FieldSocialProfile, profile.field())
+}
+
+// Related returns the relationships of the object represented by this card
+// with other entities.
+func (c Card) Related() []*Related {
+	fields := c[FieldRelated]
+	if fields == nil {
+		return nil
+	}
+
+	related := make([]*Related, len(fields))
+	for i, f := range fields {
+		related[i] = newRelated(f)
+	}
+	return related
+}
+
+// AddRelated adds a relationship to the card.
+func (c Card) AddRelated(related *Related) {
+	c.Add(FieldRelated, related.field())
+}
+
 // A field contains a value and some parameters.
 type Field struct {
 	Value  string
@@ -432,6 +696,10 @@ type Name struct {
 	AdditionalName  string
 	HonorificPrefix string
 	HonorificSuffix string
+
+	// Components defined in RFC 9554
+	SecondaryFamilyName string
+	Generation          string // e.g., "Jr." or "III"
 }
 
 func newName(field *Field) *Name {
@@ -443,6 +711,8 @@ func newName(field *Field) *Name {
 		maybeGet(components, 2),
 		maybeGet(components, 3),
 		maybeGet(components, 4),
+		maybeGet(components, 5),
+		maybeGet(components, 6),
 	}
 }
 
@@ -450,16 +720,216 @@ func (n *Name) field() *Field {
 	if n.Field == nil {
 		n.Field = new(Field)
 	}
-	n.Field.Value = strings.Join([]string{
+	n.Field.Value = joinComponents([]string{
 		n.FamilyName,
 		n.GivenName,
 		n.AdditionalName,
 		n.HonorificPrefix,
 		n.HonorificSuffix,
-	}, ";")
+	}, []string{
+		n.SecondaryFamilyName,
+		n.Generation,
+	})
 	return n.Field
 }
 
+// joinComponents formats a structured value. The extra components defined in
+// RFC 9554 are omitted if they are all empty, to keep the value readable by
+// RFC 6350 implementations.
+func joinComponents(components, extra []string) string {
+	for _, c := range extra {
+		if c != "" {
+			components = append(components, extra...)
+			break
+		}
+	}
+	return strings.Join(components, ";")
+}
+
+// Level is a level of expertise or of interest, defined in RFC 6715.
+type Level string
+
+// Values for ParamLevel.
+const (
+	// For FieldExpertise
+	LevelBeginner Level = "beginner"
+	LevelAverage  Level = "average"
+	LevelExpert   Level = "expert"
+
+	// For FieldHobby and FieldInterest
+	LevelHigh   Level = "high"
+	LevelMedium Level = "medium"
+	LevelLow    Level = "low"
+)
+
+// An Interest is an expertise, a hobby or an interest, defined in RFC 6715.
+// The value of the field is the subject area or activity, e.g. "chess".
+type Interest struct {
+	*Field
+
+	Level Level
+	Index int // position in the list, starting at 1, or zero if unspecified
+}
+
+func newInterest(field *Field) *Interest {
+	return &Interest{
+		Field: field,
+		Level: Level(strings.ToLower(field.Params.Get(ParamLevel))),
+		Index: fieldIndex(field),
+	}
+}
+
+func (i *Interest) field() *Field {
+	if i.Field == nil {
+		i.Field = new(Field)
+	}
+	if i.Field.Params == nil {
+		i.Field.Params = make(Params)
+	}
+	if i.Level != "" {
+		i.Field.Params.Set(ParamLevel, string(i.Level))
+	} else {
+		delete(i.Field.Params, ParamLevel)
+	}
+	if i.Index > 0 {
+		i.Field.Params.Set(ParamIndex, strconv.Itoa(i.Index))
+	} else {
+		delete(i.Field.Params, ParamIndex)
+	}
+	return i.Field
+}
+
+func fieldIndex(f *Field) int {
+	index, err := strconv.Atoi(f.Params.Get(ParamIndex))
+	if err != nil || index < 0 {
+		return 0
+	}
+	return index
+}
+
+// sortByIndex returns a copy of fields sorted by their ParamIndex parameter.
+// Fields without an index are kept last, in their original order.
+func sortByIndex(fields []*Field) []*Field {
+	if fields == nil {
+		return nil
+	}
+
+	sorted := make([]*Field, len(fields))
+	copy(sorted, fields)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		a, b := fieldIndex(sorted[i]), fieldIndex(sorted[j])
+		if a == 0 || b == 0 {
+			return a != 0 && b == 0
+		}
+		return a < b
+	})
+	return sorted
+}
+
+// GrammaticalGender is the grammatical gender to use in salutations and other
+// grammatical constructs, defined in RFC 9554.
+type GrammaticalGender string
+
+// Values for FieldGrammaticalGender.
+const (
+	GrammaticalGenderAnimate   GrammaticalGender = "animate"
+	GrammaticalGenderCommon    GrammaticalGender = "common"
+	GrammaticalGenderFeminine  GrammaticalGender = "feminine"
+	GrammaticalGenderInanimate GrammaticalGender = "inanimate"
+	GrammaticalGenderMasculine GrammaticalGender = "masculine"
+	GrammaticalGenderNeuter    GrammaticalGender = "neuter"
+)
+
+// Values for ParamPhonetic.
+const (
+	PhoneticIPA      = "ipa"
+	PhoneticJyutping = "jyut"
+	PhoneticPinyin   = "piny"
+	PhoneticScript   = "script"
+)
+
+// A SocialProfile is a social media profile, defined in RFC 9554. The value of
+// the field is the profile URI, or the user name if the ParamValue parameter
+// is set to "text".
+type SocialProfile struct {
+	*Field
+
+	ServiceType string // e.g. "Mastodon"
+	Username    string
+}
+
+func newSocialProfile(field *Field) *SocialProfile {
+	return &SocialProfile{
+		Field:       field,
+		ServiceType: field.Params.Get(ParamServiceType),
+		Username:    field.Params.Get(ParamUsername),
+	}
+}
+
+func (p *SocialProfile) field() *Field {
+	if p.Field == nil {
+		p.Field = new(Field)
+	}
+	if p.Field.Params == nil {
+		p.Field.Params = make(Params)
+	}
+	if p.ServiceType != "" {
+		p.Field.Params.Set(ParamServiceType, p.ServiceType)
+	} else {
+		delete(p.Field.Params, ParamServiceType)
+	}
+	if p.Username != "" {
+		p.Field.Params.Set(ParamUsername, p.Username)
+	} else {
+		delete(p.Field.Params, ParamUsername)
+	}
+	return p.Field
+}
+
+// A Related is a relationship with another entity. The value of the field is
+// the URI of the entity, usually a "urn:uuid:" URI referring to another card,
+// or a free-form description if the ParamValue parameter is set to "text".
+type Related struct {
+	*Field
+
+	Types []string // e.g. TypeSpouse
+}
+
+func newRelated(field *Field) *Related {
+	return &Related{
+		Field: field,
+		Types: field.Params.Types(),
+	}
+}
+
+// IsURI returns true if the value of the field is a URI.
+func (r *Related) IsURI() bool {
+	if strings.EqualFold(r.Params.Get(ParamValue), string(ValueText)) {
+		return false
+	}
+	return strings.Contains(r.Value, ":")
+}
+
+// HasType returns true if the relationship has the specified type.
+func (r *Related) HasType(t string) bool {
+	return containsFold(r.Types, t)
+}
+
+func (r *Related) field() *Field {
+	if r.Field == nil {
+		r.Field = new(Field)
+	}
+	if r.Field.Params == nil {
+		r.Field.Params = make(Params)
+	}
+	if len(r.Types) > 0 {
+		r.Field.Params[ParamType] = r.Types
+	} else {
+		delete(r.Field.Params, ParamType)
+	}
+	return r.Field
+}
+
 // Sex is an object's biological sex.
 type Sex string
 
@@ -483,6 +953,19 @@ type Address struct {
 	Region          string // e.g., state or province
 	PostalCode      string
 	Country         string
+
+	// Components defined in RFC 9554
+	Room         string
+	Apartment    string
+	Floor        string
+	StreetNumber string
+	StreetName   string
+	Building     string
+	Block        string
+	Subdistrict  string
+	District     string
+	Landmark     string
+	Direction    string // e.g., "Turn left at the end of the road"
 }
 
 func newAddress(field *Field) *Address {
@@ -496,6 +979,17 @@ func newAddress(field *Field) *Address {
 		maybeGet(components, 4),
 		maybeGet(components, 5),
 		maybeGet(components, 6),
+		maybeGet(components, 7),
+		maybeGet(components, 8),
+		maybeGet(components, 9),
+		maybeGet(components, 10),
+		maybeGet(components, 11),
+		maybeGet(components, 12),
+		maybeGet(components, 13),
+		maybeGet(components, 14),
+		maybeGet(components, 15),
+		maybeGet(components, 16),
+		maybeGet(components, 17),
 	}
 }
 
@@ -503,7 +997,7 @@ func (a *Address) field() *Field {
 	if a.Field == nil {
 		a.Field = new(Field)
 	}
-	a.Field.Value = strings.Join([]string{
+	a.Field.Value = joinComponents([]string{
 		a.PostOfficeBox,
 		a.ExtendedAddress,
 		a.StreetAddress,
@@ -511,6 +1005,18 @@ func (a *Address) field() *Field {
 		a.Region,
 		a.PostalCode,
 		a.Country,
-	}, ";")
+	}, []string{
+		a.Room,
+		a.Apartment,
+		a.Floor,
+		a.StreetNumber,
+		a.StreetName,
+		a.Building,
+		a.Block,
+		a.Subdistrict,
+		a.District,
+		a.Landmark,
+		a.Direction,
+	})
 	return a.Field
 }
@@ -335,3 +335,125 @@ func TestCard_Revision(t *testing.T) {
 		t.Errorf("Expected revision to be %v but got %v", expected, rev)
 	}
 }
+
+func TestCard_Interests(t *testing.T) {
+	card := make(Card)
+	card.AddHobby(&Interest{Field: &Field{Value: "reading"}, Level: LevelLow, Index: 2})
+	card.AddHobby(&Interest{Field: &Field{Value: "sewing"}})
+	card.AddHobby(&Interest{Field: &Field{Value: "chess"}, Level: LevelHigh, Index: 1})
+
+	if v := card[FieldHobby][0].Params.Get(ParamIndex); v != "2" {
+		t.Errorf("Expected hobby INDEX param to be %q, got %q", "2", v)
+	}
+
+	hobbies := card.Hobbies()
+	var values []string
+	for _, h := range hobbies {
+		values = append(values, h.Value)
+	}
+	expected := []string{"chess", "reading", "sewing"}
+	if !reflect.DeepEqual(values, expected) {
+		t.Errorf("Expected hobbies to be %v, got %v", expected, values)
+	}
+	if hobbies[0].Level != LevelHigh || hobbies[0].Index != 1 {
+		t.Errorf("Expected first hobby to have level %q and index 1, got %q and %v", LevelHigh, hobbies[0].Level, hobbies[0].Index)
+	}
+
+	if expertise := card.Expertise(); expertise != nil {
+		t.Errorf("Expected no expertise, got %v", expertise)
+	}
+}
+
+func TestCard_SocialProfiles(t *testing.T) {
+	card := make(Card)
+	card.AddSocialProfile(&SocialProfile{
+		Field:       &Field{Value: "https://example.com/@jdoe"},
+		ServiceType: "Mastodon",
+	})
+
+	expected := Params{ParamServiceType: {"Mastodon"}}
+	if params := card[FieldSocialProfile][0].Params; !reflect.DeepEqual(params, expected) {
+		t.Errorf("Expected social profile params to be %v, got %v", expected, params)
+	}
+
+	profiles := card.SocialProfiles()
+	if len(profiles) != 1 || profiles[0].ServiceType != "Mastodon" || profiles[0].Value != "https://example.com/@jdoe" {
+		t.Errorf("Expected a single Mastodon profile, got %+v", profiles)
+	}
+}
+
+func TestCard_Related(t *testing.T) {
+	card := make(Card)
+	card.AddRelated(&Related{
+		Field: &Field{Value: "urn:uuid:03a0e51f-d1aa-4385-8a53-e29025acd8af"},
+		Types: []string{TypeSpouse, TypeEmergency},
+	})
+	card.AddRelated(&Related{
+		Field: &Field{Value: "Please contact my assistant Jane Doe for any inquiries.", Params: Params{ParamValue: {"text"}}},
+	})
+
+	expected := Params{ParamType: {TypeSpouse, TypeEmergency}}
+	if params := card[FieldRelated][0].Params; !reflect.DeepEqual(params, expected) {
+		t.Errorf("Expected related params to be %v, got %v", expected, params)
+	}
+
+	related := card.Related()
+	if len(related) != 2 {
+		t.Fatalf("Expected two relationships, got %+v", related)
+	}
+	if !related[0].IsURI() || !related[0].HasType("EMERGENCY") || related[0].HasType(TypeChild) {
+		t.Errorf("Expected an emergency spouse URI, got %+v", related[0])
+	}
+	if related[1].IsURI() || len(related[1].Types) != 0 {
+		t.Errorf("Expected a text relationship without type, got %+v", related[1])
+	}
+}
+
+func TestCard_DeathDate(t *testing.T) {
+	card := make(Card)
+	if date, err := card.DeathDate(); err != nil || !date.IsZero() {
+		t.Errorf("Expected a zero death date, got %v, %v", date, err)
+	}
+
+	expected := time.Date(1953, time.October, 15, 0, 0, 0, 0, time.UTC)
+	card.SetDeathDate(expected)
+	if v := card.Value(FieldDeathDate); v != "19531015" {
+		t.Errorf("Expected death date value to be %q, got %q", "19531015", v)
+	}
+	if date, err := card.DeathDate(); err != nil || !date.Equal(expected) {
+		t.Errorf("Expected death date to be %v, got %v, %v", expected, date, err)
+	}
+
+	card.SetValue(FieldDeathDate, "1953-10-15")
+	if date, err := card.DeathDate(); err != nil || !date.Equal(expected) {
+		t.Errorf("Expected extended death date to be %v, got %v, %v", expected, date, err)
+	}
+}
+
+func TestName_extended(t *testing.T) {
+	name := &Name{FamilyName: "Doe", GivenName: "John"}
+	if v := name.field().Value; v != "Doe;John;;;" {
+		t.Errorf("Expected name value to be %q, got %q", "Doe;John;;;", v)
+	}
+
+	name.Generation = "Jr."
+	name.SecondaryFamilyName = "Smith"
+	expected := "Doe;John;;;;Smith;Jr."
+	if v := name.field().Value; v != expected {
+		t.Errorf("Expected name value to be %q, got %q", expected, v)
+	}
+	if parsed := newName(name.Field); parsed.Generation != "Jr." || parsed.SecondaryFamilyName != "Smith" {
+		t.Errorf("Expected parsed name to have extended components, got %+v", parsed)
+	}
+}
+
+func TestAddress_extended(t *testing.T) {
+	address := &Address{StreetAddress: "1 Trafalgar Square", Floor: "2", Landmark: "Nelson's Column"}
+	expected := ";;1 Trafalgar Square;;;;;;;2;;;;;;;Nelson's Column;"
+	if v := address.field().Value; v != expected {
+		t.Errorf("Expected address value to be %q, got %q", expected, v)
+	}
+	if parsed := newAddress(address.Field); parsed.Floor != "2" || parsed.Landmark != "Nelson's Column" {
+		t.Errorf("Expected parsed address to have extended components, got %+v", parsed)
+	}
+}
@@ -0,0 +1,121 @@
+// Package carddav implements a CardDAV client and server, defined in RFC 6352.
+package carddav
+
+import (
+	"errors"
+	"time"
+
+	"github.com/emersion/go-vcard"
+)
+
+// ErrNotFound is returned by backends when a resource doesn't exist.
+var ErrNotFound = errors.New("carddav: not found")
+
+// ErrPreconditionFailed is returned when a conditional request fails, e.g.
+// because the address object has been modified concurrently. It's returned by
+// clients, and by backends when Preconditions aren't met.
+var ErrPreconditionFailed = errors.New("carddav: precondition failed")
+
+// An AddressBook is a collection of address objects.
+type AddressBook struct {
+	Path        string
+	Name        string
+	Description string
+	// MaxResourceSize is the maximum size of an address object, in bytes. Zero
+	// means no limit.
+	MaxResourceSize int64
+	// SyncToken is the current sync token of the address book, as described
+	// in RFC 6578. It's empty if synchronization isn't supported.
+	SyncToken string
+}
+
+// An AddressObject is a card stored in an address book.
+type AddressObject struct {
+	Path          string
+	ModTime       time.Time
+	ContentLength int64
+	ETag          string // without quotes
+	Card          vcard.Card
+}
+
+// SyncResponse lists the changes of an address book since a sync token.
+type SyncResponse struct {
+	SyncToken string
+	// Updated contains created and modified address objects. Only their path
+	// and ETag need to be set.
+	Updated []AddressObject
+	// Deleted contains the paths of deleted address objects.
+	Deleted []string
+}
+
+// AddressDataRequest describes the card properties to return.
+type AddressDataRequest struct {
+	// Props lists the properties to return. If empty, all properties are
+	// returned.
+	Props []string
+}
+
+// AddressBookQuery is an addressbook-query REPORT request.
+type AddressBookQuery struct {
+	DataRequest AddressDataRequest
+	Filter      Filter
+	// Limit is the maximum number of results. Zero means no limit.
+	Limit int
+}
+
+// FilterTest is the logical operator used to combine filter tests.
+type FilterTest string
+
+const (
+	FilterAnyOf FilterTest = "anyof"
+	FilterAllOf FilterTest = "allof"
+)
+
+// MatchType is the kind of comparison performed by a TextMatch.
+type MatchType string
+
+const (
+	MatchEquals     MatchType = "equals"
+	MatchContains   MatchType = "contains"
+	MatchStartsWith MatchType = "starts-with"
+	MatchEndsWith   MatchType = "ends-with"
+)
+
+// Collations defined in RFC 4790.
+const (
+	CollationASCIICasemap   = "i;ascii-casemap"
+	CollationUnicodeCasemap = "i;unicode-casemap"
+	CollationOctet          = "i;octet"
+)
+
+// A Filter selects cards, as described in RFC 6352 section 10.5.
+type Filter struct {
+	Test  FilterTest
+	Props []PropFilter
+}
+
+// A PropFilter matches cards by property.
+type PropFilter struct {
+	Name string
+	Test FilterTest
+	// IsNotDefined matches cards without the property.
+	IsNotDefined bool
+	TextMatches  []TextMatch
+	Params       []ParamFilter
+}
+
+// A ParamFilter matches properties by parameter.
+type ParamFilter struct {
+	Name string
+	// IsNotDefined matches properties without the parameter.
+	IsNotDefined bool
+	TextMatch    *TextMatch
+}
+
+// A TextMatch matches property or parameter values.
+type TextMatch struct {
+	Text            string
+	Collation       string
+	MatchType       MatchType
+	NegateCondition bool
+}
@@ -0,0 +1,476 @@
+package carddav
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/emersion/go-vcard"
+)
+
+// PutAddressObjectOptions contains options for Client.PutAddressObject.
+type PutAddressObjectOptions struct {
+	// IfMatch, if non-empty, only replaces the address object if its current
+	// ETag matches.
+	IfMatch string
+	// IfNoneMatch, if set, only creates the address object if it doesn't
+	// exist yet.
+	IfNoneMatch bool
+}
+
+// A Client is a CardDAV client.
+type Client struct {
+	http     *http.Client
+	endpoint *url.URL
+}
+
+// NewClient creates a new CardDAV client. If c is nil, http.DefaultClient is
+// used. The endpoint is the URL used for discovery, for instance
+// "https://example.org/.well-known/carddav".
+func NewClient(c *http.Client, endpoint string) (*Client, error) {
+	if c == nil {
+		c = http.DefaultClient
+	}
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return nil, err
+	}
+	return &Client{http: c, endpoint: u}, nil
+}
+
+func (c *Client) resolve(p string) string {
+	return c.endpoint.ResolveReference(&url.URL{Path: p}).String()
+}
+
+func (c *Client) newRequest(ctx context.Context, method, p string, body io.Reader) (*http.Request, error) {
+	u := c.endpoint.String()
+	if p != "" {
+		u = c.resolve(p)
+	}
+	return http.NewRequestWithContext(ctx, method, u, body)
+}
+
+func (c *Client) newXMLRequest(ctx context.Context, method, p string, v interface{}) (*http.Request, error) {
+	var b bytes.Buffer
+	b.WriteString(xml.Header)
+	if err := xml.NewEncoder(&b).Encode(v); err != nil {
+		return nil, err
+	}
+
+	req, err := c.newRequest(ctx, method, p, &b)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/xml; charset=utf-8")
+	return req, nil
+}
+
+func (c *Client) do(req *http.Request) (*http.Response, error) {
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode/100 == 2 {
+		return resp, nil
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusNotFound:
+		return nil, ErrNotFound
+	case http.StatusPreconditionFailed:
+		return nil, ErrPreconditionFailed
+	}
+
+	b, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 1024))
+	msg := strings.TrimSpace(string(b))
+	if msg == "" {
+		msg = resp.Status
+	}
+	return nil, newHTTPError(resp.StatusCode, fmt.Errorf("carddav: HTTP %v: %v", resp.StatusCode, msg))
+}
+
+func (c *Client) doMultiStatus(req *http.Request) (*multiStatusResp, error) {
+	resp, err := c.do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusMultiStatus {
+		return nil, fmt.Errorf("carddav: HTTP %v: expected multistatus response", resp.StatusCode)
+	}
+
+	var ms multiStatusResp
+	if err := xml.NewDecoder(resp.Body).Decode(&ms); err != nil {
+		return nil, err
+	}
+	return &ms, nil
+}
+
+func (c *Client) propfind(ctx context.Context, p string, depth int, names ...xml.Name) (*multiStatusResp, error) {
+	req, err := c.newXMLRequest(ctx, "PROPFIND", p, &propFind{Prop: &propNames{Names: names}})
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Depth", strconv.Itoa(depth))
+	return c.doMultiStatus(req)
+}
+
+// propfindOne fetches properties of a single resource.
+func (c *Client) propfindOne(ctx context.Context, p string, names ...xml.Name) (*propValues, error) {
+	ms, err := c.propfind(ctx, p, 0, names...)
+	if err != nil {
+		return nil, err
+	}
+	if len(ms.Responses) != 1 {
+		return nil, errors.New("carddav: expected exactly one response")
+	}
+	return ms.Responses[0].props(), nil
+}
+
+// FindCurrentUserPrincipal returns the path of the principal of the
+// authenticated user.
+func (c *Client) FindCurrentUserPrincipal(ctx context.Context) (string, error) {
+	props, err := c.propfindOne(ctx, "", currentUserPrincipalName)
+	if err != nil {
+		return "", err
+	}
+	if props.CurrentUserPrincipal == nil || props.CurrentUserPrincipal.Href == "" {
+		return "", errors.New("carddav: current-user-principal property missing")
+	}
+	return hrefPath(props.CurrentUserPrincipal.Href)
+}
+
+// FindAddressBookHomeSet returns the path of the collection containing the
+// address books of a principal.
+func (c *Client) FindAddressBookHomeSet(ctx context.Context, principal string) (string, error) {
+	props, err := c.propfindOne(ctx, principal, addressBookHomeSetName)
+	if err != nil {
+		return "", err
+	}
+	if props.AddressBookHomeSet == nil || props.AddressBookHomeSet.Href == "" {
+		return "", errors.New("carddav: addressbook-home-set property missing")
+	}
+	return hrefPath(props.AddressBookHomeSet.Href)
+}
+
+// FindAddressBooks lists the address books in a home set.
+func (c *Client) FindAddressBooks(ctx context.Context, homeSet string) ([]AddressBook, error) {
+	ms, err := c.propfind(ctx, homeSet, 1,
+		resourceTypeName,
+		displayNameName,
+		addressBookDescriptionName,
+		maxResourceSizeName,
+		syncTokenName,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	var abs []AddressBook
+	for _, resp := range ms.Responses {
+		props := resp.props()
+		if props.ResourceType == nil || props.ResourceType.AddressBook == nil {
+			continue
+		}
+		p, err := resp.path()
+		if err != nil {
+			return nil, err
+		}
+
+		ab := AddressBook{
+			Path:        p,
+			Name:        props.DisplayName,
+			Description: props.AddressBookDescription,
+			SyncToken:   strings.TrimSpace(props.SyncToken),
+		}
+		if props.MaxResourceSize != "" {
+			ab.MaxResourceSize, err = strconv.ParseInt(strings.TrimSpace(props.MaxResourceSize), 10, 64)
+			if err != nil {
+				return nil, err
+			}
+		}
+		abs = append(abs, ab)
+	}
+	return abs, nil
+}
+
+func hrefPath(href string) (string, error) {
+	u, err := url.Parse(strings.TrimSpace(href))
+	if err != nil {
+		return "", err
+	}
+	return u.Path, nil
+}
+
+func (resp *responseResp) path() (string, error) {
+	if len(resp.Hrefs) != 1 {
+		return "", errors.New("carddav: expected exactly one href in response")
+	}
+	return hrefPath(resp.Hrefs[0])
+}
+
+// addressObject converts a response to an address object. The card is decoded
+// if the response contains address data.
+func (resp *responseResp) addressObject() (*AddressObject, error) {
+	p, err := resp.path()
+	if err != nil {
+		return nil, err
+	}
+
+	props := resp.props()
+	ao := &AddressObject{Path: p}
+	if props.GetETag != "" {
+		ao.ETag = unquoteETag(props.GetETag)
+	}
+	if props.GetContentLength != "" {
+		ao.ContentLength, err = strconv.ParseInt(strings.TrimSpace(props.GetContentLength), 10, 64)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if props.GetLastModified != "" {
+		ao.ModTime, err = http.ParseTime(strings.TrimSpace(props.GetLastModified))
+		if err != nil {
+			return nil, err
+		}
+	}
+	if props.AddressData != "" {
+		ao.Card, err = vcard.NewDecoder(strings.NewReader(props.AddressData)).Decode()
+		if err != nil {
+			return nil, err
+		}
+	}
+	return ao, nil
+}
+
+// addressObjects converts successful responses to address objects. Failed
+// responses are skipped.
+func (ms *multiStatusResp) addressObjects() ([]AddressObject, error) {
+	var aos []AddressObject
+	for _, resp := range ms.Responses {
+		if code := resp.status(); code < 200 || code >= 300 {
+			continue
+		}
+		ao, err := resp.addressObject()
+		if err != nil {
+			return nil, err
+		}
+		aos = append(aos, *ao)
+	}
+	return aos, nil
+}
+
+// ListAddressObjects lists the address objects of an address book, with their
+// ETag. Cards aren't fetched.
+func (c *Client) ListAddressObjects(ctx context.Context, path string) ([]AddressObject, error) {
+	ms, err := c.propfind(ctx, path, 1, resourceTypeName, getETagName, getContentLengthName, getLastModifiedName)
+	if err != nil {
+		return nil, err
+	}
+
+	var aos []AddressObject
+	for _, resp := range ms.Responses {
+		if rt := resp.props().ResourceType; rt != nil && rt.Collection != nil {
+			continue
+		}
+		ao, err := resp.addressObject()
+		if err != nil {
+			return nil, err
+		}
+		aos = append(aos, *ao)
+	}
+	return aos, nil
+}
+
+// GetAddressObject fetches an address object.
+func (c *Client) GetAddressObject(ctx context.Context, path string) (*AddressObject, error) {
+	req, err := c.newRequest(ctx, http.MethodGet, path, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", vcard.MIMEType)
+
+	resp, err := c.do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	card, err := vcard.NewDecoder(resp.Body).Decode()
+	if err != nil {
+		return nil, err
+	}
+
+	ao := &AddressObject{Path: path, Card: card, ContentLength: resp.ContentLength}
+	if etag := resp.Header.Get("ETag"); etag != "" {
+		ao.ETag = unquoteETag(etag)
+	}
+	if lastModified := resp.Header.Get("Last-Modified"); lastModified != "" {
+		ao.ModTime, _ = http.ParseTime(lastModified)
+	}
+	return ao, nil
+}
+
+func addressDataProps(req *AddressDataRequest) *propNames {
+	data := new(addressDataReq)
+	if req != nil {
+		for _, name := range req.Props {
+			data.Props = append(data.Props, addressDataProp{Name: name})
+		}
+	}
+	return &propNames{
+		Names:       []xml.Name{getETagName, addressDataName},
+		AddressData: data,
+	}
+}
+
+// MultiGetAddressBook fetches several address objects of an address book in a
+// single request. Missing address objects are omitted from the result.
+func (c *Client) MultiGetAddressBook(ctx context.Context, path string, hrefs []string, dataReq *AddressDataRequest) ([]AddressObject, error) {
+	req, err := c.newXMLRequest(ctx, "REPORT", path, &addressBookMultiget{
+		Prop:  addressDataProps(dataReq),
+		Hrefs: hrefs,
+	})
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Depth", "1")
+
+	ms, err := c.doMultiStatus(req)
+	if err != nil {
+		return nil, err
+	}
+	return ms.addressObjects()
+}
+
+// QueryAddressBook fetches the address objects of an address book matching a
+// query.
+func (c *Client) QueryAddressBook(ctx context.Context, path string, query *AddressBookQuery) ([]AddressObject, error) {
+	elem := &addressBookQuery{
+		Prop:   addressDataProps(&query.DataRequest),
+		Filter: formatFilter(&query.Filter),
+	}
+	if query.Limit > 0 {
+		elem.Limit = &limitElem{NResults: query.Limit}
+	}
+
+	req, err := c.newXMLRequest(ctx, "REPORT", path, elem)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Depth", "1")
+
+	ms, err := c.doMultiStatus(req)
+	if err != nil {
+		return nil, err
+	}
+	return ms.addressObjects()
+}
+
+// SyncAddressBook returns the changes of an address book since a sync token,
+// using the sync-collection REPORT defined in RFC 6578. An empty sync token
+// lists all address objects. Cards aren't fetched: use MultiGetAddressBook to
+// fetch updated address objects.
+func (c *Client) SyncAddressBook(ctx context.Context, path, syncToken string) (*SyncResponse, error) {
+	req, err := c.newXMLRequest(ctx, "REPORT", path, &syncCollection{
+		SyncToken: syncToken,
+		SyncLevel: "1",
+		Prop:      &propNames{Names: []xml.Name{getETagName}},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	ms, err := c.doMultiStatus(req)
+	if err != nil {
+		return nil, err
+	}
+
+	sync := &SyncResponse{SyncToken: strings.TrimSpace(ms.SyncToken)}
+	for _, resp := range ms.Responses {
+		p, err := resp.path()
+		if err != nil {
+			return nil, err
+		}
+
+		switch code := resp.status(); {
+		case code == http.StatusNotFound:
+			sync.Deleted = append(sync.Deleted, p)
+		case code >= 200 && code < 300:
+			if p == path {
+				// The collection itself
+				continue
+			}
+			ao, err := resp.addressObject()
+			if err != nil {
+				return nil, err
+			}
+			sync.Updated = append(sync.Updated, *ao)
+		}
+	}
+	return sync, nil
+}
+
+// PutAddressObject creates or replaces an address object. ErrPreconditionFailed
+// is returned if the conditions set in the options aren't met.
+func (c *Client) PutAddressObject(ctx context.Context, path string, card vcard.Card, opts *PutAddressObjectOptions) (*AddressObject, error) {
+	b, err := encodeCard(card)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := c.newRequest(ctx, http.MethodPut, path, bytes.NewReader(b))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", vcard.MIMEType+"; charset=utf-8")
+	if opts != nil {
+		if opts.IfMatch != "" {
+			req.Header.Set("If-Match", quoteETag(opts.IfMatch))
+		}
+		if opts.IfNoneMatch {
+			req.Header.Set("If-None-Match", "*")
+		}
+	}
+
+	resp, err := c.do(req)
+	if err != nil {
+		return nil, err
+	}
+	resp.Body.Close()
+
+	ao := &AddressObject{
+		Path:          path,
+		ContentLength: int64(len(b)),
+		Card:          card,
+	}
+	if etag := resp.Header.Get("ETag"); etag != "" {
+		ao.ETag = unquoteETag(etag)
+	}
+	return ao, nil
+}
+
+// DeleteAddressObject deletes an address object.
+func (c *Client) DeleteAddressObject(ctx context.Context, path string) error {
+	req, err := c.newRequest(ctx, http.MethodDelete, path, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := c.do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
@@ -0,0 +1,188 @@
+package carddav
+
+import (
+	"context"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/emersion/go-vcard"
+)
+
+func newTestClient(t *testing.T) (*Client, *httptest.Server) {
+	server := httptest.NewServer(&Handler{Backend: newTestBackend()})
+	c, err := NewClient(server.Client(), server.URL+"/.well-known/carddav")
+	if err != nil {
+		t.Fatalf("NewClient() = %v", err)
+	}
+	return c, server
+}
+
+func decodeTestCard(t *testing.T, uid, fn string) vcard.Card {
+	card, err := vcard.NewDecoder(strings.NewReader(testCard(uid, fn))).Decode()
+	if err != nil {
+		t.Fatalf("Decode() = %v", err)
+	}
+	return card
+}
+
+func TestClient_discovery(t *testing.T) {
+	c, server := newTestClient(t)
+	defer server.Close()
+	ctx := context.Background()
+
+	principal, err := c.FindCurrentUserPrincipal(ctx)
+	if err != nil {
+		t.Fatalf("FindCurrentUserPrincipal() = %v", err)
+	}
+	if principal != "/principal/" {
+		t.Errorf("FindCurrentUserPrincipal() = %v, want /principal/", principal)
+	}
+
+	homeSet, err := c.FindAddressBookHomeSet(ctx, principal)
+	if err != nil {
+		t.Fatalf("FindAddressBookHomeSet() = %v", err)
+	}
+	if homeSet != "/contacts/" {
+		t.Errorf("FindAddressBookHomeSet() = %v, want /contacts/", homeSet)
+	}
+
+	abs, err := c.FindAddressBooks(ctx, homeSet)
+	if err != nil {
+		t.Fatalf("FindAddressBooks() = %v", err)
+	}
+	want := []AddressBook{{
+		Path:            "/contacts/default/",
+		Name:            "Contacts",
+		MaxResourceSize: 4096,
+		SyncToken:       "urn:test:0",
+	}}
+	if !reflect.DeepEqual(abs, want) {
+		t.Errorf("FindAddressBooks() = %+v, want %+v", abs, want)
+	}
+}
+
+func TestClient_sync(t *testing.T) {
+	c, server := newTestClient(t)
+	defer server.Close()
+	ctx := context.Background()
+	book := "/contacts/default/"
+
+	alice, err := c.PutAddressObject(ctx, book+"alice.vcf", decodeTestCard(t, "alice", "Alice"), &PutAddressObjectOptions{IfNoneMatch: true})
+	if err != nil {
+		t.Fatalf("PutAddressObject() = %v", err)
+	}
+	if alice.ETag == "" {
+		t.Errorf("PutAddressObject(): no ETag")
+	}
+	if _, err := c.PutAddressObject(ctx, book+"alice.vcf", decodeTestCard(t, "alice", "Alice"), &PutAddressObjectOptions{IfNoneMatch: true}); err != ErrPreconditionFailed {
+		t.Errorf("PutAddressObject() on existing card = %v, want ErrPreconditionFailed", err)
+	}
+	if _, err := c.PutAddressObject(ctx, book+"bob.vcf", decodeTestCard(t, "bob", "Bob"), nil); err != nil {
+		t.Fatalf("PutAddressObject() = %v", err)
+	}
+
+	aos, err := c.ListAddressObjects(ctx, book)
+	if err != nil {
+		t.Fatalf("ListAddressObjects() = %v", err)
+	}
+	if len(aos) != 2 || aos[0].Path != book+"alice.vcf" || aos[0].ETag != alice.ETag || aos[1].Path != book+"bob.vcf" {
+		t.Errorf("ListAddressObjects() = %+v", aos)
+	}
+
+	sync, err := c.SyncAddressBook(ctx, book, "")
+	if err != nil {
+		t.Fatalf("SyncAddressBook() = %v", err)
+	}
+	if len(sync.Updated) != 2 || len(sync.Deleted) != 0 || sync.SyncToken == "" {
+		t.Fatalf("initial SyncAddressBook() = %+v", sync)
+	}
+	token := sync.SyncToken
+
+	if _, err := c.PutAddressObject(ctx, book+"alice.vcf", decodeTestCard(t, "alice", "Alice L."), &PutAddressObjectOptions{IfMatch: "outdated"}); err != ErrPreconditionFailed {
+		t.Errorf("PutAddressObject() with stale ETag = %v, want ErrPreconditionFailed", err)
+	}
+	alice, err = c.PutAddressObject(ctx, book+"alice.vcf", decodeTestCard(t, "alice", "Alice L."), &PutAddressObjectOptions{IfMatch: alice.ETag})
+	if err != nil {
+		t.Fatalf("PutAddressObject() = %v", err)
+	}
+	if err := c.DeleteAddressObject(ctx, book+"bob.vcf"); err != nil {
+		t.Fatalf("DeleteAddressObject() = %v", err)
+	}
+
+	sync, err = c.SyncAddressBook(ctx, book, token)
+	if err != nil {
+		t.Fatalf("SyncAddressBook() = %v", err)
+	}
+	if len(sync.Updated) != 1 || sync.Updated[0].Path != book+"alice.vcf" || sync.Updated[0].ETag != alice.ETag {
+		t.Errorf("SyncAddressBook(): updated = %+v", sync.Updated)
+	}
+	if !reflect.DeepEqual(sync.Deleted, []string{book + "bob.vcf"}) {
+		t.Errorf("SyncAddressBook(): deleted = %v", sync.Deleted)
+	}
+	if sync.SyncToken == token {
+		t.Errorf("SyncAddressBook(): sync token didn't change")
+	}
+
+	if _, err := c.SyncAddressBook(ctx, book, "invalid"); err == nil {
+		t.Errorf("SyncAddressBook() with invalid token = nil, want an error")
+	}
+
+	var hrefs []string
+	for _, ao := range sync.Updated {
+		hrefs = append(hrefs, ao.Path)
+	}
+	aos, err = c.MultiGetAddressBook(ctx, book, append(hrefs, book+"bob.vcf"), nil)
+	if err != nil {
+		t.Fatalf("MultiGetAddressBook() = %v", err)
+	}
+	if len(aos) != 1 || aos[0].ETag != alice.ETag {
+		t.Fatalf("MultiGetAddressBook() = %+v", aos)
+	}
+	if fn := aos[0].Card.Value(vcard.FieldFormattedName); fn != "Alice L." {
+		t.Errorf("MultiGetAddressBook(): FN = %v, want Alice L.", fn)
+	}
+
+	ao, err := c.GetAddressObject(ctx, book+"alice.vcf")
+	if err != nil {
+		t.Fatalf("GetAddressObject() = %v", err)
+	}
+	if ao.ETag != alice.ETag || ao.Card.Value(vcard.FieldUID) != "alice" {
+		t.Errorf("GetAddressObject() = %+v", ao)
+	}
+	if _, err := c.GetAddressObject(ctx, book+"bob.vcf"); err != ErrNotFound {
+		t.Errorf("GetAddressObject() on deleted card = %v, want ErrNotFound", err)
+	}
+}
+
+func TestClient_query(t *testing.T) {
+	c, server := newTestClient(t)
+	defer server.Close()
+	ctx := context.Background()
+	book := "/contacts/default/"
+
+	for uid, fn := range map[string]string{"alice": "Alice", "bob": "Bob"} {
+		if _, err := c.PutAddressObject(ctx, book+uid+".vcf", decodeTestCard(t, uid, fn), nil); err != nil {
+			t.Fatalf("PutAddressObject() = %v", err)
+		}
+	}
+
+	aos, err := c.QueryAddressBook(ctx, book, &AddressBookQuery{
+		DataRequest: AddressDataRequest{Props: []string{vcard.FieldUID}},
+		Filter: Filter{Props: []PropFilter{{
+			Name:        vcard.FieldFormattedName,
+			TextMatches: []TextMatch{{Text: "ali", MatchType: MatchStartsWith}},
+		}}},
+	})
+	if err != nil {
+		t.Fatalf("QueryAddressBook() = %v", err)
+	}
+	want := vcard.Card{
+		vcard.FieldVersion: {{Value: "4.0"}},
+		vcard.FieldUID:     {{Value: "alice"}},
+	}
+	if len(aos) != 1 || !reflect.DeepEqual(aos[0].Card, want) {
+		t.Errorf("QueryAddressBook() = %+v", aos)
+	}
+}
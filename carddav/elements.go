@@ -0,0 +1,383 @@
+package carddav
+
+import (
+	"encoding/xml"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+const (
+	davNamespace     = "DAV:"
+	carddavNamespace = "urn:ietf:params:xml:ns:carddav"
+)
+
+var (
+	resourceTypeName           = xml.Name{Space: davNamespace, Local: "resourcetype"}
+	displayNameName            = xml.Name{Space: davNamespace, Local: "displayname"}
+	getContentTypeName         = xml.Name{Space: davNamespace, Local: "getcontenttype"}
+	getContentLengthName       = xml.Name{Space: davNamespace, Local: "getcontentlength"}
+	getLastModifiedName        = xml.Name{Space: davNamespace, Local: "getlastmodified"}
+	getETagName                = xml.Name{Space: davNamespace, Local: "getetag"}
+	currentUserPrincipalName   = xml.Name{Space: davNamespace, Local: "current-user-principal"}
+	supportedReportSetName     = xml.Name{Space: davNamespace, Local: "supported-report-set"}
+	addressBookHomeSetName     = xml.Name{Space: carddavNamespace, Local: "addressbook-home-set"}
+	addressBookDescriptionName = xml.Name{Space: carddavNamespace, Local: "addressbook-description"}
+	supportedAddressDataName   = xml.Name{Space: carddavNamespace, Local: "supported-address-data"}
+	maxResourceSizeName        = xml.Name{Space: carddavNamespace, Local: "max-resource-size"}
+	addressDataName            = xml.Name{Space: carddavNamespace, Local: "address-data"}
+	syncTokenName              = xml.Name{Space: davNamespace, Local: "sync-token"}
+
+	addressBookQueryName    = xml.Name{Space: carddavNamespace, Local: "addressbook-query"}
+	addressBookMultigetName = xml.Name{Space: carddavNamespace, Local: "addressbook-multiget"}
+	syncCollectionName      = xml.Name{Space: davNamespace, Local: "sync-collection"}
+)
+
+type multiStatus struct {
+	XMLName   xml.Name   `xml:"DAV: multistatus"`
+	Responses []response `xml:"response"`
+	SyncToken string     `xml:"sync-token,omitempty"`
+}
+
+type response struct {
+	Hrefs     []string   `xml:"href"`
+	PropStats []propStat `xml:"propstat,omitempty"`
+	Status    string     `xml:"status,omitempty"`
+}
+
+type propStat struct {
+	Prop   prop   `xml:"prop"`
+	Status string `xml:"status"`
+}
+
+type prop struct {
+	Values []interface{} `xml:",any"`
+}
+
+func statusLine(code int) string {
+	return "HTTP/1.1 " + strconv.Itoa(code) + " " + http.StatusText(code)
+}
+
+// rawElement is an arbitrary XML element.
+type rawElement struct {
+	XMLName xml.Name
+	Attrs   []xml.Attr `xml:",any,attr"`
+	Inner   []byte     `xml:",innerxml"`
+}
+
+// propNames is a prop element listing property names.
+type propNames struct {
+	Names       []xml.Name
+	AddressData *addressDataReq
+}
+
+func (p *propNames) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	for {
+		tok, err := d.Token()
+		if err != nil {
+			return err
+		}
+
+		switch tok := tok.(type) {
+		case xml.StartElement:
+			p.Names = append(p.Names, tok.Name)
+			if tok.Name == addressDataName {
+				p.AddressData = new(addressDataReq)
+				err = d.DecodeElement(p.AddressData, &tok)
+			} else {
+				err = d.Skip()
+			}
+			if err != nil {
+				return err
+			}
+		case xml.EndElement:
+			return nil
+		}
+	}
+}
+
+func (p *propNames) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	if err := e.EncodeToken(start); err != nil {
+		return err
+	}
+	for _, name := range p.Names {
+		if name == addressDataName && p.AddressData != nil {
+			if err := e.Encode(p.AddressData); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := e.Encode(rawElement{XMLName: name}); err != nil {
+			return err
+		}
+	}
+	return e.EncodeToken(start.End())
+}
+
+type propFind struct {
+	XMLName  xml.Name   `xml:"DAV: propfind"`
+	Prop     *propNames `xml:"DAV: prop"`
+	AllProp  *struct{}  `xml:"DAV: allprop"`
+	PropName *struct{}  `xml:"DAV: propname"`
+}
+
+type resourceType struct {
+	XMLName     xml.Name  `xml:"DAV: resourcetype"`
+	Collection  *struct{} `xml:"DAV: collection"`
+	Principal   *struct{} `xml:"DAV: principal"`
+	AddressBook *struct{} `xml:"urn:ietf:params:xml:ns:carddav addressbook"`
+}
+
+type textProp struct {
+	XMLName xml.Name
+	Text    string `xml:",chardata"`
+}
+
+type hrefProp struct {
+	XMLName xml.Name
+	Href    string `xml:"DAV: href"`
+}
+
+type supportedReportSet struct {
+	XMLName xml.Name          `xml:"DAV: supported-report-set"`
+	Reports []supportedReport `xml:"DAV: supported-report"`
+}
+
+type supportedReport struct {
+	Report struct {
+		Name rawElement
+	} `xml:"DAV: report"`
+}
+
+type supportedAddressData struct {
+	XMLName xml.Name          `xml:"urn:ietf:params:xml:ns:carddav supported-address-data"`
+	Types   []addressDataType `xml:"urn:ietf:params:xml:ns:carddav address-data-type"`
+}
+
+type addressDataType struct {
+	ContentType string `xml:"content-type,attr"`
+	Version     string `xml:"version,attr"`
+}
+
+// addressDataReq is the address-data element of a request.
+type addressDataReq struct {
+	XMLName xml.Name          `xml:"urn:ietf:params:xml:ns:carddav address-data"`
+	AllProp *struct{}         `xml:"urn:ietf:params:xml:ns:carddav allprop"`
+	Props   []addressDataProp `xml:"urn:ietf:params:xml:ns:carddav prop"`
+}
+
+type addressDataProp struct {
+	Name string `xml:"name,attr"`
+}
+
+type addressBookQuery struct {
+	XMLName  xml.Name   `xml:"urn:ietf:params:xml:ns:carddav addressbook-query"`
+	Prop     *propNames `xml:"DAV: prop"`
+	AllProp  *struct{}  `xml:"DAV: allprop"`
+	PropName *struct{}  `xml:"DAV: propname"`
+	Filter   filterElem `xml:"urn:ietf:params:xml:ns:carddav filter"`
+	Limit    *limitElem `xml:"urn:ietf:params:xml:ns:carddav limit"`
+}
+
+type syncCollection struct {
+	XMLName   xml.Name   `xml:"DAV: sync-collection"`
+	SyncToken string     `xml:"DAV: sync-token"`
+	SyncLevel string     `xml:"DAV: sync-level"`
+	Prop      *propNames `xml:"DAV: prop"`
+}
+
+type addressBookMultiget struct {
+	XMLName  xml.Name   `xml:"urn:ietf:params:xml:ns:carddav addressbook-multiget"`
+	Prop     *propNames `xml:"DAV: prop"`
+	AllProp  *struct{}  `xml:"DAV: allprop"`
+	PropName *struct{}  `xml:"DAV: propname"`
+	Hrefs    []string   `xml:"DAV: href"`
+}
+
+type filterElem struct {
+	Test  string           `xml:"test,attr,omitempty"`
+	Props []propFilterElem `xml:"urn:ietf:params:xml:ns:carddav prop-filter"`
+}
+
+type propFilterElem struct {
+	Name         string            `xml:"name,attr"`
+	Test         string            `xml:"test,attr,omitempty"`
+	IsNotDefined *struct{}         `xml:"urn:ietf:params:xml:ns:carddav is-not-defined"`
+	TextMatches  []textMatchElem   `xml:"urn:ietf:params:xml:ns:carddav text-match"`
+	Params       []paramFilterElem `xml:"urn:ietf:params:xml:ns:carddav param-filter"`
+}
+
+type paramFilterElem struct {
+	Name         string         `xml:"name,attr"`
+	IsNotDefined *struct{}      `xml:"urn:ietf:params:xml:ns:carddav is-not-defined"`
+	TextMatch    *textMatchElem `xml:"urn:ietf:params:xml:ns:carddav text-match"`
+}
+
+type textMatchElem struct {
+	Text            string `xml:",chardata"`
+	Collation       string `xml:"collation,attr,omitempty"`
+	NegateCondition string `xml:"negate-condition,attr,omitempty"`
+	MatchType       string `xml:"match-type,attr,omitempty"`
+}
+
+type limitElem struct {
+	NResults int `xml:"urn:ietf:params:xml:ns:carddav nresults"`
+}
+
+// davError is a precondition or postcondition error, as described in RFC
+// 4918 section 16.
+type davError struct {
+	XMLName   xml.Name `xml:"DAV: error"`
+	Condition rawElement
+}
+
+func parseFilter(elem *filterElem) Filter {
+	f := Filter{Test: filterTest(elem.Test)}
+	for _, pf := range elem.Props {
+		propFilter := PropFilter{
+			Name:         pf.Name,
+			Test:         filterTest(pf.Test),
+			IsNotDefined: pf.IsNotDefined != nil,
+		}
+		for _, tm := range pf.TextMatches {
+			propFilter.TextMatches = append(propFilter.TextMatches, parseTextMatch(&tm))
+		}
+		for _, param := range pf.Params {
+			paramFilter := ParamFilter{
+				Name:         param.Name,
+				IsNotDefined: param.IsNotDefined != nil,
+			}
+			if param.TextMatch != nil {
+				tm := parseTextMatch(param.TextMatch)
+				paramFilter.TextMatch = &tm
+			}
+			propFilter.Params = append(propFilter.Params, paramFilter)
+		}
+		f.Props = append(f.Props, propFilter)
+	}
+	return f
+}
+
+func formatFilter(f *Filter) filterElem {
+	elem := filterElem{Test: string(f.Test)}
+	for _, pf := range f.Props {
+		propFilter := propFilterElem{Name: pf.Name, Test: string(pf.Test)}
+		if pf.IsNotDefined {
+			propFilter.IsNotDefined = &struct{}{}
+		}
+		for _, tm := range pf.TextMatches {
+			propFilter.TextMatches = append(propFilter.TextMatches, formatTextMatch(&tm))
+		}
+		for _, param := range pf.Params {
+			paramFilter := paramFilterElem{Name: param.Name}
+			if param.IsNotDefined {
+				paramFilter.IsNotDefined = &struct{}{}
+			}
+			if param.TextMatch != nil {
+				tm := formatTextMatch(param.TextMatch)
+				paramFilter.TextMatch = &tm
+			}
+			propFilter.Params = append(propFilter.Params, paramFilter)
+		}
+		elem.Props = append(elem.Props, propFilter)
+	}
+	return elem
+}
+
+func formatTextMatch(tm *TextMatch) textMatchElem {
+	elem := textMatchElem{
+		Text:      tm.Text,
+		Collation: tm.Collation,
+		MatchType: string(tm.MatchType),
+	}
+	if tm.NegateCondition {
+		elem.NegateCondition = "yes"
+	}
+	return elem
+}
+
+func filterTest(s string) FilterTest {
+	if s == string(FilterAllOf) {
+		return FilterAllOf
+	}
+	return FilterAnyOf
+}
+
+func parseTextMatch(elem *textMatchElem) TextMatch {
+	tm := TextMatch{
+		Text:            elem.Text,
+		Collation:       elem.Collation,
+		MatchType:       MatchType(elem.MatchType),
+		NegateCondition: elem.NegateCondition == "yes",
+	}
+	if tm.Collation == "" {
+		tm.Collation = CollationUnicodeCasemap
+	}
+	if tm.MatchType == "" {
+		tm.MatchType = MatchContains
+	}
+	return tm
+}
+
+// multiStatusResp is a multistatus response received by a client.
+type multiStatusResp struct {
+	XMLName   xml.Name       `xml:"DAV: multistatus"`
+	Responses []responseResp `xml:"DAV: response"`
+	SyncToken string         `xml:"DAV: sync-token"`
+}
+
+type responseResp struct {
+	Hrefs     []string `xml:"DAV: href"`
+	PropStats []struct {
+		Prop   propValues `xml:"DAV: prop"`
+		Status string     `xml:"DAV: status"`
+	} `xml:"DAV: propstat"`
+	Status string `xml:"DAV: status"`
+}
+
+// propValues contains the properties a client is interested in.
+type propValues struct {
+	ResourceType           *resourceType `xml:"DAV: resourcetype"`
+	DisplayName            string        `xml:"DAV: displayname"`
+	GetETag                string        `xml:"DAV: getetag"`
+	GetContentLength       string        `xml:"DAV: getcontentlength"`
+	GetLastModified        string        `xml:"DAV: getlastmodified"`
+	SyncToken              string        `xml:"DAV: sync-token"`
+	CurrentUserPrincipal   *hrefProp     `xml:"DAV: current-user-principal"`
+	AddressBookHomeSet     *hrefProp     `xml:"urn:ietf:params:xml:ns:carddav addressbook-home-set"`
+	AddressBookDescription string        `xml:"urn:ietf:params:xml:ns:carddav addressbook-description"`
+	MaxResourceSize        string        `xml:"urn:ietf:params:xml:ns:carddav max-resource-size"`
+	AddressData            string        `xml:"urn:ietf:params:xml:ns:carddav address-data"`
+}
+
+// parseStatus parses a status line, such as "HTTP/1.1 200 OK".
+func parseStatus(s string) int {
+	fields := strings.Fields(s)
+	if len(fields) < 2 {
+		return 0
+	}
+	code, _ := strconv.Atoi(fields[1])
+	return code
+}
+
+// status returns the status of the response. For responses with a propstat,
+// it's the status of the first propstat.
+func (resp *responseResp) status() int {
+	if resp.Status != "" {
+		return parseStatus(resp.Status)
+	}
+	if len(resp.PropStats) > 0 {
+		return parseStatus(resp.PropStats[0].Status)
+	}
+	return 0
+}
+
+// props returns the properties found in the response.
+func (resp *responseResp) props() *propValues {
+	for _, ps := range resp.PropStats {
+		if code := parseStatus(ps.Status); code >= 200 && code < 300 {
+			return &ps.Prop
+		}
+	}
+	return &propValues{}
+}
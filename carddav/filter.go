@@ -0,0 +1,153 @@
+package carddav
+
+import (
+	"strings"
+	"unicode"
+
+	"github.com/emersion/go-vcard"
+)
+
+// Match returns true if the card matches the filter. A filter without any
+// property filter matches all cards.
+func (f *Filter) Match(card vcard.Card) bool {
+	if len(f.Props) == 0 {
+		return true
+	}
+	return matchTest(f.Test, len(f.Props), func(i int) bool {
+		return f.Props[i].Match(card)
+	})
+}
+
+// matchTest combines n tests with the anyof or allof operator.
+func matchTest(test FilterTest, n int, match func(i int) bool) bool {
+	for i := 0; i < n; i++ {
+		ok := match(i)
+		if test == FilterAllOf && !ok {
+			return false
+		} else if test != FilterAllOf && ok {
+			return true
+		}
+	}
+	return test == FilterAllOf
+}
+
+// Match returns true if the card matches the property filter.
+func (pf *PropFilter) Match(card vcard.Card) bool {
+	fields := card[strings.ToUpper(pf.Name)]
+	if pf.IsNotDefined {
+		return len(fields) == 0
+	}
+
+	n := len(pf.TextMatches) + len(pf.Params)
+	for _, field := range fields {
+		if n == 0 {
+			return true
+		}
+		ok := matchTest(pf.Test, n, func(i int) bool {
+			if i < len(pf.TextMatches) {
+				return pf.TextMatches[i].Match(field.Value)
+			}
+			return pf.Params[i-len(pf.TextMatches)].Match(field)
+		})
+		if ok {
+			return true
+		}
+	}
+	return false
+}
+
+// Match returns true if the field matches the parameter filter.
+func (pf *ParamFilter) Match(field *vcard.Field) bool {
+	var values []string
+	for k, v := range field.Params {
+		if strings.EqualFold(k, pf.Name) {
+			values = append(values, v...)
+		}
+	}
+
+	if pf.IsNotDefined {
+		return len(values) == 0
+	}
+	if pf.TextMatch == nil {
+		return len(values) > 0
+	}
+	for _, v := range values {
+		if pf.TextMatch.Match(v) {
+			return true
+		}
+	}
+	return false
+}
+
+// Match returns true if the text matches. It returns false if the collation
+// isn't supported.
+func (tm *TextMatch) Match(text string) bool {
+	fold, ok := collations[tm.collation()]
+	if !ok {
+		return false
+	}
+
+	s, substr := fold(text), fold(tm.Text)
+	var match bool
+	switch tm.MatchType {
+	case MatchEquals:
+		match = s == substr
+	case MatchStartsWith:
+		match = strings.HasPrefix(s, substr)
+	case MatchEndsWith:
+		match = strings.HasSuffix(s, substr)
+	default:
+		match = strings.Contains(s, substr)
+	}
+	return match != tm.NegateCondition
+}
+
+func (tm *TextMatch) collation() string {
+	if tm.Collation == "" {
+		return CollationUnicodeCasemap
+	}
+	return tm.Collation
+}
+
+// collations maps supported collations to a function normalizing strings for
+// comparison.
+var collations = map[string]func(string) string{
+	CollationOctet: func(s string) string {
+		return s
+	},
+	CollationASCIICasemap: func(s string) string {
+		return strings.Map(func(r rune) rune {
+			if 'A' <= r && r <= 'Z' {
+				return r + 'a' - 'A'
+			}
+			return r
+		}, s)
+	},
+	// RFC 5051 also requires canonical decomposition, which isn't
+	// performed here
+	CollationUnicodeCasemap: func(s string) string {
+		return strings.Map(func(r rune) rune {
+			return unicode.ToLower(unicode.ToUpper(r))
+		}, s)
+	},
+}
+
+func (f *Filter) collationsSupported() bool {
+	supported := func(tm *TextMatch) bool {
+		_, ok := collations[tm.collation()]
+		return ok
+	}
+	for _, pf := range f.Props {
+		for i := range pf.TextMatches {
+			if !supported(&pf.TextMatches[i]) {
+				return false
+			}
+		}
+		for _, paramFilter := range pf.Params {
+			if paramFilter.TextMatch != nil && !supported(paramFilter.TextMatch) {
+				return false
+			}
+		}
+	}
+	return true
+}
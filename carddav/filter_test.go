@@ -0,0 +1,129 @@
+package carddav
+
+import (
+	"testing"
+
+	"github.com/emersion/go-vcard"
+)
+
+var filterTestCard = vcard.Card{
+	vcard.FieldFormattedName: {{Value: "Ünal Élise"}},
+	vcard.FieldEmail: {
+		{Value: "elise@example.org", Params: vcard.Params{vcard.ParamType: {"work"}}},
+		{Value: "elise@home.example.com"},
+	},
+}
+
+var filterTests = []struct {
+	name   string
+	filter Filter
+	want   bool
+}{
+	{
+		name:   "empty",
+		filter: Filter{},
+		want:   true,
+	},
+	{
+		name:   "defined",
+		filter: Filter{Props: []PropFilter{{Name: "email"}}},
+		want:   true,
+	},
+	{
+		name:   "notDefined",
+		filter: Filter{Props: []PropFilter{{Name: vcard.FieldTelephone}}},
+		want:   false,
+	},
+	{
+		name:   "isNotDefined",
+		filter: Filter{Props: []PropFilter{{Name: vcard.FieldTelephone, IsNotDefined: true}}},
+		want:   true,
+	},
+	{
+		name: "unicodeCasemap",
+		filter: Filter{Props: []PropFilter{{
+			Name:        vcard.FieldFormattedName,
+			TextMatches: []TextMatch{{Text: "üNAL", MatchType: MatchStartsWith}},
+		}}},
+		want: true,
+	},
+	{
+		name: "asciiCasemap",
+		filter: Filter{Props: []PropFilter{{
+			Name:        vcard.FieldFormattedName,
+			TextMatches: []TextMatch{{Text: "éLISE", Collation: CollationASCIICasemap, MatchType: MatchEndsWith}},
+		}}},
+		want: false,
+	},
+	{
+		name: "octet",
+		filter: Filter{Props: []PropFilter{{
+			Name:        vcard.FieldFormattedName,
+			TextMatches: []TextMatch{{Text: "Ünal Élise", Collation: CollationOctet, MatchType: MatchEquals}},
+		}}},
+		want: true,
+	},
+	{
+		name: "unsupportedCollation",
+		filter: Filter{Props: []PropFilter{{
+			Name:        vcard.FieldFormattedName,
+			TextMatches: []TextMatch{{Text: "Ünal", Collation: "i;unknown"}},
+		}}},
+		want: false,
+	},
+	{
+		name: "negate",
+		filter: Filter{Props: []PropFilter{{
+			Name:        vcard.FieldEmail,
+			TextMatches: []TextMatch{{Text: "@example.org", MatchType: MatchEndsWith, NegateCondition: true}},
+		}}},
+		// matches the second email
+		want: true,
+	},
+	{
+		name: "param",
+		filter: Filter{Props: []PropFilter{{
+			Name:        vcard.FieldEmail,
+			Test:        FilterAllOf,
+			TextMatches: []TextMatch{{Text: "home"}},
+			Params:      []ParamFilter{{Name: "type", TextMatch: &TextMatch{Text: "work", MatchType: MatchEquals}}},
+		}}},
+		want: false,
+	},
+	{
+		name: "paramNotDefined",
+		filter: Filter{Props: []PropFilter{{
+			Name:        vcard.FieldEmail,
+			Test:        FilterAllOf,
+			TextMatches: []TextMatch{{Text: "home"}},
+			Params:      []ParamFilter{{Name: vcard.ParamType, IsNotDefined: true}},
+		}}},
+		want: true,
+	},
+	{
+		name: "anyOf",
+		filter: Filter{Props: []PropFilter{
+			{Name: vcard.FieldTelephone},
+			{Name: vcard.FieldEmail},
+		}},
+		want: true,
+	},
+	{
+		name: "allOf",
+		filter: Filter{Test: FilterAllOf, Props: []PropFilter{
+			{Name: vcard.FieldTelephone},
+			{Name: vcard.FieldEmail},
+		}},
+		want: false,
+	},
+}
+
+func TestFilter_Match(t *testing.T) {
+	for _, test := range filterTests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := test.filter.Match(filterTestCard); got != test.want {
+				t.Errorf("Match() = %v, want %v", got, test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,815 @@
+package carddav
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"errors"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/emersion/go-vcard"
+)
+
+// A Backend stores address books.
+//
+// Paths are absolute URL paths. Collection paths end with a slash.
+type Backend interface {
+	CurrentUserPrincipal(ctx context.Context) (string, error)
+	AddressBookHomeSetPath(ctx context.Context) (string, error)
+	ListAddressBooks(ctx context.Context) ([]AddressBook, error)
+	GetAddressBook(ctx context.Context, path string) (*AddressBook, error)
+	GetAddressObject(ctx context.Context, path string) (*AddressObject, error)
+	ListAddressObjects(ctx context.Context, path string) ([]AddressObject, error)
+	// QueryAddressObjects returns the address objects of the address book
+	// matching the query filter. Applying the filter is an optimization: the
+	// handler filters the results again. The limit and data request don't
+	// need to be applied.
+	QueryAddressObjects(ctx context.Context, path string, query *AddressBookQuery) ([]AddressObject, error)
+	// PutAddressObject creates or replaces an address object. The
+	// preconditions must be checked atomically with the modification, see
+	// Preconditions.
+	PutAddressObject(ctx context.Context, path string, card vcard.Card, cond *Preconditions) (*AddressObject, error)
+	// DeleteAddressObject deletes an address object. The preconditions must be
+	// checked atomically with the deletion, see Preconditions.
+	DeleteAddressObject(ctx context.Context, path string, cond *Preconditions) error
+}
+
+// Preconditions are the If-Match and If-None-Match conditions of a request
+// modifying an address object, defined in RFC 9110 section 13.1. Backends
+// return ErrPreconditionFailed if they aren't met.
+type Preconditions struct {
+	// IfMatch is empty, "*" or a list of quoted ETags.
+	IfMatch string
+	// IfNoneMatch is empty, "*" or a list of quoted ETags.
+	IfNoneMatch string
+}
+
+func preconditionsFromRequest(r *http.Request) *Preconditions {
+	return &Preconditions{
+		IfMatch:     r.Header.Get("If-Match"),
+		IfNoneMatch: r.Header.Get("If-None-Match"),
+	}
+}
+
+// Check returns true if the conditions are met by the current address object,
+// which is nil if it doesn't exist. If-Match uses the strong comparison and
+// If-None-Match the weak comparison.
+func (cond *Preconditions) Check(ao *AddressObject) bool {
+	if cond == nil {
+		return true
+	}
+	if cond.IfMatch != "" && !matchETag(cond.IfMatch, ao, false) {
+		return false
+	}
+	if cond.IfNoneMatch != "" && matchETag(cond.IfNoneMatch, ao, true) {
+		return false
+	}
+	return true
+}
+
+// ErrInvalidSyncToken is returned by backends when a sync token is invalid or
+// has expired.
+var ErrInvalidSyncToken = errors.New("carddav: invalid sync token")
+
+// A SyncBackend is a Backend supporting the sync-collection REPORT, defined
+// in RFC 6578.
+type SyncBackend interface {
+	Backend
+	// SyncAddressObjects returns the changes of the address book since the
+	// sync token. An empty token requests all address objects.
+	SyncAddressObjects(ctx context.Context, path, syncToken string) (*SyncResponse, error)
+}
+
+// Handler serves address books over CardDAV.
+type Handler struct {
+	Backend Backend
+}
+
+type httpError struct {
+	code int
+	err  error
+	body *davError
+}
+
+func (err *httpError) Error() string {
+	return err.err.Error()
+}
+
+func newHTTPError(code int, err error) error {
+	return &httpError{code: code, err: err}
+}
+
+func newPreconditionError(code int, condition xml.Name) error {
+	return &httpError{
+		code: code,
+		err:  errors.New("carddav: precondition " + condition.Local + " failed"),
+		body: &davError{Condition: rawElement{XMLName: condition}},
+	}
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if h.Backend == nil {
+		http.Error(w, "carddav: no backend available", http.StatusInternalServerError)
+		return
+	}
+
+	if r.URL.Path == "/.well-known/carddav" {
+		// RFC 6764 section 5
+		principal, err := h.Backend.CurrentUserPrincipal(r.Context())
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		http.Redirect(w, r, principal, http.StatusPermanentRedirect)
+		return
+	}
+
+	var err error
+	switch r.Method {
+	case http.MethodOptions:
+		h.handleOptions(w)
+	case http.MethodGet, http.MethodHead:
+		err = h.handleGet(w, r)
+	case http.MethodPut:
+		err = h.handlePut(w, r)
+	case http.MethodDelete:
+		err = h.handleDelete(w, r)
+	case "PROPFIND":
+		err = h.handlePropfind(w, r)
+	case "REPORT":
+		err = h.handleReport(w, r)
+	default:
+		err = newHTTPError(http.StatusMethodNotAllowed, errors.New("carddav: unsupported method"))
+	}
+
+	if err != nil {
+		writeError(w, err)
+	}
+}
+
+func writeError(w http.ResponseWriter, err error) {
+	code := http.StatusInternalServerError
+	var body *davError
+	if err == ErrNotFound {
+		code = http.StatusNotFound
+	} else if err == ErrPreconditionFailed {
+		code = http.StatusPreconditionFailed
+	} else if httpErr, ok := err.(*httpError); ok {
+		code = httpErr.code
+		body = httpErr.body
+	}
+
+	if body == nil {
+		http.Error(w, err.Error(), code)
+		return
+	}
+	writeXML(w, code, body)
+}
+
+func writeXML(w http.ResponseWriter, code int, v interface{}) error {
+	w.Header().Set("Content-Type", "application/xml; charset=utf-8")
+	w.WriteHeader(code)
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	return xml.NewEncoder(w).Encode(v)
+}
+
+func (h *Handler) handleOptions(w http.ResponseWriter) {
+	w.Header().Set("DAV", "1, 3, addressbook")
+	w.Header().Set("Allow", "OPTIONS, GET, HEAD, PUT, DELETE, PROPFIND, REPORT")
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func encodeCard(card vcard.Card) ([]byte, error) {
+	var b bytes.Buffer
+	if err := vcard.NewEncoder(&b).Encode(card); err != nil {
+		return nil, err
+	}
+	return b.Bytes(), nil
+}
+
+func quoteETag(etag string) string {
+	return `"` + etag + `"`
+}
+
+func unquoteETag(s string) string {
+	s = strings.TrimPrefix(strings.TrimSpace(s), "W/")
+	if len(s) >= 2 && s[0] == '"' && s[len(s)-1] == '"' {
+		s = s[1 : len(s)-1]
+	}
+	return s
+}
+
+func (h *Handler) handleGet(w http.ResponseWriter, r *http.Request) error {
+	ao, err := h.Backend.GetAddressObject(r.Context(), r.URL.Path)
+	if err != nil {
+		return err
+	}
+
+	b, err := encodeCard(ao.Card)
+	if err != nil {
+		return err
+	}
+
+	w.Header().Set("Content-Type", vcard.MIMEType+"; charset=utf-8")
+	w.Header().Set("Content-Length", strconv.Itoa(len(b)))
+	if ao.ETag != "" {
+		w.Header().Set("ETag", quoteETag(ao.ETag))
+	}
+	if !ao.ModTime.IsZero() {
+		w.Header().Set("Last-Modified", ao.ModTime.UTC().Format(http.TimeFormat))
+	}
+	w.WriteHeader(http.StatusOK)
+	if r.Method != http.MethodHead {
+		_, err = w.Write(b)
+	}
+	return err
+}
+
+// matchETag returns true if the If-Match or If-None-Match header value v
+// matches the object. Weak ETags only match if weak is set. The ETags of
+// address objects are strong.
+func matchETag(v string, ao *AddressObject, weak bool) bool {
+	if ao == nil {
+		return false
+	}
+	for _, etag := range strings.Split(v, ",") {
+		etag = strings.TrimSpace(etag)
+		if weak {
+			etag = strings.TrimPrefix(etag, "W/")
+		}
+		if etag == "*" || etag == quoteETag(ao.ETag) {
+			return true
+		}
+	}
+	return false
+}
+
+// getExisting returns the address object at p, or nil if it doesn't exist.
+func (h *Handler) getExisting(ctx context.Context, p string) (*AddressObject, error) {
+	ao, err := h.Backend.GetAddressObject(ctx, p)
+	if err == ErrNotFound {
+		return nil, nil
+	}
+	return ao, err
+}
+
+func (h *Handler) handlePut(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+	p := r.URL.Path
+	if strings.HasSuffix(p, "/") {
+		return newHTTPError(http.StatusMethodNotAllowed, errors.New("carddav: can't PUT a collection"))
+	}
+
+	ab, err := h.Backend.GetAddressBook(ctx, path.Dir(p)+"/")
+	if err == ErrNotFound {
+		return newHTTPError(http.StatusConflict, errors.New("carddav: parent address book doesn't exist"))
+	} else if err != nil {
+		return err
+	}
+
+	var body io.Reader = r.Body
+	if ab.MaxResourceSize > 0 {
+		body = io.LimitReader(r.Body, ab.MaxResourceSize+1)
+	}
+	b, err := ioutil.ReadAll(body)
+	if err != nil {
+		return err
+	}
+	if ab.MaxResourceSize > 0 && int64(len(b)) > ab.MaxResourceSize {
+		return newPreconditionError(http.StatusForbidden, maxResourceSizeName)
+	}
+
+	card, err := vcard.NewDecoder(bytes.NewReader(b)).Decode()
+	if err != nil || card.Value(vcard.FieldUID) == "" {
+		return newPreconditionError(http.StatusForbidden, xml.Name{Space: carddavNamespace, Local: "valid-address-data"})
+	}
+
+	// Only used to pick the status code, the preconditions are checked by
+	// the backend
+	existing, err := h.getExisting(ctx, p)
+	if err != nil {
+		return err
+	}
+
+	ao, err := h.Backend.PutAddressObject(ctx, p, card, preconditionsFromRequest(r))
+	if err != nil {
+		return err
+	}
+
+	if ao.ETag != "" {
+		w.Header().Set("ETag", quoteETag(ao.ETag))
+	}
+	if existing == nil {
+		w.WriteHeader(http.StatusCreated)
+	} else {
+		w.WriteHeader(http.StatusNoContent)
+	}
+	return nil
+}
+
+func (h *Handler) handleDelete(w http.ResponseWriter, r *http.Request) error {
+	if err := h.Backend.DeleteAddressObject(r.Context(), r.URL.Path, preconditionsFromRequest(r)); err != nil {
+		return err
+	}
+	w.WriteHeader(http.StatusNoContent)
+	return nil
+}
+
+type propFunc func() (interface{}, error)
+
+// propFindRequest lists the requested properties. If allProp is set, all
+// properties are returned. If propName is set, only property names are
+// returned.
+type propFindRequest struct {
+	allProp  bool
+	propName bool
+	props    []xml.Name
+	// addressData is the address-data element of REPORT requests, if any
+	addressData *addressDataReq
+}
+
+func newPropFindRequest(prop *propNames, allProp, propName *struct{}) *propFindRequest {
+	if prop == nil || allProp != nil || propName != nil {
+		return &propFindRequest{allProp: propName == nil, propName: propName != nil}
+	}
+	return &propFindRequest{props: prop.Names, addressData: prop.AddressData}
+}
+
+func (req *propFindRequest) has(name xml.Name) bool {
+	for _, p := range req.props {
+		if p == name {
+			return true
+		}
+	}
+	return false
+}
+
+func newResponse(href string, req *propFindRequest, props map[xml.Name]propFunc) (*response, error) {
+	resp := &response{Hrefs: []string{href}}
+
+	if req.allProp || req.propName {
+		names := make([]xml.Name, 0, len(props))
+		for name := range props {
+			names = append(names, name)
+		}
+		sort.Slice(names, func(i, j int) bool {
+			if names[i].Space != names[j].Space {
+				return names[i].Space < names[j].Space
+			}
+			return names[i].Local < names[j].Local
+		})
+
+		var values []interface{}
+		for _, name := range names {
+			f := props[name]
+			if req.propName {
+				values = append(values, rawElement{XMLName: name})
+				continue
+			}
+			v, err := f()
+			if err != nil {
+				return nil, err
+			}
+			values = append(values, v)
+		}
+		resp.PropStats = append(resp.PropStats, propStat{
+			Prop:   prop{Values: values},
+			Status: statusLine(http.StatusOK),
+		})
+		return resp, nil
+	}
+
+	var found, notFound []interface{}
+	for _, name := range req.props {
+		f, ok := props[name]
+		if !ok {
+			notFound = append(notFound, rawElement{XMLName: name})
+			continue
+		}
+		v, err := f()
+		if err != nil {
+			return nil, err
+		}
+		found = append(found, v)
+	}
+	if len(found) > 0 {
+		resp.PropStats = append(resp.PropStats, propStat{
+			Prop:   prop{Values: found},
+			Status: statusLine(http.StatusOK),
+		})
+	}
+	if len(notFound) > 0 {
+		resp.PropStats = append(resp.PropStats, propStat{
+			Prop:   prop{Values: notFound},
+			Status: statusLine(http.StatusNotFound),
+		})
+	}
+	return resp, nil
+}
+
+func constProp(v interface{}) propFunc {
+	return func() (interface{}, error) {
+		return v, nil
+	}
+}
+
+func (h *Handler) handlePropfind(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+
+	var pf propFind
+	if r.ContentLength != 0 {
+		if err := xml.NewDecoder(r.Body).Decode(&pf); err != nil && err != io.EOF {
+			return newHTTPError(http.StatusBadRequest, err)
+		}
+	}
+	req := newPropFindRequest(pf.Prop, pf.AllProp, pf.PropName)
+
+	depth := 1
+	switch r.Header.Get("Depth") {
+	case "0":
+		depth = 0
+	case "1", "infinity", "":
+	default:
+		return newHTTPError(http.StatusBadRequest, errors.New("carddav: invalid Depth header"))
+	}
+
+	principal, err := h.Backend.CurrentUserPrincipal(ctx)
+	if err != nil {
+		return err
+	}
+	homeSet, err := h.Backend.AddressBookHomeSetPath(ctx)
+	if err != nil {
+		return err
+	}
+
+	var ms multiStatus
+	add := func(resp *response, err error) error {
+		if err != nil {
+			return err
+		}
+		ms.Responses = append(ms.Responses, *resp)
+		return nil
+	}
+
+	p := r.URL.Path
+	switch {
+	case p == principal || p == homeSet:
+		if err := add(h.propfindHome(ctx, p, req, principal, homeSet)); err != nil {
+			return err
+		}
+		if depth > 0 && p == homeSet {
+			abs, err := h.Backend.ListAddressBooks(ctx)
+			if err != nil {
+				return err
+			}
+			for i := range abs {
+				if err := add(h.propfindAddressBook(ctx, &abs[i], req, principal, homeSet)); err != nil {
+					return err
+				}
+			}
+		}
+	case strings.HasSuffix(p, "/"):
+		ab, err := h.Backend.GetAddressBook(ctx, p)
+		if err != nil {
+			return err
+		}
+		if err := add(h.propfindAddressBook(ctx, ab, req, principal, homeSet)); err != nil {
+			return err
+		}
+		if depth > 0 {
+			aos, err := h.Backend.ListAddressObjects(ctx, p)
+			if err != nil {
+				return err
+			}
+			for i := range aos {
+				if err := add(h.propfindAddressObject(ctx, &aos[i], req, principal, nil)); err != nil {
+					return err
+				}
+			}
+		}
+	default:
+		ao, err := h.Backend.GetAddressObject(ctx, p)
+		if err != nil {
+			return err
+		}
+		if err := add(h.propfindAddressObject(ctx, ao, req, principal, nil)); err != nil {
+			return err
+		}
+	}
+
+	return writeXML(w, http.StatusMultiStatus, &ms)
+}
+
+func (h *Handler) propfindHome(ctx context.Context, p string, req *propFindRequest, principal, homeSet string) (*response, error) {
+	rt := &resourceType{Collection: &struct{}{}}
+	if p == principal {
+		rt.Principal = &struct{}{}
+	}
+	props := map[xml.Name]propFunc{
+		resourceTypeName:         constProp(rt),
+		currentUserPrincipalName: constProp(&hrefProp{XMLName: currentUserPrincipalName, Href: principal}),
+		addressBookHomeSetName:   constProp(&hrefProp{XMLName: addressBookHomeSetName, Href: homeSet}),
+	}
+	return newResponse(p, req, props)
+}
+
+func (h *Handler) propfindAddressBook(ctx context.Context, ab *AddressBook, req *propFindRequest, principal, homeSet string) (*response, error) {
+	props := map[xml.Name]propFunc{
+		resourceTypeName:         constProp(&resourceType{Collection: &struct{}{}, AddressBook: &struct{}{}}),
+		currentUserPrincipalName: constProp(&hrefProp{XMLName: currentUserPrincipalName, Href: principal}),
+		addressBookHomeSetName:   constProp(&hrefProp{XMLName: addressBookHomeSetName, Href: homeSet}),
+		supportedAddressDataName: constProp(&supportedAddressData{Types: []addressDataType{
+			{ContentType: vcard.MIMEType, Version: "3.0"},
+			{ContentType: vcard.MIMEType, Version: "4.0"},
+		}}),
+	}
+	reports := []supportedReport{
+		newSupportedReport(addressBookQueryName),
+		newSupportedReport(addressBookMultigetName),
+	}
+	if _, ok := h.Backend.(SyncBackend); ok {
+		reports = append(reports, newSupportedReport(syncCollectionName))
+	}
+	props[supportedReportSetName] = constProp(&supportedReportSet{Reports: reports})
+	if ab.SyncToken != "" {
+		props[syncTokenName] = constProp(&textProp{XMLName: syncTokenName, Text: ab.SyncToken})
+	}
+	if ab.Name != "" {
+		props[displayNameName] = constProp(&textProp{XMLName: displayNameName, Text: ab.Name})
+	}
+	if ab.Description != "" {
+		props[addressBookDescriptionName] = constProp(&textProp{XMLName: addressBookDescriptionName, Text: ab.Description})
+	}
+	if ab.MaxResourceSize > 0 {
+		props[maxResourceSizeName] = constProp(&textProp{
+			XMLName: maxResourceSizeName,
+			Text:    strconv.FormatInt(ab.MaxResourceSize, 10),
+		})
+	}
+	return newResponse(ab.Path, req, props)
+}
+
+func newSupportedReport(name xml.Name) supportedReport {
+	var report supportedReport
+	report.Report.Name = rawElement{XMLName: name}
+	return report
+}
+
+// propfindAddressObject returns the properties of an address object. If
+// dataReq is non-nil, the address-data property is available.
+func (h *Handler) propfindAddressObject(ctx context.Context, ao *AddressObject, req *propFindRequest, principal string, dataReq *AddressDataRequest) (*response, error) {
+	props := map[xml.Name]propFunc{
+		resourceTypeName:         constProp(&resourceType{}),
+		currentUserPrincipalName: constProp(&hrefProp{XMLName: currentUserPrincipalName, Href: principal}),
+		getContentTypeName:       constProp(&textProp{XMLName: getContentTypeName, Text: vcard.MIMEType}),
+	}
+	if ao.ETag != "" {
+		props[getETagName] = constProp(&textProp{XMLName: getETagName, Text: quoteETag(ao.ETag)})
+	}
+	if ao.ContentLength > 0 {
+		props[getContentLengthName] = constProp(&textProp{
+			XMLName: getContentLengthName,
+			Text:    strconv.FormatInt(ao.ContentLength, 10),
+		})
+	}
+	if !ao.ModTime.IsZero() {
+		props[getLastModifiedName] = constProp(&textProp{
+			XMLName: getLastModifiedName,
+			Text:    ao.ModTime.UTC().Format(http.TimeFormat),
+		})
+	}
+	if dataReq != nil && req.has(addressDataName) {
+		props[addressDataName] = func() (interface{}, error) {
+			b, err := encodeCard(filterCard(ao.Card, dataReq))
+			if err != nil {
+				return nil, err
+			}
+			return &textProp{XMLName: addressDataName, Text: string(b)}, nil
+		}
+	}
+	return newResponse(ao.Path, req, props)
+}
+
+// filterCard returns a card only containing the requested properties.
+func filterCard(card vcard.Card, req *AddressDataRequest) vcard.Card {
+	if len(req.Props) == 0 {
+		return card
+	}
+
+	filtered := vcard.Card{vcard.FieldVersion: card[vcard.FieldVersion]}
+	for _, k := range req.Props {
+		k = strings.ToUpper(k)
+		if fields, ok := card[k]; ok {
+			filtered[k] = fields
+		}
+	}
+	return filtered
+}
+
+// addressDataRequest returns the address data requested in a REPORT request.
+func addressDataRequest(req *propFindRequest) *AddressDataRequest {
+	dataReq := new(AddressDataRequest)
+	if elem := req.addressData; elem != nil && elem.AllProp == nil {
+		for _, prop := range elem.Props {
+			dataReq.Props = append(dataReq.Props, prop.Name)
+		}
+	}
+	return dataReq
+}
+
+func (h *Handler) handleReport(w http.ResponseWriter, r *http.Request) error {
+	b, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		return err
+	}
+
+	var root struct {
+		XMLName xml.Name
+	}
+	if err := xml.Unmarshal(b, &root); err != nil {
+		return newHTTPError(http.StatusBadRequest, err)
+	}
+
+	switch root.XMLName {
+	case addressBookQueryName:
+		var query addressBookQuery
+		if err := xml.Unmarshal(b, &query); err != nil {
+			return newHTTPError(http.StatusBadRequest, err)
+		}
+		return h.handleQuery(w, r, &query)
+	case addressBookMultigetName:
+		var multiget addressBookMultiget
+		if err := xml.Unmarshal(b, &multiget); err != nil {
+			return newHTTPError(http.StatusBadRequest, err)
+		}
+		return h.handleMultiget(w, r, &multiget)
+	case syncCollectionName:
+		var sync syncCollection
+		if err := xml.Unmarshal(b, &sync); err != nil {
+			return newHTTPError(http.StatusBadRequest, err)
+		}
+		return h.handleSyncCollection(w, r, &sync)
+	default:
+		return newPreconditionError(http.StatusForbidden, xml.Name{Space: davNamespace, Local: "supported-report"})
+	}
+}
+
+func (h *Handler) handleQuery(w http.ResponseWriter, r *http.Request, elem *addressBookQuery) error {
+	ctx := r.Context()
+	req := newPropFindRequest(elem.Prop, elem.AllProp, elem.PropName)
+	dataReq := addressDataRequest(req)
+
+	query := &AddressBookQuery{
+		DataRequest: *dataReq,
+		Filter:      parseFilter(&elem.Filter),
+	}
+	if !query.Filter.collationsSupported() {
+		return newPreconditionError(http.StatusForbidden, xml.Name{Space: carddavNamespace, Local: "supported-collation"})
+	}
+	if elem.Limit != nil {
+		if elem.Limit.NResults <= 0 {
+			return newHTTPError(http.StatusBadRequest, errors.New("carddav: invalid limit"))
+		}
+		query.Limit = elem.Limit.NResults
+	}
+
+	principal, err := h.Backend.CurrentUserPrincipal(ctx)
+	if err != nil {
+		return err
+	}
+	aos, err := h.Backend.QueryAddressObjects(ctx, r.URL.Path, query)
+	if err != nil {
+		return err
+	}
+	matches := make([]AddressObject, 0, len(aos))
+	for _, ao := range aos {
+		if query.Filter.Match(ao.Card) {
+			matches = append(matches, ao)
+		}
+	}
+	aos = matches
+
+	var ms multiStatus
+	truncated := query.Limit > 0 && len(aos) > query.Limit
+	if truncated {
+		aos = aos[:query.Limit]
+	}
+	for i := range aos {
+		resp, err := h.propfindAddressObject(ctx, &aos[i], req, principal, dataReq)
+		if err != nil {
+			return err
+		}
+		ms.Responses = append(ms.Responses, *resp)
+	}
+	if truncated {
+		// RFC 6352 section 8.6.1
+		ms.Responses = append(ms.Responses, response{
+			Hrefs:  []string{r.URL.Path},
+			Status: statusLine(http.StatusInsufficientStorage),
+		})
+	}
+	return writeXML(w, http.StatusMultiStatus, &ms)
+}
+
+// addressObjectPath returns the path of an address object referred to by an
+// href of a multiget request. Only the direct children of the address book are
+// allowed.
+func addressObjectPath(book, href string) (string, bool) {
+	u, err := url.Parse(href)
+	if err != nil || u.Path == "" || strings.HasSuffix(u.Path, "/") {
+		return "", false
+	}
+	p := path.Clean(u.Path)
+	if path.Dir(p) != strings.TrimSuffix(path.Clean(book), "/") {
+		return "", false
+	}
+	return p, true
+}
+
+func (h *Handler) handleMultiget(w http.ResponseWriter, r *http.Request, elem *addressBookMultiget) error {
+	ctx := r.Context()
+	req := newPropFindRequest(elem.Prop, elem.AllProp, elem.PropName)
+	dataReq := addressDataRequest(req)
+
+	principal, err := h.Backend.CurrentUserPrincipal(ctx)
+	if err != nil {
+		return err
+	}
+
+	var ms multiStatus
+	for _, href := range elem.Hrefs {
+		href = strings.TrimSpace(href)
+		var ao *AddressObject
+		p, ok := addressObjectPath(r.URL.Path, href)
+		if ok {
+			ao, err = h.Backend.GetAddressObject(ctx, p)
+		} else {
+			err = ErrNotFound
+		}
+		if err == ErrNotFound {
+			ms.Responses = append(ms.Responses, response{
+				Hrefs:  []string{href},
+				Status: statusLine(http.StatusNotFound),
+			})
+			continue
+		} else if err != nil {
+			return err
+		}
+
+		resp, err := h.propfindAddressObject(ctx, ao, req, principal, dataReq)
+		if err != nil {
+			return err
+		}
+		ms.Responses = append(ms.Responses, *resp)
+	}
+	return writeXML(w, http.StatusMultiStatus, &ms)
+}
+
+func (h *Handler) handleSyncCollection(w http.ResponseWriter, r *http.Request, elem *syncCollection) error {
+	ctx := r.Context()
+	backend, ok := h.Backend.(SyncBackend)
+	if !ok {
+		return newPreconditionError(http.StatusForbidden, xml.Name{Space: davNamespace, Local: "supported-report"})
+	}
+	if elem.SyncLevel != "1" {
+		return newHTTPError(http.StatusBadRequest, errors.New("carddav: unsupported sync level"))
+	}
+
+	sync, err := backend.SyncAddressObjects(ctx, r.URL.Path, strings.TrimSpace(elem.SyncToken))
+	if err == ErrInvalidSyncToken {
+		return newPreconditionError(http.StatusForbidden, xml.Name{Space: davNamespace, Local: "valid-sync-token"})
+	} else if err != nil {
+		return err
+	}
+
+	principal, err := h.Backend.CurrentUserPrincipal(ctx)
+	if err != nil {
+		return err
+	}
+
+	req := newPropFindRequest(elem.Prop, nil, nil)
+	ms := multiStatus{SyncToken: sync.SyncToken}
+	for i := range sync.Updated {
+		resp, err := h.propfindAddressObject(ctx, &sync.Updated[i], req, principal, nil)
+		if err != nil {
+			return err
+		}
+		ms.Responses = append(ms.Responses, *resp)
+	}
+	for _, p := range sync.Deleted {
+		ms.Responses = append(ms.Responses, response{
+			Hrefs:  []string{p},
+			Status: statusLine(http.StatusNotFound),
+		})
+	}
+	return writeXML(w, http.StatusMultiStatus, &ms)
+}
@@ -0,0 +1,496 @@
+package carddav
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/xml"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/emersion/go-vcard"
+)
+
+type testBackend struct {
+	mutex     sync.Mutex
+	book      AddressBook
+	objects   map[string]AddressObject
+	lastQuery *AddressBookQuery
+
+	// Changes, for synchronization
+	version  int
+	versions map[string]int // path → version of the last change
+	deleted  map[string]bool
+}
+
+func newTestBackend() *testBackend {
+	return &testBackend{
+		book:     AddressBook{Path: "/contacts/default/", Name: "Contacts", MaxResourceSize: 4096},
+		objects:  make(map[string]AddressObject),
+		versions: make(map[string]int),
+		deleted:  make(map[string]bool),
+	}
+}
+
+func (b *testBackend) change(p string, deleted bool) {
+	b.version++
+	b.versions[p] = b.version
+	b.deleted[p] = deleted
+}
+
+func (b *testBackend) SyncAddressObjects(ctx context.Context, p, syncToken string) (*SyncResponse, error) {
+	if p != b.book.Path {
+		return nil, ErrNotFound
+	}
+
+	var since int
+	if syncToken != "" {
+		if !strings.HasPrefix(syncToken, "urn:test:") {
+			return nil, ErrInvalidSyncToken
+		}
+		var err error
+		if since, err = strconv.Atoi(strings.TrimPrefix(syncToken, "urn:test:")); err != nil {
+			return nil, ErrInvalidSyncToken
+		}
+	}
+
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	sync := &SyncResponse{SyncToken: "urn:test:" + strconv.Itoa(b.version)}
+	var paths []string
+	for p := range b.versions {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+	for _, p := range paths {
+		if b.versions[p] <= since {
+			continue
+		}
+		if b.deleted[p] {
+			if since > 0 {
+				sync.Deleted = append(sync.Deleted, p)
+			}
+		} else {
+			sync.Updated = append(sync.Updated, b.objects[p])
+		}
+	}
+	return sync, nil
+}
+
+func (b *testBackend) CurrentUserPrincipal(ctx context.Context) (string, error) {
+	return "/principal/", nil
+}
+
+func (b *testBackend) AddressBookHomeSetPath(ctx context.Context) (string, error) {
+	return "/contacts/", nil
+}
+
+func (b *testBackend) ListAddressBooks(ctx context.Context) ([]AddressBook, error) {
+	ab, err := b.GetAddressBook(ctx, b.book.Path)
+	if err != nil {
+		return nil, err
+	}
+	return []AddressBook{*ab}, nil
+}
+
+func (b *testBackend) GetAddressBook(ctx context.Context, p string) (*AddressBook, error) {
+	if p != b.book.Path {
+		return nil, ErrNotFound
+	}
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	ab := b.book
+	ab.SyncToken = "urn:test:" + strconv.Itoa(b.version)
+	return &ab, nil
+}
+
+func (b *testBackend) GetAddressObject(ctx context.Context, p string) (*AddressObject, error) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	ao := b.lookup(p)
+	if ao == nil {
+		return nil, ErrNotFound
+	}
+	return ao, nil
+}
+
+// lookup returns the address object at p, or nil if it doesn't exist. The
+// caller must hold the mutex.
+func (b *testBackend) lookup(p string) *AddressObject {
+	ao, ok := b.objects[p]
+	if !ok {
+		return nil
+	}
+	return &ao
+}
+
+func (b *testBackend) ListAddressObjects(ctx context.Context, p string) ([]AddressObject, error) {
+	if p != b.book.Path {
+		return nil, ErrNotFound
+	}
+
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	var l []AddressObject
+	for _, ao := range b.objects {
+		if path.Dir(ao.Path)+"/" == p {
+			l = append(l, ao)
+		}
+	}
+	sort.Slice(l, func(i, j int) bool {
+		return l[i].Path < l[j].Path
+	})
+	return l, nil
+}
+
+func (b *testBackend) QueryAddressObjects(ctx context.Context, p string, query *AddressBookQuery) ([]AddressObject, error) {
+	b.mutex.Lock()
+	b.lastQuery = query
+	b.mutex.Unlock()
+	return b.ListAddressObjects(ctx, p)
+}
+
+func (b *testBackend) PutAddressObject(ctx context.Context, p string, card vcard.Card, cond *Preconditions) (*AddressObject, error) {
+	var buf bytes.Buffer
+	if err := vcard.NewEncoder(&buf).Encode(card); err != nil {
+		return nil, err
+	}
+	sum := sha1.Sum(buf.Bytes())
+
+	ao := AddressObject{
+		Path:          p,
+		ContentLength: int64(buf.Len()),
+		ETag:          hex.EncodeToString(sum[:]),
+		Card:          card,
+	}
+
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	if !cond.Check(b.lookup(p)) {
+		return nil, ErrPreconditionFailed
+	}
+	b.objects[p] = ao
+	b.change(p, false)
+	return &ao, nil
+}
+
+func (b *testBackend) DeleteAddressObject(ctx context.Context, p string, cond *Preconditions) error {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	ao := b.lookup(p)
+	if ao == nil {
+		return ErrNotFound
+	}
+	if !cond.Check(ao) {
+		return ErrPreconditionFailed
+	}
+	delete(b.objects, p)
+	b.change(p, true)
+	return nil
+}
+
+type testMultiStatus struct {
+	Responses []struct {
+		Href      string `xml:"href"`
+		Status    string `xml:"status"`
+		PropStats []struct {
+			Status string `xml:"status"`
+			Prop   struct {
+				ETag        string    `xml:"getetag"`
+				AddressData string    `xml:"address-data"`
+				HomeSet     string    `xml:"addressbook-home-set>href"`
+				DisplayName string    `xml:"displayname"`
+				AddressBook *struct{} `xml:"resourcetype>addressbook"`
+			} `xml:"prop"`
+		} `xml:"propstat"`
+	} `xml:"response"`
+}
+
+func testCard(uid, fn string) string {
+	return "BEGIN:VCARD\r\n" +
+		"VERSION:4.0\r\n" +
+		"FN:" + fn + "\r\n" +
+		"UID:" + uid + "\r\n" +
+		"END:VCARD\r\n"
+}
+
+type testClient struct {
+	t      *testing.T
+	server *httptest.Server
+}
+
+func (c *testClient) do(method, p, body string, header http.Header) (*http.Response, string) {
+	req, err := http.NewRequest(method, c.server.URL+p, strings.NewReader(body))
+	if err != nil {
+		c.t.Fatalf("http.NewRequest() = %v", err)
+	}
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		c.t.Fatalf("%v %v: %v", method, p, err)
+	}
+	defer resp.Body.Close()
+	b, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		c.t.Fatalf("%v %v: %v", method, p, err)
+	}
+	return resp, string(b)
+}
+
+func (c *testClient) multiStatus(method, p, body string, header http.Header) *testMultiStatus {
+	resp, b := c.do(method, p, body, header)
+	if resp.StatusCode != http.StatusMultiStatus {
+		c.t.Fatalf("%v %v: status %v, want 207: %v", method, p, resp.StatusCode, b)
+	}
+	var ms testMultiStatus
+	if err := xml.Unmarshal([]byte(b), &ms); err != nil {
+		c.t.Fatalf("%v %v: invalid XML: %v", method, p, err)
+	}
+	return &ms
+}
+
+func newTestServer(t *testing.T) (*testClient, *testBackend) {
+	backend := newTestBackend()
+	server := httptest.NewServer(&Handler{Backend: backend})
+	return &testClient{t, server}, backend
+}
+
+func TestHandler_putGetDelete(t *testing.T) {
+	c, _ := newTestServer(t)
+	defer c.server.Close()
+	p := "/contacts/default/alice.vcf"
+
+	resp, _ := c.do(http.MethodPut, p, testCard("alice", "Alice"), http.Header{"If-None-Match": {"*"}})
+	if resp.StatusCode != http.StatusCreated {
+		t.Fatalf("PUT: status %v, want 201", resp.StatusCode)
+	}
+	etag := resp.Header.Get("ETag")
+	if etag == "" {
+		t.Fatalf("PUT: no ETag")
+	}
+
+	resp, _ = c.do(http.MethodPut, p, testCard("alice", "Alice"), http.Header{"If-None-Match": {"*"}})
+	if resp.StatusCode != http.StatusPreconditionFailed {
+		t.Errorf("PUT with If-None-Match: status %v, want 412", resp.StatusCode)
+	}
+	resp, _ = c.do(http.MethodPut, p, testCard("alice", "Alice L."), http.Header{"If-Match": {`"outdated"`}})
+	if resp.StatusCode != http.StatusPreconditionFailed {
+		t.Errorf("PUT with stale If-Match: status %v, want 412", resp.StatusCode)
+	}
+	resp, _ = c.do(http.MethodPut, p, testCard("alice", "Alice L."), http.Header{"If-Match": {"W/" + etag}})
+	if resp.StatusCode != http.StatusPreconditionFailed {
+		t.Errorf("PUT with weak If-Match: status %v, want 412", resp.StatusCode)
+	}
+	resp, _ = c.do(http.MethodPut, p, testCard("alice", "Alice L."), http.Header{"If-Match": {etag}})
+	if resp.StatusCode != http.StatusNoContent {
+		t.Fatalf("PUT with If-Match: status %v, want 204", resp.StatusCode)
+	}
+	etag = resp.Header.Get("ETag")
+
+	resp, body := c.do(http.MethodGet, p, "", nil)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("GET: status %v, want 200", resp.StatusCode)
+	}
+	if got := resp.Header.Get("ETag"); got != etag {
+		t.Errorf("GET: ETag = %v, want %v", got, etag)
+	}
+	if want := testCard("alice", "Alice L."); body != want {
+		t.Errorf("GET: body = \n%v\nbut want:\n%v", body, want)
+	}
+
+	resp, _ = c.do(http.MethodDelete, p, "", http.Header{"If-Match": {`"outdated"`}})
+	if resp.StatusCode != http.StatusPreconditionFailed {
+		t.Errorf("DELETE with stale If-Match: status %v, want 412", resp.StatusCode)
+	}
+	resp, _ = c.do(http.MethodDelete, p, "", nil)
+	if resp.StatusCode != http.StatusNoContent {
+		t.Errorf("DELETE: status %v, want 204", resp.StatusCode)
+	}
+	resp, _ = c.do(http.MethodGet, p, "", nil)
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("GET after DELETE: status %v, want 404", resp.StatusCode)
+	}
+}
+
+func TestHandler_putInvalid(t *testing.T) {
+	c, _ := newTestServer(t)
+	defer c.server.Close()
+
+	resp, body := c.do(http.MethodPut, "/contacts/default/invalid.vcf", "BEGIN:VCARD\r\nVERSION:4.0\r\nFN:No UID\r\nEND:VCARD\r\n", nil)
+	if resp.StatusCode != http.StatusForbidden || !strings.Contains(body, "valid-address-data") {
+		t.Errorf("PUT without UID: status %v, want 403: %v", resp.StatusCode, body)
+	}
+
+	resp, _ = c.do(http.MethodPut, "/contacts/missing/card.vcf", testCard("bob", "Bob"), nil)
+	if resp.StatusCode != http.StatusConflict {
+		t.Errorf("PUT outside address book: status %v, want 409", resp.StatusCode)
+	}
+
+	resp, body = c.do(http.MethodPut, "/contacts/default/big.vcf", testCard("big", strings.Repeat("a", 5000)), nil)
+	if resp.StatusCode != http.StatusForbidden || !strings.Contains(body, "max-resource-size") {
+		t.Errorf("PUT too large: status %v, want 403: %v", resp.StatusCode, body)
+	}
+}
+
+func TestHandler_propfind(t *testing.T) {
+	c, backend := newTestServer(t)
+	defer c.server.Close()
+	ctx := context.Background()
+	for _, uid := range []string{"alice", "bob"} {
+		card, _ := vcard.NewDecoder(strings.NewReader(testCard(uid, uid))).Decode()
+		backend.PutAddressObject(ctx, path.Join(backend.book.Path, uid+".vcf"), card, nil)
+	}
+
+	ms := c.multiStatus("PROPFIND", "/principal/", `<?xml version="1.0"?>
+<d:propfind xmlns:d="DAV:" xmlns:c="urn:ietf:params:xml:ns:carddav">
+	<d:prop><c:addressbook-home-set/></d:prop>
+</d:propfind>`, http.Header{"Depth": {"0"}})
+	if len(ms.Responses) != 1 || ms.Responses[0].PropStats[0].Prop.HomeSet != "/contacts/" {
+		t.Errorf("PROPFIND principal: unexpected response %+v", ms)
+	}
+
+	ms = c.multiStatus("PROPFIND", "/contacts/", "", http.Header{"Depth": {"1"}})
+	if len(ms.Responses) != 2 {
+		t.Fatalf("PROPFIND home set: got %v responses, want 2", len(ms.Responses))
+	}
+	if r := ms.Responses[1]; r.Href != "/contacts/default/" || r.PropStats[0].Prop.DisplayName != "Contacts" || r.PropStats[0].Prop.AddressBook == nil {
+		t.Errorf("PROPFIND home set: unexpected address book %+v", r)
+	}
+
+	ms = c.multiStatus("PROPFIND", "/contacts/default/", `<?xml version="1.0"?>
+<propfind xmlns="DAV:"><prop><getetag/><displayname/></prop></propfind>`, http.Header{"Depth": {"1"}})
+	if len(ms.Responses) != 3 {
+		t.Fatalf("PROPFIND address book: got %v responses, want 3", len(ms.Responses))
+	}
+	for i, href := range []string{"/contacts/default/alice.vcf", "/contacts/default/bob.vcf"} {
+		r := ms.Responses[i+1]
+		if r.Href != href {
+			t.Errorf("PROPFIND address book: href = %v, want %v", r.Href, href)
+		}
+		if len(r.PropStats) != 2 || r.PropStats[0].Prop.ETag == "" || !strings.Contains(r.PropStats[1].Status, "404") {
+			t.Errorf("PROPFIND address book: unexpected response %+v", r)
+		}
+	}
+}
+
+func TestHandler_report(t *testing.T) {
+	c, backend := newTestServer(t)
+	defer c.server.Close()
+	ctx := context.Background()
+	cards := make(map[string]vcard.Card)
+	for _, uid := range []string{"alice", "bob", "dave"} {
+		card, _ := vcard.NewDecoder(strings.NewReader(testCard(uid, uid))).Decode()
+		if uid == "dave" {
+			card.AddValue(vcard.FieldEmail, uid+"@example.org")
+		} else {
+			card.AddValue(vcard.FieldEmail, uid+"@example.com")
+		}
+		backend.PutAddressObject(ctx, path.Join(backend.book.Path, uid+".vcf"), card, nil)
+		cards[uid] = card
+	}
+	card, _ := vcard.NewDecoder(strings.NewReader(testCard("carol", "carol"))).Decode()
+	backend.PutAddressObject(ctx, "/contacts/other/carol.vcf", card, nil)
+
+	ms := c.multiStatus("REPORT", "/contacts/default/", `<?xml version="1.0"?>
+<C:addressbook-multiget xmlns:D="DAV:" xmlns:C="urn:ietf:params:xml:ns:carddav">
+	<D:prop>
+		<D:getetag/>
+		<C:address-data><C:prop name="FN"/></C:address-data>
+	</D:prop>
+	<D:href>/contacts/default/alice.vcf</D:href>
+	<D:href>/contacts/default/missing.vcf</D:href>
+	<D:href>/contacts/other/carol.vcf</D:href>
+	<D:href>/contacts/default/../other/carol.vcf</D:href>
+	<D:href>http://example.org/contacts/default/bob.vcf</D:href>
+</C:addressbook-multiget>`, nil)
+	if len(ms.Responses) != 5 {
+		t.Fatalf("multiget: got %v responses, want 5", len(ms.Responses))
+	}
+	want := "BEGIN:VCARD\r\nVERSION:4.0\r\nFN:alice\r\nEND:VCARD\r\n"
+	if got := ms.Responses[0].PropStats[0].Prop.AddressData; got != want {
+		t.Errorf("multiget: address data = \n%v\nbut want:\n%v", got, want)
+	}
+	if r := ms.Responses[1]; r.Href != "/contacts/default/missing.vcf" || !strings.Contains(r.Status, "404") {
+		t.Errorf("multiget: unexpected response for missing card %+v", r)
+	}
+	for _, r := range ms.Responses[2:4] {
+		if !strings.Contains(r.Status, "404") {
+			t.Errorf("multiget: unexpected response for card outside the address book %+v", r)
+		}
+	}
+	if r := ms.Responses[4]; len(r.PropStats) == 0 || !strings.Contains(r.PropStats[0].Prop.AddressData, "FN:bob") {
+		t.Errorf("multiget: unexpected response for absolute URL %+v", r)
+	}
+
+	ms = c.multiStatus("REPORT", "/contacts/default/", `<?xml version="1.0"?>
+<C:addressbook-query xmlns:D="DAV:" xmlns:C="urn:ietf:params:xml:ns:carddav">
+	<D:prop><D:getetag/><C:address-data/></D:prop>
+	<C:filter test="allof">
+		<C:prop-filter name="FN"/>
+		<C:prop-filter name="EMAIL" test="allof">
+			<C:text-match match-type="ends-with" negate-condition="yes">@example.org</C:text-match>
+			<C:param-filter name="TYPE"><C:is-not-defined/></C:param-filter>
+		</C:prop-filter>
+	</C:filter>
+	<C:limit><C:nresults>1</C:nresults></C:limit>
+</C:addressbook-query>`, nil)
+	if len(ms.Responses) != 2 {
+		t.Fatalf("query: got %v responses, want 2", len(ms.Responses))
+	}
+	b, _ := encodeCard(cards["alice"])
+	if got, want := ms.Responses[0].PropStats[0].Prop.AddressData, string(b); got != want {
+		t.Errorf("query: address data = \n%v\nbut want:\n%v", got, want)
+	}
+	if r := ms.Responses[1]; r.Href != "/contacts/default/" || !strings.Contains(r.Status, "507") {
+		t.Errorf("query: unexpected truncation response %+v", r)
+	}
+
+	wantQuery := &AddressBookQuery{
+		Filter: Filter{
+			Test: FilterAllOf,
+			Props: []PropFilter{
+				{Name: "FN", Test: FilterAnyOf},
+				{
+					Name: "EMAIL",
+					Test: FilterAllOf,
+					TextMatches: []TextMatch{{
+						Text:            "@example.org",
+						Collation:       CollationUnicodeCasemap,
+						MatchType:       MatchEndsWith,
+						NegateCondition: true,
+					}},
+					Params: []ParamFilter{{Name: "TYPE", IsNotDefined: true}},
+				},
+			},
+		},
+		Limit: 1,
+	}
+	if !reflect.DeepEqual(backend.lastQuery, wantQuery) {
+		t.Errorf("query = %+v, want %+v", backend.lastQuery, wantQuery)
+	}
+
+	// The backend doesn't filter, the handler does
+	ms = c.multiStatus("REPORT", "/contacts/default/", `<?xml version="1.0"?>
+<C:addressbook-query xmlns:D="DAV:" xmlns:C="urn:ietf:params:xml:ns:carddav">
+	<D:prop><D:getetag/></D:prop>
+	<C:filter>
+		<C:prop-filter name="EMAIL">
+			<C:text-match match-type="ends-with">@example.org</C:text-match>
+		</C:prop-filter>
+	</C:filter>
+</C:addressbook-query>`, nil)
+	if len(ms.Responses) != 1 || ms.Responses[0].Href != "/contacts/default/dave.vcf" {
+		t.Errorf("query: unexpected responses %+v", ms.Responses)
+	}
+}
@@ -0,0 +1,290 @@
+// Package contactcsv converts cards from and to the CSV contact formats
+// exported by Google Contacts and Outlook.
+//
+// The mapping between CSV columns and card fields is configurable, so that
+// custom spreadsheets can be imported too.
+package contactcsv
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/emersion/go-vcard"
+)
+
+// Part is the part of a field a column contains.
+type Part int
+
+const (
+	// The whole field value.
+	PartValue Part = iota
+	// The field types, formatted as a label such as "Home" or "Work Fax".
+	PartLabel
+
+	// Components of FieldName.
+	PartFamilyName
+	PartGivenName
+	PartAdditionalName
+	PartHonorificPrefix
+	PartHonorificSuffix
+
+	// Components of FieldAddress.
+	PartPostOfficeBox
+	PartExtendedAddress
+	PartStreetAddress
+	PartLocality
+	PartRegion
+	PartPostalCode
+	PartCountry
+
+	// Components of FieldOrganization.
+	PartOrganizationName
+	PartOrganizationUnit
+)
+
+// A Column maps a CSV column to a part of a card field.
+type Column struct {
+	// Header is the name of the column in the CSV header row.
+	Header string
+	// Key is the property the column maps to.
+	Key string
+	// Types, if non-nil, restricts the column to fields whose types are
+	// exactly Types, ignoring "pref", "voice" and "internet". Fields
+	// created from the column get these types.
+	Types []string
+	// Index is the index of the field among the fields matching Key and
+	// Types.
+	Index int
+	Part  Part
+	// Separator, if non-empty, is the separator used between list items in
+	// the column, e.g. " ::: " for categories exported by Google Contacts.
+	Separator string
+}
+
+// A Mapping is a list of columns.
+type Mapping []*Column
+
+func (col *Column) selectorKey() string {
+	return col.Key + "\x00" + strings.Join(col.Types, ",") + "\x00" + strconv.Itoa(col.Index)
+}
+
+// matchTypes returns true if the field types match the column types.
+func (col *Column) matchTypes(f *vcard.Field) bool {
+	if col.Types == nil {
+		return true
+	}
+	var types []string
+	for _, t := range f.Params.Types() {
+		switch t {
+		case "pref", vcard.TypeVoice, "internet":
+			continue
+		}
+		types = append(types, t)
+	}
+	if len(types) != len(col.Types) {
+		return false
+	}
+	for _, t := range col.Types {
+		if !f.Params.HasType(t) {
+			return false
+		}
+	}
+	return true
+}
+
+var labelTypes = map[string]string{
+	"mobile":   vcard.TypeCell,
+	"business": vcard.TypeWork,
+	"homepage": "",
+	"main":     "main-number",
+	"other":    "",
+	"smtp":     "", // Outlook email address type
+}
+
+// parseLabel converts a label such as "Work Fax" or "* Mobile" to field types.
+// A leading star marks the preferred field.
+func parseLabel(label string) (types []string, pref bool) {
+	label = strings.TrimSpace(label)
+	if strings.HasPrefix(label, "*") {
+		pref = true
+		label = strings.TrimSpace(label[1:])
+	}
+	for _, word := range strings.Fields(strings.ToLower(label)) {
+		t, ok := labelTypes[word]
+		if !ok {
+			t = word
+		}
+		if t != "" {
+			types = append(types, t)
+		}
+	}
+	return types, pref
+}
+
+// formatLabel converts field types to a label.
+func formatLabel(f *vcard.Field) string {
+	var words []string
+	for _, t := range f.Params.Types() {
+		switch t {
+		case "pref", vcard.TypeVoice, "internet":
+			continue
+		case vcard.TypeCell:
+			t = "mobile"
+		case "main-number":
+			t = "main"
+		}
+		words = append(words, strings.Title(t))
+	}
+	label := strings.Join(words, " ")
+	if f.Params.Get(vcard.ParamPreferred) == "1" || f.Params.HasType("pref") {
+		label = "* " + label
+	}
+	return label
+}
+
+func nameComponent(name *vcard.Name, part Part) *string {
+	switch part {
+	case PartFamilyName:
+		return &name.FamilyName
+	case PartGivenName:
+		return &name.GivenName
+	case PartAdditionalName:
+		return &name.AdditionalName
+	case PartHonorificPrefix:
+		return &name.HonorificPrefix
+	case PartHonorificSuffix:
+		return &name.HonorificSuffix
+	}
+	return nil
+}
+
+func addressComponent(address *vcard.Address, part Part) *string {
+	switch part {
+	case PartPostOfficeBox:
+		return &address.PostOfficeBox
+	case PartExtendedAddress:
+		return &address.ExtendedAddress
+	case PartStreetAddress:
+		return &address.StreetAddress
+	case PartLocality:
+		return &address.Locality
+	case PartRegion:
+		return &address.Region
+	case PartPostalCode:
+		return &address.PostalCode
+	case PartCountry:
+		return &address.Country
+	}
+	return nil
+}
+
+func numbered(prefix string, n int, suffix string) string {
+	return prefix + " " + strconv.Itoa(n+1) + " - " + suffix
+}
+
+// GoogleMapping is the mapping used by Google Contacts CSV exports. It
+// contains up to three emails, phone numbers, addresses and websites.
+var GoogleMapping = googleMapping(3)
+
+func googleMapping(n int) Mapping {
+	m := Mapping{
+		{Header: "First Name", Key: vcard.FieldName, Part: PartGivenName},
+		{Header: "Middle Name", Key: vcard.FieldName, Part: PartAdditionalName},
+		{Header: "Last Name", Key: vcard.FieldName, Part: PartFamilyName},
+		{Header: "Name Prefix", Key: vcard.FieldName, Part: PartHonorificPrefix},
+		{Header: "Name Suffix", Key: vcard.FieldName, Part: PartHonorificSuffix},
+		{Header: "Nickname", Key: vcard.FieldNickname},
+		{Header: "Organization Name", Key: vcard.FieldOrganization, Part: PartOrganizationName},
+		{Header: "Organization Title", Key: vcard.FieldTitle},
+		{Header: "Organization Department", Key: vcard.FieldOrganization, Part: PartOrganizationUnit},
+		{Header: "Birthday", Key: vcard.FieldBirthday},
+		{Header: "Notes", Key: vcard.FieldNote},
+		{Header: "Photo", Key: vcard.FieldPhoto},
+		{Header: "Labels", Key: vcard.FieldCategories, Separator: " ::: "},
+	}
+	for i := 0; i < n; i++ {
+		m = append(m,
+			&Column{Header: numbered("E-mail", i, "Label"), Key: vcard.FieldEmail, Index: i, Part: PartLabel},
+			&Column{Header: numbered("E-mail", i, "Value"), Key: vcard.FieldEmail, Index: i},
+		)
+	}
+	for i := 0; i < n; i++ {
+		m = append(m,
+			&Column{Header: numbered("Phone", i, "Label"), Key: vcard.FieldTelephone, Index: i, Part: PartLabel},
+			&Column{Header: numbered("Phone", i, "Value"), Key: vcard.FieldTelephone, Index: i},
+		)
+	}
+	for i := 0; i < n; i++ {
+		m = append(m,
+			&Column{Header: numbered("Address", i, "Label"), Key: vcard.FieldAddress, Index: i, Part: PartLabel},
+			&Column{Header: numbered("Address", i, "Street"), Key: vcard.FieldAddress, Index: i, Part: PartStreetAddress},
+			&Column{Header: numbered("Address", i, "City"), Key: vcard.FieldAddress, Index: i, Part: PartLocality},
+			&Column{Header: numbered("Address", i, "PO Box"), Key: vcard.FieldAddress, Index: i, Part: PartPostOfficeBox},
+			&Column{Header: numbered("Address", i, "Region"), Key: vcard.FieldAddress, Index: i, Part: PartRegion},
+			&Column{Header: numbered("Address", i, "Postal Code"), Key: vcard.FieldAddress, Index: i, Part: PartPostalCode},
+			&Column{Header: numbered("Address", i, "Country"), Key: vcard.FieldAddress, Index: i, Part: PartCountry},
+			&Column{Header: numbered("Address", i, "Extended Address"), Key: vcard.FieldAddress, Index: i, Part: PartExtendedAddress},
+		)
+	}
+	for i := 0; i < n; i++ {
+		m = append(m,
+			&Column{Header: numbered("Website", i, "Label"), Key: vcard.FieldURL, Index: i, Part: PartLabel},
+			&Column{Header: numbered("Website", i, "Value"), Key: vcard.FieldURL, Index: i},
+		)
+	}
+	return m
+}
+
+// OutlookMapping is the mapping used by Outlook CSV exports.
+var OutlookMapping = outlookMapping()
+
+func outlookMapping() Mapping {
+	m := Mapping{
+		{Header: "Title", Key: vcard.FieldName, Part: PartHonorificPrefix},
+		{Header: "First Name", Key: vcard.FieldName, Part: PartGivenName},
+		{Header: "Middle Name", Key: vcard.FieldName, Part: PartAdditionalName},
+		{Header: "Last Name", Key: vcard.FieldName, Part: PartFamilyName},
+		{Header: "Suffix", Key: vcard.FieldName, Part: PartHonorificSuffix},
+		{Header: "Nickname", Key: vcard.FieldNickname},
+		{Header: "Company", Key: vcard.FieldOrganization, Part: PartOrganizationName},
+		{Header: "Department", Key: vcard.FieldOrganization, Part: PartOrganizationUnit},
+		{Header: "Job Title", Key: vcard.FieldTitle},
+	}
+	for _, t := range []struct {
+		prefix string
+		typ    string
+	}{
+		{"Business", vcard.TypeWork},
+		{"Home", vcard.TypeHome},
+	} {
+		types := []string{t.typ}
+		m = append(m,
+			&Column{Header: t.prefix + " Street", Key: vcard.FieldAddress, Types: types, Part: PartStreetAddress},
+			&Column{Header: t.prefix + " City", Key: vcard.FieldAddress, Types: types, Part: PartLocality},
+			&Column{Header: t.prefix + " State", Key: vcard.FieldAddress, Types: types, Part: PartRegion},
+			&Column{Header: t.prefix + " Postal Code", Key: vcard.FieldAddress, Types: types, Part: PartPostalCode},
+			&Column{Header: t.prefix + " Country/Region", Key: vcard.FieldAddress, Types: types, Part: PartCountry},
+		)
+	}
+	m = append(m,
+		&Column{Header: "Business Fax", Key: vcard.FieldTelephone, Types: []string{vcard.TypeWork, vcard.TypeFax}},
+		&Column{Header: "Business Phone", Key: vcard.FieldTelephone, Types: []string{vcard.TypeWork}},
+		&Column{Header: "Home Fax", Key: vcard.FieldTelephone, Types: []string{vcard.TypeHome, vcard.TypeFax}},
+		&Column{Header: "Home Phone", Key: vcard.FieldTelephone, Types: []string{vcard.TypeHome}},
+		&Column{Header: "Mobile Phone", Key: vcard.FieldTelephone, Types: []string{vcard.TypeCell}},
+		&Column{Header: "Pager", Key: vcard.FieldTelephone, Types: []string{vcard.TypePager}},
+		&Column{Header: "Birthday", Key: vcard.FieldBirthday},
+		&Column{Header: "Categories", Key: vcard.FieldCategories, Separator: ";"},
+	)
+	for i, prefix := range []string{"E-mail", "E-mail 2", "E-mail 3"} {
+		m = append(m,
+			&Column{Header: prefix + " Address", Key: vcard.FieldEmail, Index: i},
+			&Column{Header: prefix + " Type", Key: vcard.FieldEmail, Index: i, Part: PartLabel},
+		)
+	}
+	m = append(m,
+		&Column{Header: "Notes", Key: vcard.FieldNote},
+		&Column{Header: "Web Page", Key: vcard.FieldURL},
+	)
+	return m
+}
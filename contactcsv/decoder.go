@@ -0,0 +1,218 @@
+package contactcsv
+
+import (
+	"encoding/csv"
+	"io"
+	"strings"
+
+	"github.com/emersion/go-vcard"
+)
+
+// A Decoder reads cards from a CSV file. The first row of the file must be a
+// header row.
+type Decoder struct {
+	r       *csv.Reader
+	mapping Mapping
+	columns []*Column // indexed by CSV column, nil if unmapped
+}
+
+// NewDecoder creates a new Decoder reading cards from an io.Reader. Columns
+// are matched against the mapping by header, case-insensitively. Columns
+// missing from the mapping are ignored.
+func NewDecoder(r io.Reader, mapping Mapping) *Decoder {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	cr.LazyQuotes = true
+	return &Decoder{r: cr, mapping: mapping}
+}
+
+func (dec *Decoder) readHeader() error {
+	header, err := dec.r.Read()
+	if err != nil {
+		return err
+	}
+
+	dec.columns = make([]*Column, len(header))
+	for i, h := range header {
+		if i == 0 {
+			h = strings.TrimPrefix(h, "\ufeff")
+		}
+		h = strings.TrimSpace(h)
+		for _, col := range dec.mapping {
+			if strings.EqualFold(col.Header, h) {
+				dec.columns[i] = col
+				break
+			}
+		}
+	}
+	return nil
+}
+
+// Decode parses a single card. It returns io.EOF when there are no more
+// cards.
+func (dec *Decoder) Decode() (vcard.Card, error) {
+	if dec.columns == nil {
+		if err := dec.readHeader(); err != nil {
+			return nil, err
+		}
+	}
+
+	record, err := dec.r.Read()
+	if err != nil {
+		return nil, err
+	}
+
+	var r row
+	for i, v := range record {
+		if i >= len(dec.columns) || dec.columns[i] == nil {
+			continue
+		}
+		if v = strings.TrimSpace(v); v != "" {
+			r.set(dec.columns[i], v)
+		}
+	}
+	return r.card(), nil
+}
+
+// row holds the fields of a card being decoded. Columns referring to the same
+// field share an entry.
+type row struct {
+	entries []*rowEntry
+}
+
+type rowEntry struct {
+	key     string
+	col     *Column
+	field   *vcard.Field
+	name    *vcard.Name
+	address *vcard.Address
+}
+
+func (r *row) entry(col *Column) *rowEntry {
+	key := col.selectorKey()
+	for _, e := range r.entries {
+		if e.key == key {
+			return e
+		}
+	}
+
+	field := &vcard.Field{Params: make(vcard.Params)}
+	for _, t := range col.Types {
+		field.Params.Add(vcard.ParamType, t)
+	}
+	e := &rowEntry{key: key, col: col, field: field}
+	switch col.Key {
+	case vcard.FieldName:
+		e.name = &vcard.Name{Field: field}
+	case vcard.FieldAddress:
+		e.address = &vcard.Address{Field: field}
+	}
+	r.entries = append(r.entries, e)
+	return e
+}
+
+func (r *row) set(col *Column, v string) {
+	e := r.entry(col)
+
+	if col.Part == PartLabel {
+		types, pref := parseLabel(v)
+		for _, t := range types {
+			if !e.field.Params.HasType(t) {
+				e.field.Params.Add(vcard.ParamType, t)
+			}
+		}
+		if pref {
+			e.field.Params.Set(vcard.ParamPreferred, "1")
+		}
+		return
+	}
+
+	if e.name != nil {
+		if component := nameComponent(e.name, col.Part); component != nil {
+			*component = v
+		}
+		return
+	}
+	if e.address != nil {
+		if component := addressComponent(e.address, col.Part); component != nil {
+			*component = v
+		}
+		return
+	}
+
+	switch col.Part {
+	case PartOrganizationName:
+		e.field.Value = setComponent(e.field.Value, 0, v)
+	case PartOrganizationUnit:
+		e.field.Value = setComponent(e.field.Value, 1, v)
+	default:
+		if col.Separator != "" {
+			items := strings.Split(v, col.Separator)
+			for i, item := range items {
+				items[i] = strings.TrimSpace(item)
+			}
+			v = strings.Join(items, ",")
+		}
+		e.field.Value = v
+	}
+}
+
+func setComponent(value string, i int, v string) string {
+	components := strings.Split(value, ";")
+	for len(components) <= i {
+		components = append(components, "")
+	}
+	components[i] = v
+	return strings.Join(components, ";")
+}
+
+func (r *row) card() vcard.Card {
+	card := make(vcard.Card)
+	card.SetValue(vcard.FieldVersion, "4.0")
+
+	for _, e := range r.entries {
+		if len(e.field.Params) == 0 {
+			e.field.Params = nil
+		}
+		switch {
+		case e.name != nil:
+			if !isEmpty(e.name.FamilyName, e.name.GivenName, e.name.AdditionalName, e.name.HonorificPrefix, e.name.HonorificSuffix) {
+				card.SetName(e.name)
+			}
+		case e.address != nil:
+			if !isEmpty(e.address.PostOfficeBox, e.address.ExtendedAddress, e.address.StreetAddress, e.address.Locality, e.address.Region, e.address.PostalCode, e.address.Country) {
+				card.AddAddress(e.address)
+			}
+		default:
+			if strings.Trim(e.field.Value, ";") != "" {
+				card.Add(e.col.Key, e.field)
+			}
+		}
+	}
+
+	if card.Get(vcard.FieldFormattedName) == nil {
+		card.SetValue(vcard.FieldFormattedName, formattedName(card))
+	}
+	return card
+}
+
+func isEmpty(l ...string) bool {
+	for _, s := range l {
+		if s != "" {
+			return false
+		}
+	}
+	return true
+}
+
+// formattedName builds a formatted name from the name, organization or email
+// of a card.
+func formattedName(card vcard.Card) string {
+	if name := card.Name(); name != nil {
+		return name.Format(card.DefaultLanguage())
+	}
+	if org := card.Value(vcard.FieldOrganization); org != "" {
+		return strings.SplitN(org, ";", 2)[0]
+	}
+	return card.PreferredValue(vcard.FieldEmail)
+}
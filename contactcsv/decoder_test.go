@@ -0,0 +1,97 @@
+package contactcsv
+
+import (
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/emersion/go-vcard"
+)
+
+const googleCSV = "First Name,Last Name,Labels,E-mail 1 - Label,E-mail 1 - Value,E-mail 2 - Label,E-mail 2 - Value,Phone 1 - Label,Phone 1 - Value,Address 1 - Label,Address 1 - Street,Address 1 - City,Address 1 - Country,Unknown\r\n" +
+	"Ada,Lovelace,* myContacts ::: Friends,* Home,ada@example.org,Work,ada@example.com,Mobile,+1 555 0100,Home,12 St James's Square,London,UK,x\r\n"
+
+func TestDecoder_google(t *testing.T) {
+	dec := NewDecoder(strings.NewReader(googleCSV), GoogleMapping)
+	card, err := dec.Decode()
+	if err != nil {
+		t.Fatalf("Decode() = %v", err)
+	}
+
+	want := vcard.Card{
+		vcard.FieldVersion:       {{Value: "4.0"}},
+		vcard.FieldFormattedName: {{Value: "Ada Lovelace"}},
+		vcard.FieldName:          {{Value: "Lovelace;Ada;;;"}},
+		vcard.FieldCategories:    {{Value: "* myContacts,Friends"}},
+		vcard.FieldEmail: {
+			{Value: "ada@example.org", Params: vcard.Params{vcard.ParamType: {"home"}, vcard.ParamPreferred: {"1"}}},
+			{Value: "ada@example.com", Params: vcard.Params{vcard.ParamType: {"work"}}},
+		},
+		vcard.FieldTelephone: {{Value: "+1 555 0100", Params: vcard.Params{vcard.ParamType: {"cell"}}}},
+		vcard.FieldAddress: {{
+			Value:  ";;12 St James's Square;London;;;UK",
+			Params: vcard.Params{vcard.ParamType: {"home"}},
+		}},
+	}
+	if !reflect.DeepEqual(card, want) {
+		t.Errorf("Decode() = \n%#v\nbut want:\n%#v", card, want)
+	}
+
+	if _, err := dec.Decode(); err != io.EOF {
+		t.Errorf("Decode() = %v, want io.EOF", err)
+	}
+}
+
+const outlookCSV = "\ufeffFirst Name,Last Name,Company,Department,E-mail Address,E-mail Type,E-mail 2 Address,E-mail 2 Type,Business Phone,Business Fax,Business City\r\n" +
+	"Grace,Hopper,US Navy,Research,grace@example.org,SMTP,hopper@example.mil,SMTP,+1 555 0101,+1 555 0102,Arlington\r\n"
+
+func TestDecoder_outlook(t *testing.T) {
+	card, err := NewDecoder(strings.NewReader(outlookCSV), OutlookMapping).Decode()
+	if err != nil {
+		t.Fatalf("Decode() = %v", err)
+	}
+
+	want := vcard.Card{
+		vcard.FieldVersion:       {{Value: "4.0"}},
+		vcard.FieldFormattedName: {{Value: "Grace Hopper"}},
+		vcard.FieldName:          {{Value: "Hopper;Grace;;;"}},
+		vcard.FieldOrganization:  {{Value: "US Navy;Research"}},
+		vcard.FieldEmail: {
+			{Value: "grace@example.org"},
+			{Value: "hopper@example.mil"},
+		},
+		vcard.FieldTelephone: {
+			{Value: "+1 555 0101", Params: vcard.Params{vcard.ParamType: {"work"}}},
+			{Value: "+1 555 0102", Params: vcard.Params{vcard.ParamType: {"work", "fax"}}},
+		},
+		vcard.FieldAddress: {{Value: ";;;Arlington;;;", Params: vcard.Params{vcard.ParamType: {"work"}}}},
+	}
+	if !reflect.DeepEqual(card, want) {
+		t.Errorf("Decode() = \n%#v\nbut want:\n%#v", card, want)
+	}
+}
+
+func TestDecoder_customMapping(t *testing.T) {
+	mapping := Mapping{
+		{Header: "Full name", Key: vcard.FieldFormattedName},
+		{Header: "Mail", Key: vcard.FieldEmail, Types: []string{vcard.TypeWork}},
+		{Header: "Town", Key: vcard.FieldAddress, Part: PartLocality},
+	}
+	csv := "full name,mail,town\nAlan Turing,alan@example.org,Wilmslow\n"
+
+	card, err := NewDecoder(strings.NewReader(csv), mapping).Decode()
+	if err != nil {
+		t.Fatalf("Decode() = %v", err)
+	}
+
+	want := vcard.Card{
+		vcard.FieldVersion:       {{Value: "4.0"}},
+		vcard.FieldFormattedName: {{Value: "Alan Turing"}},
+		vcard.FieldEmail:         {{Value: "alan@example.org", Params: vcard.Params{vcard.ParamType: {"work"}}}},
+		vcard.FieldAddress:       {{Value: ";;;Wilmslow;;;"}},
+	}
+	if !reflect.DeepEqual(card, want) {
+		t.Errorf("Decode() = \n%#v\nbut want:\n%#v", card, want)
+	}
+}
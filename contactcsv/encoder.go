@@ -0,0 +1,104 @@
+package contactcsv
+
+import (
+	"encoding/csv"
+	"io"
+	"strings"
+
+	"github.com/emersion/go-vcard"
+)
+
+// An Encoder writes cards to a CSV file. A header row is written before the
+// first card.
+type Encoder struct {
+	w           *csv.Writer
+	mapping     Mapping
+	wroteHeader bool
+}
+
+// NewEncoder creates a new Encoder writing cards to an io.Writer, with one
+// column per mapping entry.
+func NewEncoder(w io.Writer, mapping Mapping) *Encoder {
+	return &Encoder{w: csv.NewWriter(w), mapping: mapping}
+}
+
+// Encode formats a card as a CSV row.
+func (enc *Encoder) Encode(card vcard.Card) error {
+	if !enc.wroteHeader {
+		header := make([]string, len(enc.mapping))
+		for i, col := range enc.mapping {
+			header[i] = col.Header
+		}
+		if err := enc.w.Write(header); err != nil {
+			return err
+		}
+		enc.wroteHeader = true
+	}
+
+	record := make([]string, len(enc.mapping))
+	for i, col := range enc.mapping {
+		record[i] = col.value(card)
+	}
+	if err := enc.w.Write(record); err != nil {
+		return err
+	}
+	enc.w.Flush()
+	return enc.w.Error()
+}
+
+// field returns the card field selected by the column, or nil.
+func (col *Column) field(card vcard.Card) *vcard.Field {
+	var n int
+	for _, f := range card[col.Key] {
+		if !col.matchTypes(f) {
+			continue
+		}
+		if n == col.Index {
+			return f
+		}
+		n++
+	}
+	return nil
+}
+
+// value returns the value of the column for a card.
+func (col *Column) value(card vcard.Card) string {
+	f := col.field(card)
+	if f == nil {
+		return ""
+	}
+
+	if col.Part == PartLabel {
+		return formatLabel(f)
+	}
+
+	switch col.Key {
+	case vcard.FieldName:
+		name := vcard.Card{vcard.FieldName: {f}}.Name()
+		if component := nameComponent(name, col.Part); component != nil {
+			return *component
+		}
+		return ""
+	case vcard.FieldAddress:
+		address := vcard.Card{vcard.FieldAddress: {f}}.Address()
+		if component := addressComponent(address, col.Part); component != nil {
+			return *component
+		}
+		return ""
+	}
+
+	switch col.Part {
+	case PartOrganizationName:
+		return strings.Split(f.Value, ";")[0]
+	case PartOrganizationUnit:
+		components := strings.Split(f.Value, ";")
+		if len(components) < 2 {
+			return ""
+		}
+		return strings.Join(components[1:], ", ")
+	}
+	if col.Separator != "" {
+		return strings.Replace(f.Value, ",", col.Separator, -1)
+	}
+	return f.Value
+}
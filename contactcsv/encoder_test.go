@@ -0,0 +1,66 @@
+package contactcsv
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/emersion/go-vcard"
+)
+
+func TestEncoder(t *testing.T) {
+	mapping := Mapping{
+		{Header: "First Name", Key: vcard.FieldName, Part: PartGivenName},
+		{Header: "Last Name", Key: vcard.FieldName, Part: PartFamilyName},
+		{Header: "Home Phone", Key: vcard.FieldTelephone, Types: []string{vcard.TypeHome}},
+		{Header: "Mobile Phone", Key: vcard.FieldTelephone, Types: []string{vcard.TypeCell}},
+		{Header: "E-mail 1 - Label", Key: vcard.FieldEmail, Part: PartLabel},
+		{Header: "E-mail 1 - Value", Key: vcard.FieldEmail},
+		{Header: "City", Key: vcard.FieldAddress, Part: PartLocality},
+		{Header: "Labels", Key: vcard.FieldCategories, Separator: " ::: "},
+	}
+
+	card := vcard.Card{
+		vcard.FieldFormattedName: {{Value: "Ada Lovelace"}},
+		vcard.FieldName:          {{Value: "Lovelace;Ada;;;"}},
+		vcard.FieldTelephone: {
+			{Value: "+1 555 0100", Params: vcard.Params{vcard.ParamType: {"cell", "voice"}}},
+			{Value: "+1 555 0103", Params: vcard.Params{vcard.ParamType: {"home"}}},
+		},
+		vcard.FieldEmail:      {{Value: "ada@example.org", Params: vcard.Params{vcard.ParamType: {"home", "pref"}}}},
+		vcard.FieldAddress:    {{Value: ";;;London;;;"}},
+		vcard.FieldCategories: {{Value: "Friends,Family"}},
+	}
+
+	var b bytes.Buffer
+	if err := NewEncoder(&b, mapping).Encode(card); err != nil {
+		t.Fatalf("Encode() = %v", err)
+	}
+
+	want := "First Name,Last Name,Home Phone,Mobile Phone,E-mail 1 - Label,E-mail 1 - Value,City,Labels\n" +
+		"Ada,Lovelace,+1 555 0103,+1 555 0100,* Home,ada@example.org,London,Friends ::: Family\n"
+	if b.String() != want {
+		t.Errorf("Encode() = \n%v\nbut want:\n%v", b.String(), want)
+	}
+}
+
+func TestEncoder_roundTrip(t *testing.T) {
+	card, err := NewDecoder(strings.NewReader(googleCSV), GoogleMapping).Decode()
+	if err != nil {
+		t.Fatalf("Decode() = %v", err)
+	}
+
+	var b bytes.Buffer
+	if err := NewEncoder(&b, GoogleMapping).Encode(card); err != nil {
+		t.Fatalf("Encode() = %v", err)
+	}
+
+	got, err := NewDecoder(&b, GoogleMapping).Decode()
+	if err != nil {
+		t.Fatalf("Decode() = %v", err)
+	}
+	if !reflect.DeepEqual(got, card) {
+		t.Errorf("round trip = \n%#v\nbut want:\n%#v", got, card)
+	}
+}
@@ -3,14 +3,62 @@ package vcard
 import (
 	"bufio"
 	"errors"
+	"fmt"
 	"io"
 	"strconv"
 	"strings"
+	"unicode/utf8"
 )
 
+var (
+	// ErrLineTooLong is returned when a line exceeds Decoder.MaxLineLength.
+	ErrLineTooLong = errors.New("vcard: line too long")
+	// ErrCardTooLarge is returned when a card exceeds Decoder.MaxCardSize.
+	ErrCardTooLarge = errors.New("vcard: card too large")
+)
+
+// A DecodeError is returned by Decoder.Next when a card is malformed.
+type DecodeError struct {
+	// Line is the line number where the card starts, starting from 1.
+	Line int
+	Err  error
+}
+
+func (err *DecodeError) Error() string {
+	return fmt.Sprintf("%v (card at line %v)", err.Err, err.Line)
+}
+
+func (err *DecodeError) Unwrap() error {
+	return err.Err
+}
+
 // A Decoder parses cards.
 type Decoder struct {
+	// MaxLineLength is the maximum length of an unfolded line, in bytes. Zero
+	// means no limit.
+	MaxLineLength int
+	// MaxCardSize is the maximum size of a card, in bytes. Zero means no
+	// limit. Memory usage is only bounded if MaxLineLength is set too.
+	MaxCardSize int
+
 	r *bufio.Reader
+
+	// number of lines and bytes read so far
+	line   int
+	offset int64
+	// line pushed back by Next, with its line number and offset
+	unread       *string
+	unreadLine   int
+	unreadOffset int64
+	// I/O error, which stops Next
+	err error
+
+	// line buffer reused across calls to readLine
+	buf []byte
+
+	// if capture is set, raw lines are appended to raw
+	capture bool
+	raw     []byte
 }
 
 // NewDecoder creates a new Decoder reading cards from an io.Reader.
@@ -18,52 +66,162 @@ func NewDecoder(r io.Reader) *Decoder {
 	return &Decoder{r: bufio.NewReader(r)}
 }
 
+// readPhysicalLine reads a line and appends it to buf, including the line
+// ending. Bytes past limit are discarded, in which case truncated is true.
+func (dec *Decoder) readPhysicalLine(buf []byte, limit int) (b []byte, truncated bool, err error) {
+	n := 0
+	for {
+		chunk, err := dec.r.ReadSlice('\n')
+		n += len(chunk)
+		dec.offset += int64(len(chunk))
+		if !truncated && (limit <= 0 || len(buf)+len(chunk) <= limit) {
+			buf = append(buf, chunk...)
+			if dec.capture {
+				dec.raw = append(dec.raw, chunk...)
+			}
+		} else {
+			truncated = true
+		}
+
+		if err == bufio.ErrBufferFull {
+			continue
+		} else if err == io.EOF && n > 0 {
+			err = nil
+		}
+		if err == nil {
+			dec.line++
+		}
+		return buf, truncated, err
+	}
+}
+
 func (dec *Decoder) readLine() (string, error) {
-	l, err := dec.r.ReadString('\n')
-	l = strings.TrimRight(l, "\r\n")
-	if len(l) > 0 && err == io.EOF {
+	if dec.unread != nil {
+		l := *dec.unread
+		dec.unread = nil
 		return l, nil
-	} else if err != nil {
-		return l, err
 	}
 
+	b, err := dec.readLineBytes()
+	return string(b), err
+}
+
+// readLineBytes reads an unfolded line. The returned slice is only valid until
+// the next call. Lines pushed back by Next are ignored.
+func (dec *Decoder) readLineBytes() ([]byte, error) {
+	limit := 0
+	if dec.MaxLineLength > 0 {
+		// Leave room for the line ending
+		limit = dec.MaxLineLength + 2
+	}
+
+	buf, tooLong, err := dec.readPhysicalLine(dec.buf[:0], limit)
+	if err != nil {
+		return nil, err
+	}
+	buf = trimLineEnding(buf)
+
 	for {
 		next, err := dec.r.Peek(1)
 		if err == io.EOF {
 			break
 		} else if err != nil {
-			return l, err
+			return nil, err
 		}
 
 		if ch := next[0]; ch != ' ' && ch != '\t' {
 			break
 		}
 
-		if _, err := dec.r.Discard(1); err != nil {
-			return l, err
+		ch, err := dec.r.ReadByte()
+		if err != nil {
+			return nil, err
+		}
+		dec.offset++
+		if dec.capture {
+			dec.raw = append(dec.raw, ch)
 		}
 
-		folded, err := dec.r.ReadString('\n')
+		var truncated bool
+		buf, truncated, err = dec.readPhysicalLine(buf, limit)
 		if err != nil {
-			return l, err
+			return nil, err
 		}
-		l += strings.TrimRight(folded, "\r\n")
+		buf = trimLineEnding(buf)
+		tooLong = tooLong || truncated
 	}
 
-	return l, nil
+	dec.buf = buf
+	if tooLong || (dec.MaxLineLength > 0 && len(buf) > dec.MaxLineLength) {
+		return nil, ErrLineTooLong
+	}
+	return buf, nil
+}
+
+func trimLineEnding(b []byte) []byte {
+	for len(b) > 0 && (b[len(b)-1] == '\n' || b[len(b)-1] == '\r') {
+		b = b[:len(b)-1]
+	}
+	return b
 }
 
 // Decode parses a single card.
 func (dec *Decoder) Decode() (Card, error) {
-	card := make(Card)
+	card, _, err := dec.decode(false)
+	return card, err
+}
+
+// Next parses the next card. It returns io.EOF when there are no more cards.
+//
+// Unlike Decode, Next recovers from malformed cards: it returns a
+// *DecodeError and the next call resumes at the following BEGIN:VCARD line.
+// Data outside of cards is ignored. Other errors are returned by all
+// subsequent calls.
+func (dec *Decoder) Next() (Card, error) {
+	if dec.err != nil {
+		return nil, dec.err
+	}
+
+	card, line, err := dec.decode(true)
+	if err == nil {
+		return card, nil
+	} else if err == io.EOF {
+		dec.err = err
+	}
+	if dec.err != nil {
+		return nil, dec.err
+	}
+	return nil, &DecodeError{Line: line, Err: err}
+}
+
+// decode parses a card and returns the line number where it starts. In
+// lenient mode, lines preceding BEGIN:VCARD are skipped and a BEGIN:VCARD line
+// before END:VCARD starts a new card.
+func (dec *Decoder) decode(lenient bool) (card Card, line int, err error) {
+	card = make(Card)
 
 	var hasBegin, hasEnd bool
+	var start int64
 	for {
+		lineStart, offset := dec.line+1, dec.offset
+		if dec.unread != nil {
+			lineStart, offset = dec.unreadLine, dec.unreadOffset
+		}
+
 		l, err := dec.readLine()
 		if err == io.EOF {
 			break
+		} else if err == ErrLineTooLong && lenient && !hasBegin {
+			continue
 		} else if err != nil {
-			return card, err
+			if err != ErrLineTooLong {
+				dec.err = err
+			}
+			return card, line, err
+		}
+
+		if hasBegin && dec.MaxCardSize > 0 && dec.offset-start > int64(dec.MaxCardSize) {
+			return card, line, ErrCardTooLarge
 		}
 
 		k, f, err := parseLine(l)
@@ -74,19 +232,29 @@ func (dec *Decoder) Decode() (Card, error) {
 		if !hasBegin {
 			if k == "BEGIN" {
 				if strings.ToUpper(f.Value) != "VCARD" {
-					return card, errors.New("vcard: invalid BEGIN value")
+					if lenient {
+						continue
+					}
+					return card, lineStart, errors.New("vcard: invalid BEGIN value")
 				}
 				hasBegin = true
+				line, start = lineStart, offset
+				continue
+			} else if lenient {
 				continue
 			} else {
-				return card, errors.New("vcard: no BEGIN field found")
+				return card, lineStart, errors.New("vcard: no BEGIN field found")
 			}
 		} else if k == "END" {
 			if strings.ToUpper(f.Value) != "VCARD" {
-				return card, errors.New("vcard: invalid END value")
+				return card, line, errors.New("vcard: invalid END value")
 			}
 			hasEnd = true
 			break
+		} else if lenient && k == "BEGIN" && strings.ToUpper(f.Value) == "VCARD" {
+			dec.unread = &l
+			dec.unreadLine, dec.unreadOffset = lineStart, offset
+			break
 		}
 
 		card[k] = append(card[k], f)
@@ -94,11 +262,11 @@ func (dec *Decoder) Decode() (Card, error) {
 
 	if !hasEnd {
 		if !hasBegin {
-			return nil, io.EOF
+			return nil, line, io.EOF
 		}
-		return card, errors.New("vcard: no END field found")
+		return card, line, errors.New("vcard: no END field found")
 	}
-	return card, nil
+	return card, line, nil
 }
 
 func parseLine(l string) (key string, field *Field, err error) {
@@ -160,7 +328,11 @@ func parseParams(s string) (params Params, tail string, err error) {
 			return
 		}
 
-		params[k] = append(params[k], values...)
+		if prev, ok := params[k]; ok {
+			params[k] = append(prev, values...)
+		} else {
+			params[k] = values
+		}
 
 		if !more {
 			break
@@ -193,16 +365,44 @@ func parseParamValues(s string) (values []string, more bool, tail string, err er
 		}
 	}
 
-	values = strings.Split(vs, ",")
+	values = splitParamValues(vs)
 	for i, value := range values {
 		values[i] = parseValue(value)
 	}
 	return
 }
 
+// splitParamValues splits a list of parameter values on commas which aren't
+// escaped with a backslash.
+func splitParamValues(s string) []string {
+	if strings.IndexByte(s, '\\') < 0 {
+		return strings.Split(s, ",")
+	}
+
+	var values []string
+	start := 0
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case ',':
+			values = append(values, s[start:i])
+			start = i + 1
+		}
+	}
+	return append(values, s[start:])
+}
+
 func parseQuoted(s string, quote byte) (value, tail string, err error) {
+	// Fast path: no escape sequence
+	if i := strings.IndexByte(s, quote); i >= 0 {
+		if v := s[:i]; strings.IndexByte(v, '\\') < 0 && utf8.ValidString(v) {
+			return v, s[i+1:], nil
+		}
+	}
+
 	tail = s
-	var buf []rune
+	var sb strings.Builder
 	for tail != "" {
 		if tail[0] == quote {
 			tail = tail[1:]
@@ -214,14 +414,37 @@ func parseQuoted(s string, quote byte) (value, tail string, err error) {
 		if err != nil {
 			return
 		}
-		buf = append(buf, r)
+		sb.WriteRune(r)
 	}
-	value = string(buf)
+	value = sb.String()
 	return
 }
 
-var valueParser = strings.NewReplacer("\\\\", "\\", "\\n", "\n", "\\,", ",")
-
+// parseValue unescapes a value.
 func parseValue(s string) string {
-	return valueParser.Replace(s)
+	i := strings.IndexByte(s, '\\')
+	if i < 0 {
+		return s
+	}
+
+	var sb strings.Builder
+	sb.Grow(len(s))
+	sb.WriteString(s[:i])
+	for ; i < len(s); i++ {
+		c := s[i]
+		if c == '\\' && i+1 < len(s) {
+			switch s[i+1] {
+			case '\\':
+				i++
+			case 'n':
+				c = '\n'
+				i++
+			case ',':
+				c = ','
+				i++
+			}
+		}
+		sb.WriteByte(c)
+	}
+	return sb.String()
 }
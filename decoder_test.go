@@ -1,6 +1,8 @@
 package vcard
 
 import (
+	"errors"
+	"io"
 	"reflect"
 	"strings"
 	"testing"
@@ -140,3 +142,163 @@ func TestParseLine_escaped(t *testing.T) {
 		t.Errorf("parseLine(%q): expected (%q, %q), got (%q, %q)", l, expectedKey, expectedValue, key, field.Value)
 	}
 }
+
+func TestParseLine_escapedParam(t *testing.T) {
+	l := "ADR;LABEL=1 Main Street\\nSpringfield\\, IL;TYPE=home,work:;;1 Main Street;Springfield;IL;;"
+	expected := Params{
+		ParamLabel: {"1 Main Street\nSpringfield, IL"},
+		ParamType:  {"home", "work"},
+	}
+
+	if _, field, err := parseLine(l); err != nil {
+		t.Fatal("Expected no error while parsing line, got:", err)
+	} else if !reflect.DeepEqual(field.Params, expected) {
+		t.Errorf("parseLine(%q): expected params %v, got %v", l, expected, field.Params)
+	}
+}
+
+const testNextString = "BEGIN:VCARD\r\n" +
+	"VERSION:4.0\r\n" +
+	"FN:Alice\r\n" +
+	"END:VCARD\r\n" +
+	"garbage between cards\r\n" +
+	"BEGIN:VCARD\r\n" +
+	"VERSION:4.0\r\n" +
+	"FN:Broken, missing END\r\n" +
+	"BEGIN:VCARD\r\n" +
+	"VERSION:4.0\r\n" +
+	"FN:Bob\r\n" +
+	"NOTE:This line is way too long\r\n" +
+	"END:VCARD\r\n" +
+	"BEGIN:VCARD\r\n" +
+	"VERSION:4.0\r\n" +
+	"FN:Carol\r\n" +
+	"NOTE:one\r\n" +
+	"NOTE:two\r\n" +
+	"NOTE:three\r\n" +
+	"END:VCARD\r\n" +
+	"BEGIN:VCARD\r\n" +
+	"VERSION:4.0\r\n" +
+	"FN:Dave\r\n" +
+	"END:VCARD\r\n"
+
+func TestDecoder_Next(t *testing.T) {
+	dec := NewDecoder(strings.NewReader(testNextString))
+	dec.MaxLineLength = 25
+	dec.MaxCardSize = 70
+
+	type result struct {
+		fn   string
+		line int
+		err  error
+	}
+	var results []result
+	for {
+		card, err := dec.Next()
+		if err == io.EOF {
+			break
+		} else if e, ok := err.(*DecodeError); ok {
+			var cause error
+			if e.Err == ErrLineTooLong || e.Err == ErrCardTooLarge {
+				cause = e.Err
+			}
+			results = append(results, result{line: e.Line, err: cause})
+			continue
+		} else if err != nil {
+			t.Fatalf("Next() = %v", err)
+		}
+		results = append(results, result{fn: card.Value(FieldFormattedName)})
+	}
+
+	want := []result{
+		{fn: "Alice"},
+		{line: 6},
+		{line: 9, err: ErrLineTooLong},
+		{line: 14, err: ErrCardTooLarge},
+		{fn: "Dave"},
+	}
+	if !reflect.DeepEqual(results, want) {
+		t.Errorf("Next() = %+v, want %+v", results, want)
+	}
+}
+
+type errReader struct{}
+
+func (errReader) Read(b []byte) (int, error) {
+	return 0, errors.New("I/O error")
+}
+
+func TestDecoder_NextReadError(t *testing.T) {
+	dec := NewDecoder(io.MultiReader(strings.NewReader("BEGIN:VCARD\r\n"), errReader{}))
+	for i := 0; i < 2; i++ {
+		if _, err := dec.Next(); err == nil || err.Error() != "I/O error" {
+			t.Fatalf("Next() = %v, want I/O error", err)
+		}
+	}
+}
+
+func TestDecoder_limits(t *testing.T) {
+	dec := NewDecoder(strings.NewReader(testCardHandmadeString))
+	dec.MaxLineLength = 10
+	if _, err := dec.Decode(); err != ErrLineTooLong {
+		t.Errorf("Decode() = %v, want ErrLineTooLong", err)
+	}
+
+	dec = NewDecoder(strings.NewReader(testCardHandmadeString))
+	dec.MaxCardSize = 50
+	if _, err := dec.Decode(); err != ErrCardTooLarge {
+		t.Errorf("Decode() = %v, want ErrCardTooLarge", err)
+	}
+}
+
+// testCardBenchmarkString is a realistic card exported by a phone, with
+// parameters, escaped values and a folded photo.
+var testCardBenchmarkString = "BEGIN:VCARD\r\n" +
+	"VERSION:3.0\r\n" +
+	"PRODID:-//Apple Inc.//iPhone OS 17.0//EN\r\n" +
+	"N:Liddell;Alice;Pleasance;;\r\n" +
+	"FN:Alice Pleasance Liddell\r\n" +
+	"ORG:Wonderland Inc.;Rabbit Hole Division\r\n" +
+	"TITLE:Explorer\r\n" +
+	"EMAIL;TYPE=INTERNET;TYPE=HOME;TYPE=pref:alice@example.org\r\n" +
+	"EMAIL;TYPE=INTERNET;TYPE=WORK:alice@wonderland.example.com\r\n" +
+	"TEL;TYPE=CELL;TYPE=VOICE;TYPE=pref:+44 20 7946 0000\r\n" +
+	"TEL;TYPE=HOME;TYPE=VOICE:+44 20 7946 0001\r\n" +
+	"item1.ADR;TYPE=HOME;TYPE=pref:;;1 Rabbit Hole;Oxford;;OX1 1AA;United Kingdom\r\n" +
+	"item1.X-ABADR:gb\r\n" +
+	"item2.URL;TYPE=pref:https://example.org/alice\r\n" +
+	"item2.X-ABLABEL:_$!<HomePage>!$_\r\n" +
+	"BDAY;VALUE=date:1852-05-04\r\n" +
+	"NOTE:Curiouser and curiouser!\\nSaid Alice\\, who was much surprised.\r\n" +
+	"PHOTO;ENCODING=b;TYPE=JPEG:/9j/4AAQSkZJRgABAQAAAQABAAD/2wBDAAMCAgICAgMCAgIDAwMDBAYEBAQEBAgGBgUGCQgKCgkICQkKDA8MCgsOCwkJDRENDg8QEBEQCgwSExIQEw8QEBD/\r\n" +
+	" 2wBDAQMDAwQDBAgEBAgQCwkLEBAQEBAQEBAQEBAQEBAQEBAQEBAQEBAQEBAQEBAQEBAQEBAQEBAQEBAQEBAQEBAQEBD/wAARCAABAAEDASIAAhEBAxEB/8QAFQABAQAAAAAAAAAAAAAAAAAAAAn/\r\n" +
+	" xAAUEAEAAAAAAAAAAAAAAAAAAAAA/8QAFAEBAAAAAAAAAAAAAAAAAAAAAP/EABQRAQAAAAAAAAAAAAAAAAAAAAD/2gAMAwEAAhEDEQA/AKpgB//Z\r\n" +
+	"CATEGORIES:Friends,Wonderland\r\n" +
+	"REV:2023-09-18T10:00:00Z\r\n" +
+	"END:VCARD\r\n"
+
+func BenchmarkDecoder(b *testing.B) {
+	input := strings.Repeat(testCardBenchmarkString, 100)
+	b.SetBytes(int64(len(input)))
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		dec := NewDecoder(strings.NewReader(input))
+		for {
+			if _, err := dec.Decode(); err == io.EOF {
+				break
+			} else if err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+}
+
+func BenchmarkParseLine(b *testing.B) {
+	l := `EMAIL;TYPE=INTERNET;TYPE="HOME,pref":alice@example.org`
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, _, err := parseLine(l); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -3,86 +3,202 @@ package vcard
 import (
 	"errors"
 	"io"
-	"sort"
 	"strings"
+	"time"
 )
 
 // An Encoder formats cards.
+//
+// The options below add properties to the encoded cards. They are disabled by
+// default. The cards passed to Encode are never modified.
 type Encoder struct {
+	// GenerateUID adds a random UID, generated with NewUIDv4, to cards
+	// without one.
+	GenerateUID bool
+	// StampRevision sets the REV property to the current time.
+	StampRevision bool
+	// Now returns the current time used by StampRevision. If nil, time.Now
+	// is used.
+	Now func() time.Time
+	// ProductID, if not empty, is the value of the PRODID property, e.g.
+	// "-//Example Corp.//Example Client 1.0//EN".
+	ProductID string
+
 	w io.Writer
+
+	// buffers reused across calls to Encode
+	buf       []byte
+	keys      []string
+	paramKeys []string
 }
 
 // NewEncoder creates a new Encoder that writes cards to w.
 func NewEncoder(w io.Writer) *Encoder {
-	return &Encoder{w}
+	return &Encoder{w: w}
 }
 
 // Encode formats a card. The card must have a FieldVersion field.
 func (enc *Encoder) Encode(c Card) error {
-	begin := "BEGIN:VCARD\r\n"
-	if _, err := io.WriteString(enc.w, begin); err != nil {
-		return err
-	}
-
 	version := c.Get(FieldVersion)
 	if version == nil {
 		return errors.New("vcard: VERSION field missing")
 	}
-	if _, err := io.WriteString(enc.w, formatLine(FieldVersion, version)+"\r\n"); err != nil {
+
+	c, err := enc.stamp(c)
+	if err != nil {
 		return err
 	}
 
-	var keys []string
+	b := append(enc.buf[:0], "BEGIN:VCARD\r\n"...)
+	b = enc.appendLine(b, FieldVersion, version)
+
+	keys := enc.keys[:0]
 	for k := range c {
-		keys = append(keys, k)
+		if !strings.EqualFold(k, FieldVersion) {
+			keys = append(keys, k)
+		}
 	}
-	sort.Strings(keys)
+	sortStrings(keys)
 	for _, k := range keys {
-		fields := c[k]
-		if strings.EqualFold(k, FieldVersion) {
-			continue
-		}
-		for _, f := range fields {
-			if _, err := io.WriteString(enc.w, formatLine(k, f)+"\r\n"); err != nil {
-				return err
-			}
+		for _, f := range c[k] {
+			b = enc.appendLine(b, k, f)
 		}
 	}
+	b = append(b, "END:VCARD\r\n"...)
 
-	end := "END:VCARD\r\n"
-	_, err := io.WriteString(enc.w, end)
+	enc.buf, enc.keys = b, keys
+	_, err = enc.w.Write(b)
 	return err
 }
 
-func formatLine(key string, field *Field) string {
-	var s string
+// stamp returns a shallow copy of the card with the properties added by the
+// encoder options, or the card itself if there are none.
+func (enc *Encoder) stamp(c Card) (Card, error) {
+	set := make(map[string]string)
+	if enc.GenerateUID && c.Value(FieldUID) == "" {
+		uid, err := NewUIDv4()
+		if err != nil {
+			return nil, err
+		}
+		set[FieldUID] = uid
+	}
+	if enc.StampRevision {
+		now := time.Now
+		if enc.Now != nil {
+			now = enc.Now
+		}
+		set[FieldRevision] = now().UTC().Format(timestampLayout)
+	}
+	if enc.ProductID != "" {
+		set[FieldProductID] = enc.ProductID
+	}
+	if len(set) == 0 {
+		return c, nil
+	}
 
-	if field.Group != "" {
-		s += field.Group + "."
+	cp := make(Card, len(c)+len(set))
+	for k, fields := range c {
+		cp[k] = fields
 	}
-	s += key
+	for k, v := range set {
+		cp[k] = []*Field{{Value: v}}
+	}
+	return cp, nil
+}
 
-	var keys []string
+// appendLine appends a content line, including the line ending.
+func (enc *Encoder) appendLine(b []byte, key string, field *Field) []byte {
+	paramKeys := enc.paramKeys[:0]
 	for k := range field.Params {
-		keys = append(keys, k)
+		paramKeys = append(paramKeys, k)
 	}
-	sort.Strings(keys)
-	for _, pk := range keys {
+	sortStrings(paramKeys)
+	enc.paramKeys = paramKeys
+
+	b = appendLine(b, key, field, paramKeys)
+	return append(b, "\r\n"...)
+}
+
+func formatLine(key string, field *Field) string {
+	var paramKeys []string
+	for k := range field.Params {
+		paramKeys = append(paramKeys, k)
+	}
+	sortStrings(paramKeys)
+	return string(appendLine(nil, key, field, paramKeys))
+}
+
+// appendLine appends a content line without the line ending. paramKeys are
+// the sorted keys of the field parameters.
+func appendLine(b []byte, key string, field *Field, paramKeys []string) []byte {
+	if field.Group != "" {
+		b = append(b, field.Group...)
+		b = append(b, '.')
+	}
+	b = append(b, key...)
+
+	for _, pk := range paramKeys {
 		for _, pv := range field.Params[pk] {
-			s += ";" + formatParam(pk, pv)
+			b = append(b, ';')
+			b = append(b, pk...)
+			b = append(b, '=')
+			b = appendEscaped(b, pv, true)
 		}
 	}
 
-	s += ":" + formatValue(field.Value)
-	return s
+	b = append(b, ':')
+	return appendFieldValue(b, key, field)
 }
 
-func formatParam(k, v string) string {
-	return k + "=" + formatValue(v)
+// sortStrings sorts a short list of strings. Unlike sort.Strings, it doesn't
+// allocate.
+func sortStrings(l []string) {
+	for i := 1; i < len(l); i++ {
+		for j := i; j > 0 && l[j] < l[j-1]; j-- {
+			l[j], l[j-1] = l[j-1], l[j]
+		}
+	}
 }
 
-var valueFormatter = strings.NewReplacer("\\", "\\\\", "\n", "\\n", ",", "\\,")
-
 func formatValue(v string) string {
-	return valueFormatter.Replace(v)
+	return string(appendEscaped(nil, v, true))
+}
+
+// appendEscaped appends an escaped value. Commas are only escaped if comma is
+// true.
+func appendEscaped(b []byte, v string, comma bool) []byte {
+	for i := 0; i < len(v); i++ {
+		switch c := v[i]; c {
+		case '\\':
+			b = append(b, '\\', '\\')
+		case '\n':
+			b = append(b, '\\', 'n')
+		case ',':
+			if comma {
+				b = append(b, '\\')
+			}
+			b = append(b, ',')
+		default:
+			b = append(b, c)
+		}
+	}
+	return b
+}
+
+// formatFieldValue escapes the value of the field f for the property k.
+func formatFieldValue(k string, f *Field) string {
+	return string(appendFieldValue(nil, k, f))
+}
+
+// appendFieldValue appends the escaped value of the field f for the property
+// k. Commas are only escaped in single text values: they are list separators
+// in list values, and are left as-is in other value types such as URIs.
+func appendFieldValue(b []byte, k string, f *Field) []byte {
+	if valueType(k, f) != ValueText {
+		return appendEscaped(b, f.Value, false)
+	}
+	if info := LookupProperty(k); info != nil && info.List {
+		return appendEscaped(b, f.Value, false)
+	}
+	return appendEscaped(b, f.Value, true)
 }
@@ -2,8 +2,11 @@ package vcard
 
 import (
 	"bytes"
+	"io/ioutil"
 	"reflect"
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestEncoder(t *testing.T) {
@@ -27,6 +30,78 @@ func TestEncoder(t *testing.T) {
 	}
 }
 
+func TestEncoder_generateUID(t *testing.T) {
+	card := Card{
+		FieldVersion:       {{Value: "4.0"}},
+		FieldFormattedName: {{Value: "Alice"}},
+	}
+
+	var b bytes.Buffer
+	enc := NewEncoder(&b)
+	enc.GenerateUID = true
+	if err := enc.Encode(card); err != nil {
+		t.Fatalf("Encode() = %v", err)
+	}
+	if _, ok := card[FieldUID]; ok {
+		t.Errorf("Encode() modified the card")
+	}
+
+	decoded, err := NewDecoder(&b).Decode()
+	if err != nil {
+		t.Fatalf("Decode() = %v", err)
+	}
+	uid := decoded.Value(FieldUID)
+	if NormalizeUID(uid) != uid || !strings.HasPrefix(uid, "urn:uuid:") {
+		t.Errorf("Encode() generated UID %q, want a UUID URN", uid)
+	}
+
+	b.Reset()
+	if err := enc.Encode(testCard); err != nil {
+		t.Fatalf("Encode() = %v", err)
+	}
+	if !strings.Contains(b.String(), "UID:urn:uuid:4fbe8971-0bc3-424c-9c26-36c3e1eff6b1\r\n") || strings.Count(b.String(), "UID:") != 1 {
+		t.Errorf("Encode() replaced the existing UID: %q", b.String())
+	}
+}
+
+func TestEncoder_stamp(t *testing.T) {
+	card := Card{
+		FieldVersion:   {{Value: "4.0"}},
+		FieldUID:       {{Value: "urn:uuid:4fbe8971-0bc3-424c-9c26-36c3e1eff6b1"}},
+		FieldRevision:  {{Value: "20000101T000000Z"}},
+		FieldProductID: {{Value: "-//Old//EN"}},
+	}
+
+	var b bytes.Buffer
+	enc := NewEncoder(&b)
+	enc.GenerateUID = true
+	enc.StampRevision = true
+	enc.Now = func() time.Time {
+		return time.Date(2023, 9, 18, 12, 0, 0, 0, time.FixedZone("CEST", 2*60*60))
+	}
+	enc.ProductID = "-//Example//EN"
+	if err := enc.Encode(card); err != nil {
+		t.Fatalf("Encode() = %v", err)
+	}
+
+	expected := "BEGIN:VCARD\r\nVERSION:4.0\r\nPRODID:-//Example//EN\r\nREV:20230918T100000Z\r\nUID:urn:uuid:4fbe8971-0bc3-424c-9c26-36c3e1eff6b1\r\nEND:VCARD\r\n"
+	if b.String() != expected {
+		t.Errorf("Encode() = %q, want %q", b.String(), expected)
+	}
+	if card.Value(FieldRevision) != "20000101T000000Z" || card.Value(FieldProductID) != "-//Old//EN" {
+		t.Errorf("Encode() modified the card")
+	}
+
+	// Options are disabled by default
+	b.Reset()
+	if err := NewEncoder(&b).Encode(card); err != nil {
+		t.Fatalf("Encode() = %v", err)
+	}
+	if !strings.Contains(b.String(), "REV:20000101T000000Z\r\n") || !strings.Contains(b.String(), "PRODID:-//Old//EN\r\n") {
+		t.Errorf("Encode() without options changed the card: %q", b.String())
+	}
+}
+
 func TestFormatLine_withGroup(t *testing.T) {
 	l := formatLine("FN", &Field{
 		Value: "Akiyama Mio",
@@ -133,3 +208,18 @@ func TestEncoderDeterminism(t *testing.T) {
 		}
 	}
 }
+
+func BenchmarkEncoder(b *testing.B) {
+	card, err := NewDecoder(strings.NewReader(testCardBenchmarkString)).Decode()
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	enc := NewEncoder(ioutil.Discard)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if err := enc.Encode(card); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -1,3 +1,5 @@
 module github.com/emersion/go-vcard
 
 go 1.13
+
+require golang.org/x/net v0.35.0
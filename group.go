@@ -0,0 +1,234 @@
+package vcard
+
+import (
+	"reflect"
+	"strings"
+)
+
+// A GroupCycleError is returned when a group is a member of itself, directly
+// or through nested groups.
+type GroupCycleError struct {
+	// UIDs of the groups forming the cycle. The first and last UIDs are the
+	// same.
+	UIDs []string
+}
+
+func (err *GroupCycleError) Error() string {
+	return "vcard: cycle in group membership: " + strings.Join(err.UIDs, " -> ")
+}
+
+// Members returns the URIs of the members of a group.
+func (c Card) Members() []string {
+	return c.Values(FieldMember)
+}
+
+// AddMember adds the card with the specified UID to the members of a group.
+// UUIDs which aren't URIs are converted to "urn:uuid:" URIs, other UIDs are
+// used as-is. It returns false if the card is already a member.
+func (c Card) AddMember(uid string) bool {
+	uri := memberURI(uid)
+	key := memberKey(uri)
+	for _, v := range c.Values(FieldMember) {
+		if memberKey(v) == key {
+			return false
+		}
+	}
+	c.AddValue(FieldMember, uri)
+	return true
+}
+
+// RemoveMember removes the card with the specified UID from the members of a
+// group. It returns false if the card isn't a member.
+func (c Card) RemoveMember(uid string) bool {
+	key := memberKey(memberURI(uid))
+	fields := c[FieldMember]
+	l := fields[:0]
+	for _, f := range fields {
+		if memberKey(f.Value) != key {
+			l = append(l, f)
+		}
+	}
+	if len(l) == len(fields) {
+		return false
+	}
+	for i := len(l); i < len(fields); i++ {
+		fields[i] = nil
+	}
+	if len(l) == 0 {
+		delete(c, FieldMember)
+	} else {
+		c[FieldMember] = l
+	}
+	return true
+}
+
+func memberURI(uid string) string {
+	if !strings.Contains(uid, ":") && isUUID(uid) {
+		return uuidURNPrefix + uid
+	}
+	return uid
+}
+
+// memberKey returns a key identifying a member URI. "urn:uuid:" URIs, UUIDs
+// and email addresses are case-insensitive.
+func memberKey(uri string) string {
+	uri = strings.TrimSpace(uri)
+	if len(uri) > 7 && strings.EqualFold(uri[:7], "mailto:") {
+		return "mailto:" + normalizeEmail(uri)
+	}
+	if len(uri) > len(uuidURNPrefix) && hasPrefixFold(uri, uuidURNPrefix) {
+		return uuidURNPrefix + strings.ToLower(uri[len(uuidURNPrefix):])
+	}
+	return NormalizeUID(uri)
+}
+
+// uidKeys returns the keys of the member URIs referring to the card with the
+// specified UID, see memberKey. A UID which is neither a URI nor a UUID can
+// also be referred to with a "urn:uuid:" URI, as done by some clients.
+func uidKeys(uid string) []string {
+	uid = strings.TrimSpace(uid)
+	keys := []string{memberKey(uid)}
+	if !strings.Contains(uid, ":") && !isUUID(uid) {
+		keys = append(keys, memberKey(uuidURNPrefix+uid))
+	}
+	return keys
+}
+
+// A GroupMember is a member of a group.
+type GroupMember struct {
+	// URI is the value of the MEMBER property.
+	URI string
+	// Card is nil if the URI doesn't refer to a card of the address book,
+	// e.g. for the email address of an external contact.
+	Card Card
+}
+
+// Members returns the direct members of a group. A "urn:uuid:" URI refers to
+// the card with the same UID, with or without the "urn:uuid:" prefix and
+// compared with EqualUID, and a "mailto:" URI refers to the cards with the
+// same email address.
+func (ab *AddressBook) Members(group Card) []GroupMember {
+	ab.mutex.RLock()
+	defer ab.mutex.RUnlock()
+
+	var members []GroupMember
+	for _, uri := range group.Members() {
+		members = append(members, ab.resolveMember(uri)...)
+	}
+	return members
+}
+
+// ExpandGroup returns the members of a group which aren't groups themselves,
+// recursively expanding nested groups. Each card is returned once. If a group
+// is a member of itself, a *GroupCycleError is returned.
+func (ab *AddressBook) ExpandGroup(group Card) ([]GroupMember, error) {
+	ab.mutex.RLock()
+	defer ab.mutex.RUnlock()
+
+	exp := groupExpansion{
+		ab:       ab,
+		seen:     make(map[interface{}]bool),
+		expanded: make(map[interface{}]bool),
+	}
+	if err := exp.expand(group); err != nil {
+		return nil, err
+	}
+	return exp.members, nil
+}
+
+type groupExpansion struct {
+	ab *AddressBook
+	// groups being expanded, to detect cycles
+	stack []Card
+	// IDs of the groups already expanded, see cardID
+	expanded map[interface{}]bool
+	// IDs of the cards and keys of the unresolved URIs already returned
+	seen    map[interface{}]bool
+	members []GroupMember
+}
+
+// unresolvedKey is the key of an unresolved member URI in
+// groupExpansion.seen.
+type unresolvedKey string
+
+// cardID identifies a card: by UID, or by identity if it has no UID.
+func cardID(c Card) interface{} {
+	if uid := c.Value(FieldUID); uid != "" {
+		return uid
+	}
+	return reflect.ValueOf(c).Pointer()
+}
+
+func (exp *groupExpansion) expand(group Card) error {
+	id := cardID(group)
+	for i, g := range exp.stack {
+		if cardID(g) == id {
+			var uids []string
+			for _, g := range exp.stack[i:] {
+				uids = append(uids, g.Value(FieldUID))
+			}
+			return &GroupCycleError{UIDs: append(uids, group.Value(FieldUID))}
+		}
+	}
+	if exp.expanded[id] {
+		return nil
+	}
+	exp.stack = append(exp.stack, group)
+
+	for _, uri := range group.Members() {
+		for _, m := range exp.ab.resolveMember(uri) {
+			if m.Card != nil && m.Card.Kind() == KindGroup {
+				if err := exp.expand(m.Card); err != nil {
+					return err
+				}
+				continue
+			}
+
+			var k interface{}
+			if m.Card != nil {
+				k = cardID(m.Card)
+			} else {
+				k = unresolvedKey(memberKey(m.URI))
+			}
+			if !exp.seen[k] {
+				exp.seen[k] = true
+				exp.members = append(exp.members, m)
+			}
+		}
+	}
+
+	exp.stack = exp.stack[:len(exp.stack)-1]
+	exp.expanded[id] = true
+	return nil
+}
+
+// resolveMember returns the cards referred to by a member URI. The caller
+// must hold the address book mutex.
+func (ab *AddressBook) resolveMember(uri string) []GroupMember {
+	uri = strings.TrimSpace(uri)
+	var cards []Card
+	if len(uri) > 7 && strings.EqualFold(uri[:7], "mailto:") {
+		var uids []string
+		for uid := range ab.emails[normalizeEmail(uri)] {
+			uids = append(uids, uid)
+		}
+		cards = ab.cardsByUID(uids)
+	} else if entry, ok := ab.entries[uri]; ok {
+		cards = []Card{entry.card}
+	} else {
+		var uids []string
+		for uid := range ab.uids[memberKey(uri)] {
+			uids = append(uids, uid)
+		}
+		cards = ab.cardsByUID(uids)
+	}
+
+	if len(cards) == 0 {
+		return []GroupMember{{URI: uri}}
+	}
+	members := make([]GroupMember, len(cards))
+	for i, card := range cards {
+		members[i] = GroupMember{URI: uri, Card: card}
+	}
+	return members
+}
@@ -0,0 +1,174 @@
+package vcard
+
+import (
+	"reflect"
+	"testing"
+)
+
+func testGroupCard(uid string, members ...string) Card {
+	card := newAddressBookTestCard(uid, uid)
+	card.SetValue(FieldVersion, "4.0")
+	card.SetKind(KindGroup)
+	for _, m := range members {
+		card.AddValue(FieldMember, m)
+	}
+	return card
+}
+
+func memberURIs(members []GroupMember) []string {
+	var l []string
+	for _, m := range members {
+		s := m.URI
+		if m.Card != nil {
+			s += "=" + m.Card.Value(FieldUID)
+		}
+		l = append(l, s)
+	}
+	return l
+}
+
+func TestCard_AddMember(t *testing.T) {
+	group := testGroupCard("group")
+	if !group.AddMember("03a0e51f-d1aa-4385-8a53-e29025acd8af") {
+		t.Errorf("AddMember() = false, want true")
+	}
+	if !group.AddMember("mailto:alice@example.com") {
+		t.Errorf("AddMember() = false, want true")
+	}
+	if group.AddMember("urn:uuid:03A0E51F-D1AA-4385-8A53-E29025ACD8AF") {
+		t.Errorf("AddMember() of an existing member = true, want false")
+	}
+	want := []string{"urn:uuid:03a0e51f-d1aa-4385-8a53-e29025acd8af", "mailto:alice@example.com"}
+	if members := group.Members(); !reflect.DeepEqual(members, want) {
+		t.Errorf("Members() = %v, want %v", members, want)
+	}
+
+	if !group.RemoveMember("03a0e51f-d1aa-4385-8a53-e29025acd8af") {
+		t.Errorf("RemoveMember() = false, want true")
+	}
+	if group.RemoveMember("unknown") {
+		t.Errorf("RemoveMember() of an unknown member = true, want false")
+	}
+	if !group.RemoveMember("MAILTO:Alice@example.com") {
+		t.Errorf("RemoveMember() = false, want true")
+	}
+	if _, ok := group[FieldMember]; ok {
+		t.Errorf("RemoveMember() didn't delete the empty MEMBER property")
+	}
+}
+
+func TestCard_AddMember_nonUUID(t *testing.T) {
+	group := testGroupCard("group")
+	if !group.AddMember("john@example.com") {
+		t.Errorf("AddMember() = false, want true")
+	}
+	if want := []string{"john@example.com"}; !reflect.DeepEqual(group.Members(), want) {
+		t.Errorf("Members() = %v, want %v", group.Members(), want)
+	}
+
+	// Cards with such UIDs can still be referred to with "urn:uuid:" URIs
+	ab := NewAddressBook()
+	ab.Put(newAddressBookTestCard("john@example.com", "John"))
+	group.AddValue(FieldMember, "urn:uuid:john@example.com")
+	want := []string{"john@example.com=john@example.com", "urn:uuid:john@example.com=john@example.com"}
+	if got := memberURIs(ab.Members(group)); !reflect.DeepEqual(got, want) {
+		t.Errorf("Members() = %v, want %v", got, want)
+	}
+}
+
+func TestAddressBook_Members(t *testing.T) {
+	ab := NewAddressBook()
+	ab.Put(Card{FieldUID: {{Value: "urn:uuid:alice"}}, FieldEmail: {{Value: "alice@example.com"}}})
+	ab.Put(Card{FieldUID: {{Value: "bob"}}})
+
+	group := testGroupCard("group", "urn:uuid:alice", "urn:uuid:bob", "mailto:Alice@example.com", "mailto:carol@example.com")
+	got := memberURIs(ab.Members(group))
+	want := []string{
+		"urn:uuid:alice=urn:uuid:alice",
+		"urn:uuid:bob=bob",
+		"mailto:Alice@example.com=urn:uuid:alice",
+		"mailto:carol@example.com",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Members() = %v, want %v", got, want)
+	}
+}
+
+func TestAddressBook_ExpandGroup(t *testing.T) {
+	ab := NewAddressBook()
+	ab.Put(Card{FieldUID: {{Value: "alice"}}, FieldEmail: {{Value: "alice@example.com"}}})
+	ab.Put(Card{FieldUID: {{Value: "bob"}}})
+	ab.Put(testGroupCard("friends", "urn:uuid:alice", "urn:uuid:bob"))
+	ab.Put(testGroupCard("family", "urn:uuid:alice"))
+	ab.Put(testGroupCard("all", "urn:uuid:friends", "urn:uuid:family", "mailto:alice@example.com", "mailto:dave@example.com"))
+
+	members, err := ab.ExpandGroup(ab.Get("all"))
+	if err != nil {
+		t.Fatalf("ExpandGroup() = %v", err)
+	}
+	want := []string{"urn:uuid:alice=alice", "urn:uuid:bob=bob", "mailto:dave@example.com"}
+	if got := memberURIs(members); !reflect.DeepEqual(got, want) {
+		t.Errorf("ExpandGroup() = %v, want %v", got, want)
+	}
+}
+
+func TestAddressBook_ExpandGroup_cycle(t *testing.T) {
+	ab := NewAddressBook()
+	ab.Put(testGroupCard("a", "urn:uuid:b"))
+	ab.Put(testGroupCard("b", "urn:uuid:c"))
+	ab.Put(testGroupCard("c", "urn:uuid:a"))
+
+	_, err := ab.ExpandGroup(ab.Get("a"))
+	cycleErr, ok := err.(*GroupCycleError)
+	if !ok {
+		t.Fatalf("ExpandGroup() = %v, want a *GroupCycleError", err)
+	}
+	if want := []string{"a", "b", "c", "a"}; !reflect.DeepEqual(cycleErr.UIDs, want) {
+		t.Errorf("GroupCycleError.UIDs = %v, want %v", cycleErr.UIDs, want)
+	}
+}
+
+// putWithoutUID adds cards to an address book, then removes their UID. Cards
+// are stored by reference, so the address book keeps indexing them by their
+// former UID. This breaks the rule that cards mustn't be modified after Put,
+// but it's the only way to get cards without a UID into an address book.
+func putWithoutUID(ab *AddressBook, cards ...Card) {
+	for _, card := range cards {
+		ab.Put(card)
+		delete(card, FieldUID)
+	}
+}
+
+func expandedNames(t *testing.T, ab *AddressBook, group Card) []string {
+	members, err := ab.ExpandGroup(group)
+	if err != nil {
+		t.Fatalf("ExpandGroup() = %v", err)
+	}
+	var fns []string
+	for _, m := range members {
+		fns = append(fns, m.Card.Value(FieldFormattedName))
+	}
+	return fns
+}
+
+func TestAddressBook_ExpandGroup_membersWithoutUID(t *testing.T) {
+	ab := NewAddressBook()
+	putWithoutUID(ab, newAddressBookTestCard("alice", "Alice"), newAddressBookTestCard("bob", "Bob"))
+
+	group := testGroupCard("group", "urn:uuid:alice", "urn:uuid:bob")
+	if got, want := expandedNames(t, ab, group), []string{"Alice", "Bob"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("ExpandGroup() = %v, want %v", got, want)
+	}
+}
+
+func TestAddressBook_ExpandGroup_groupsWithoutUID(t *testing.T) {
+	ab := NewAddressBook()
+	ab.Put(newAddressBookTestCard("alice", "Alice"))
+	putWithoutUID(ab, testGroupCard("inner", "urn:uuid:alice"))
+
+	group := testGroupCard("outer", "urn:uuid:inner")
+	delete(group, FieldUID)
+	if got, want := expandedNames(t, ab, group), []string{"Alice"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("ExpandGroup() = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,143 @@
+package vcard
+
+import (
+	"crypto/sha1"
+	"strings"
+)
+
+// Apple-specific properties used by iCloud and macOS to represent groups in
+// vCard 3.0.
+const (
+	FieldAppleKind   = "X-ADDRESSBOOKSERVER-KIND"
+	FieldAppleMember = "X-ADDRESSBOOKSERVER-MEMBER"
+)
+
+// ToAppleGroup converts a group from the RFC 6350 form, with the KIND and
+// MEMBER properties, to the form used by Apple, with the
+// X-ADDRESSBOOKSERVER-KIND and X-ADDRESSBOOKSERVER-MEMBER properties. The
+// card is modified in place. Cards which aren't groups are left untouched.
+func (c Card) ToAppleGroup() {
+	if c.Kind() != KindGroup {
+		return
+	}
+	delete(c, FieldKind)
+	c.SetValue(FieldAppleKind, string(KindGroup))
+	moveFields(c, FieldMember, FieldAppleMember)
+}
+
+// FromAppleGroup converts a group from the form used by Apple to the
+// RFC 6350 form. It's the inverse of ToAppleGroup.
+func (c Card) FromAppleGroup() {
+	if !strings.EqualFold(c.Value(FieldAppleKind), string(KindGroup)) {
+		return
+	}
+	delete(c, FieldAppleKind)
+	c.SetKind(KindGroup)
+	moveFields(c, FieldAppleMember, FieldMember)
+}
+
+// moveFields moves the fields of the property from to the property to,
+// skipping members already present.
+func moveFields(c Card, from, to string) {
+	fields := c[from]
+	delete(c, from)
+
+	seen := make(map[string]bool)
+	for _, v := range c.Values(to) {
+		seen[memberKey(v)] = true
+	}
+	for _, f := range fields {
+		if k := memberKey(f.Value); !seen[k] {
+			seen[k] = true
+			c.Add(to, f)
+		}
+	}
+}
+
+// GroupsFromCategories converts groups represented as categories, as done by
+// Google Contacts, to group cards. One group card is created per category,
+// with the cards having this category as members. The UID of each group is
+// derived from the category name, so that converting the same categories
+// twice results in the same groups. Cards without a UID are ignored.
+func GroupsFromCategories(cards []Card) []Card {
+	var groups []Card
+	byName := make(map[string]Card)
+	for _, card := range cards {
+		uid := card.Value(FieldUID)
+		if uid == "" {
+			continue
+		}
+		for _, name := range cardCategories(card) {
+			group, ok := byName[name]
+			if !ok {
+				group = Card{
+					FieldVersion:       {{Value: Version40}},
+					FieldUID:           {{Value: categoryGroupUID(name)}},
+					FieldFormattedName: {{Value: name}},
+				}
+				group.SetKind(KindGroup)
+				byName[name] = group
+				groups = append(groups, group)
+			}
+			group.AddMember(uid)
+		}
+	}
+	return groups
+}
+
+// CategoriesFromGroups converts group cards to categories: the formatted
+// name of each group is added to the categories of its members. Members are
+// looked up in cards by UID and email address. The member cards are modified
+// in place. Cards which aren't groups and groups without a formatted name are
+// ignored.
+func CategoriesFromGroups(groups []Card, cards []Card) {
+	byKey := indexCardsByURI(cards)
+
+	for _, group := range groups {
+		name := strings.TrimSpace(group.Value(FieldFormattedName))
+		if group.Kind() != KindGroup || name == "" {
+			continue
+		}
+		for _, uri := range group.Members() {
+			for _, card := range byKey[memberKey(uri)] {
+				addCategory(card, name)
+			}
+		}
+	}
+}
+
+func cardCategories(c Card) []string {
+	var categories []string
+	for _, v := range c.Values(FieldCategories) {
+		for _, s := range strings.Split(v, ",") {
+			if s = strings.TrimSpace(s); s != "" {
+				categories = append(categories, s)
+			}
+		}
+	}
+	return categories
+}
+
+func addCategory(c Card, name string) {
+	categories := cardCategories(c)
+	for _, s := range categories {
+		if strings.EqualFold(s, name) {
+			return
+		}
+	}
+	c.SetCategories(append(categories, name))
+}
+
+// categoryGroupNamespace is the namespace of the name-based UUIDs of groups
+// created from categories.
+var categoryGroupNamespace = [16]byte{0x3c, 0x5d, 0x6b, 0x8e, 0x2f, 0x41, 0x4a, 0x0b, 0x9d, 0x27, 0x6e, 0x13, 0xa4, 0x58, 0xc2, 0x7f}
+
+// categoryGroupUID returns a version 5 UUID URN derived from a category name.
+func categoryGroupUID(name string) string {
+	h := sha1.New()
+	h.Write(categoryGroupNamespace[:])
+	h.Write([]byte(name))
+	var b [16]byte
+	copy(b[:], h.Sum(nil))
+	return formatUUID(b, 5)
+}
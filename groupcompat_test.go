@@ -0,0 +1,101 @@
+package vcard
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCard_ToAppleGroup(t *testing.T) {
+	group := testGroupCard("group", "urn:uuid:alice", "urn:uuid:bob")
+	group.ToAppleGroup()
+
+	if _, ok := group[FieldKind]; ok {
+		t.Errorf("ToAppleGroup() kept KIND")
+	}
+	if _, ok := group[FieldMember]; ok {
+		t.Errorf("ToAppleGroup() kept MEMBER")
+	}
+	if kind := group.Value(FieldAppleKind); kind != "group" {
+		t.Errorf("ToAppleGroup(): %v = %q, want %q", FieldAppleKind, kind, "group")
+	}
+	want := []string{"urn:uuid:alice", "urn:uuid:bob"}
+	if members := group.Values(FieldAppleMember); !reflect.DeepEqual(members, want) {
+		t.Errorf("ToAppleGroup(): %v = %v, want %v", FieldAppleMember, members, want)
+	}
+
+	group.FromAppleGroup()
+	if want := testGroupCard("group", "urn:uuid:alice", "urn:uuid:bob"); !reflect.DeepEqual(group, want) {
+		t.Errorf("FromAppleGroup() = %v, want %v", group, want)
+	}
+
+	individual := Card{FieldMember: {{Value: "urn:uuid:alice"}}}
+	individual.ToAppleGroup()
+	if _, ok := individual[FieldAppleMember]; ok {
+		t.Errorf("ToAppleGroup() converted a card which isn't a group")
+	}
+}
+
+func TestCard_FromAppleGroup(t *testing.T) {
+	group := Card{
+		FieldVersion:     {{Value: "3.0"}},
+		FieldAppleKind:   {{Value: "Group"}},
+		FieldAppleMember: {{Value: "urn:uuid:alice"}, {Value: "urn:uuid:bob"}},
+		FieldMember:      {{Value: "urn:uuid:ALICE"}},
+	}
+	group.FromAppleGroup()
+
+	if group.Kind() != KindGroup {
+		t.Errorf("FromAppleGroup(): Kind() = %v, want %v", group.Kind(), KindGroup)
+	}
+	want := []string{"urn:uuid:ALICE", "urn:uuid:bob"}
+	if members := group.Members(); !reflect.DeepEqual(members, want) {
+		t.Errorf("FromAppleGroup(): Members() = %v, want %v", members, want)
+	}
+}
+
+func TestGroupsFromCategories(t *testing.T) {
+	cards := []Card{
+		{FieldUID: {{Value: "alice"}}, FieldCategories: {{Value: "Friends,Work"}}},
+		{FieldUID: {{Value: "bob"}}, FieldCategories: {{Value: "Friends"}}},
+		{FieldCategories: {{Value: "Ignored"}}},
+	}
+	groups := GroupsFromCategories(cards)
+	if len(groups) != 2 {
+		t.Fatalf("GroupsFromCategories() returned %v groups, want 2", len(groups))
+	}
+
+	friends := groups[0]
+	if friends.Kind() != KindGroup || friends.Value(FieldFormattedName) != "Friends" {
+		t.Errorf("GroupsFromCategories(): first group = %v", friends)
+	}
+	if want := []string{"alice", "bob"}; !reflect.DeepEqual(friends.Members(), want) {
+		t.Errorf("GroupsFromCategories(): members = %v, want %v", friends.Members(), want)
+	}
+
+	again := GroupsFromCategories(cards)
+	if uid := again[0].Value(FieldUID); uid != friends.Value(FieldUID) {
+		t.Errorf("GroupsFromCategories(): UID isn't stable: %q != %q", uid, friends.Value(FieldUID))
+	}
+	if friends.Value(FieldUID) == groups[1].Value(FieldUID) {
+		t.Errorf("GroupsFromCategories(): groups have the same UID")
+	}
+}
+
+func TestCategoriesFromGroups(t *testing.T) {
+	alice := Card{FieldUID: {{Value: "urn:uuid:alice"}}, FieldCategories: {{Value: "friends"}}}
+	bob := Card{FieldUID: {{Value: "bob"}}, FieldEmail: {{Value: "bob@example.com"}}}
+	cards := []Card{alice, bob}
+
+	friends := testGroupCard("friends", "urn:uuid:alice", "mailto:Bob@example.com")
+	friends.SetValue(FieldFormattedName, "Friends")
+	work := testGroupCard("work", "urn:uuid:bob")
+	work.SetValue(FieldFormattedName, "Work")
+	CategoriesFromGroups([]Card{friends, work}, cards)
+
+	if want := []string{"friends"}; !reflect.DeepEqual(cardCategories(alice), want) {
+		t.Errorf("CategoriesFromGroups(): alice categories = %v, want %v", cardCategories(alice), want)
+	}
+	if want := []string{"Friends", "Work"}; !reflect.DeepEqual(cardCategories(bob), want) {
+		t.Errorf("CategoriesFromGroups(): bob categories = %v, want %v", cardCategories(bob), want)
+	}
+}
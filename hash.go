@@ -0,0 +1,74 @@
+package vcard
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"sort"
+)
+
+// VolatileProps lists properties which are usually updated when a card is
+// saved, even if it hasn't been modified.
+var VolatileProps = []string{FieldRevision, FieldProductID}
+
+// HashOptions contains options for Card.Hash.
+type HashOptions struct {
+	// IgnoreProps lists properties excluded from the hash, e.g.
+	// VolatileProps.
+	IgnoreProps []string
+	// IgnoreOrder makes the hash independent of the order of the fields of
+	// a property.
+	IgnoreOrder bool
+}
+
+// Hash returns a SHA-256 hash of the card. The hash is computed over a
+// canonical serialization of the normalized card (see Normalize), so it
+// doesn't depend on map iteration order, parameter name case and other
+// formatting details. The card isn't modified. If options is nil, all
+// properties are hashed and the order of fields matters.
+func (c Card) Hash(options *HashOptions) [sha256.Size]byte {
+	if options == nil {
+		options = new(HashOptions)
+	}
+
+	norm := c.Clone()
+	norm.Normalize()
+
+	keys := make([]string, 0, len(norm))
+	for k := range norm {
+		if !containsFold(options.IgnoreProps, k) {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+
+	h := sha256.New()
+	var lines []string
+	for _, k := range keys {
+		lines = lines[:0]
+		for _, f := range norm[k] {
+			paramKeys := make([]string, 0, len(f.Params))
+			for pk := range f.Params {
+				paramKeys = append(paramKeys, pk)
+			}
+			sort.Strings(paramKeys)
+			lines = append(lines, string(appendLine(nil, k, f, paramKeys))+"\r\n")
+		}
+		if options.IgnoreOrder {
+			sort.Strings(lines)
+		}
+		for _, l := range lines {
+			h.Write([]byte(l))
+		}
+	}
+
+	var sum [sha256.Size]byte
+	h.Sum(sum[:0])
+	return sum
+}
+
+// ETag returns an entity tag for the card, without quotes. Cards with the
+// same hash have the same entity tag. See Hash.
+func (c Card) ETag(options *HashOptions) string {
+	sum := c.Hash(options)
+	return hex.EncodeToString(sum[:16])
+}
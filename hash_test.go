@@ -0,0 +1,71 @@
+package vcard
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCard_Hash(t *testing.T) {
+	a := Card{
+		FieldVersion:       {{Value: "4.0"}},
+		FieldFormattedName: {{Value: "Alice Liddell"}},
+		FieldEmail: {
+			{Value: "alice@example.com", Params: Params{ParamType: {"work", "home"}}},
+			{Value: "alice@example.org"},
+		},
+		FieldRevision:  {{Value: "20230918T100000Z"}},
+		FieldProductID: {{Value: "-//Example//EN"}},
+	}
+	b := Card{
+		FieldVersion: {{Value: "4.0"}},
+		"fn":         {{Value: "Alice Liddell "}},
+		FieldEmail: {
+			{Value: "alice@example.com", Params: Params{"type": {"HOME,Work"}}},
+			{Value: "alice@example.org"},
+		},
+		FieldRevision:  {{Value: "20240101T000000Z"}},
+		FieldProductID: {{Value: "-//Other//EN"}},
+	}
+
+	if a.Hash(nil) != a.Clone().Hash(nil) {
+		t.Errorf("Hash() isn't stable")
+	}
+	if a.Hash(nil) == b.Hash(nil) {
+		t.Errorf("Hash() of cards with different REV is the same")
+	}
+	options := &HashOptions{IgnoreProps: VolatileProps}
+	if a.Hash(options) != b.Hash(options) {
+		t.Errorf("Hash() ignoring volatile properties differs")
+	}
+	if b.Value("fn") != "Alice Liddell " {
+		t.Errorf("Hash() modified the card")
+	}
+
+	b[FieldEmail][0], b[FieldEmail][1] = b[FieldEmail][1], b[FieldEmail][0]
+	if a.Hash(options) == b.Hash(options) {
+		t.Errorf("Hash() doesn't depend on field order")
+	}
+	options.IgnoreOrder = true
+	if a.Hash(options) != b.Hash(options) {
+		t.Errorf("Hash() ignoring order differs")
+	}
+
+	b.SetValue(FieldNote, "changed")
+	if a.Hash(options) == b.Hash(options) {
+		t.Errorf("Hash() of different cards is the same")
+	}
+}
+
+func TestCard_ETag(t *testing.T) {
+	card := Card{
+		FieldVersion:       {{Value: "4.0"}},
+		FieldFormattedName: {{Value: "Alice"}},
+	}
+	etag := card.ETag(nil)
+	if len(etag) != 32 || strings.Trim(etag, "0123456789abcdef") != "" {
+		t.Errorf("ETag() = %q, want 32 hexadecimal digits", etag)
+	}
+	if card.Clone().ETag(nil) != etag {
+		t.Errorf("ETag() isn't stable")
+	}
+}
@@ -0,0 +1,90 @@
+// Package hcard implements the h-card microformat, defined in
+// https://microformats.org/wiki/h-card, and its predecessor hCard.
+//
+// Cards are rendered as microformats2 h-card HTML. Both h-card and legacy
+// hCard markup can be parsed.
+package hcard
+
+import (
+	"github.com/emersion/go-vcard"
+)
+
+// Address properties, in the order of the ADR components.
+var addressProps = []string{
+	"post-office-box",
+	"extended-address",
+	"street-address",
+	"locality",
+	"region",
+	"postal-code",
+	"country-name",
+}
+
+// Name properties, in the order of the N components.
+var nameProps = []string{
+	"family-name",
+	"given-name",
+	"additional-name",
+	"honorific-prefix",
+	"honorific-suffix",
+}
+
+// Simple properties mapped to a single vCard property.
+var simpleProps = map[string]string{
+	"name":        vcard.FieldFormattedName,
+	"nickname":    vcard.FieldNickname,
+	"email":       vcard.FieldEmail,
+	"tel":         vcard.FieldTelephone,
+	"url":         vcard.FieldURL,
+	"photo":       vcard.FieldPhoto,
+	"logo":        vcard.FieldLogo,
+	"uid":         vcard.FieldUID,
+	"bday":        vcard.FieldBirthday,
+	"anniversary": vcard.FieldAnniversary,
+	"job-title":   vcard.FieldTitle,
+	"role":        vcard.FieldRole,
+	"note":        vcard.FieldNote,
+	"category":    vcard.FieldCategories,
+	"key":         vcard.FieldKey,
+	"impp":        vcard.FieldIMPP,
+	"tz":          vcard.FieldTimezone,
+	"sound":       vcard.FieldSound,
+}
+
+// Legacy hCard class names, mapped to their microformats2 equivalent.
+var legacyProps = map[string]string{
+	"fn":                "p-name",
+	"family-name":       "p-family-name",
+	"given-name":        "p-given-name",
+	"additional-name":   "p-additional-name",
+	"honorific-prefix":  "p-honorific-prefix",
+	"honorific-suffix":  "p-honorific-suffix",
+	"nickname":          "p-nickname",
+	"email":             "u-email",
+	"tel":               "p-tel",
+	"url":               "u-url",
+	"photo":             "u-photo",
+	"logo":              "u-logo",
+	"uid":               "u-uid",
+	"bday":              "dt-bday",
+	"title":             "p-job-title",
+	"role":              "p-role",
+	"note":              "p-note",
+	"category":          "p-category",
+	"key":               "u-key",
+	"sound":             "u-sound",
+	"org":               "p-org",
+	"organization-name": "p-organization-name",
+	"organization-unit": "p-organization-unit",
+	"adr":               "p-adr",
+	"post-office-box":   "p-post-office-box",
+	"extended-address":  "p-extended-address",
+	"street-address":    "p-street-address",
+	"locality":          "p-locality",
+	"region":            "p-region",
+	"postal-code":       "p-postal-code",
+	"country-name":      "p-country-name",
+	"geo":               "p-geo",
+	"latitude":          "p-latitude",
+	"longitude":         "p-longitude",
+}
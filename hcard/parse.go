@@ -0,0 +1,356 @@
+package hcard
+
+import (
+	"io"
+	"strings"
+
+	"github.com/emersion/go-vcard"
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+type node struct {
+	tag      string // empty for text nodes
+	attrs    map[string]string
+	text     string
+	children []*node
+}
+
+func (n *node) classes() []string {
+	return strings.Fields(n.attrs["class"])
+}
+
+// textContent returns the text of the node, with images replaced with their
+// alternative text and whitespace collapsed.
+func (n *node) textContent() string {
+	var sb strings.Builder
+	var walk func(n *node)
+	walk = func(n *node) {
+		switch n.tag {
+		case "":
+			sb.WriteString(n.text)
+		case "img":
+			sb.WriteString(n.attrs["alt"])
+		default:
+			for _, child := range n.children {
+				walk(child)
+			}
+		}
+	}
+	walk(n)
+	return strings.Join(strings.Fields(sb.String()), " ")
+}
+
+// parseHTML parses an HTML document. Scripts, stylesheets and comments are
+// omitted from the tree.
+func parseHTML(r io.Reader) (*node, error) {
+	doc, err := html.Parse(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var convert func(hn *html.Node) *node
+	convert = func(hn *html.Node) *node {
+		n := &node{tag: strings.ToLower(hn.Data), attrs: make(map[string]string)}
+		for _, attr := range hn.Attr {
+			n.attrs[strings.ToLower(attr.Key)] = attr.Val
+		}
+		for c := hn.FirstChild; c != nil; c = c.NextSibling {
+			switch c.Type {
+			case html.TextNode:
+				n.children = append(n.children, &node{text: c.Data})
+			case html.ElementNode:
+				if c.DataAtom == atom.Script || c.DataAtom == atom.Style {
+					continue
+				}
+				n.children = append(n.children, convert(c))
+			}
+		}
+		return n
+	}
+	root := convert(doc)
+	root.tag = "#document"
+	return root, nil
+}
+
+// Parse parses all top-level h-card and legacy hCard elements of an HTML
+// document.
+func Parse(r io.Reader) ([]vcard.Card, error) {
+	root, err := parseHTML(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var cards []vcard.Card
+	var walk func(n *node)
+	walk = func(n *node) {
+		if root, legacy := rootClass(n); root {
+			cards = append(cards, parseCard(n, legacy))
+			return
+		}
+		for _, child := range n.children {
+			walk(child)
+		}
+	}
+	walk(root)
+	return cards, nil
+}
+
+func rootClass(n *node) (root, legacy bool) {
+	for _, class := range n.classes() {
+		if class == "h-card" {
+			return true, false
+		}
+	}
+	for _, class := range n.classes() {
+		if class == "vcard" {
+			return true, true
+		}
+	}
+	return false, false
+}
+
+// properties returns the microformats2 property classes of a node. Legacy
+// classes are converted if legacy is true.
+func properties(n *node, legacy bool) []string {
+	var props []string
+	for _, class := range n.classes() {
+		if legacy {
+			class = legacyProps[class]
+		}
+		if i := strings.IndexByte(class, '-'); i > 0 {
+			switch class[:i] {
+			case "p", "u", "dt", "e":
+				props = append(props, class)
+			}
+		}
+	}
+	return props
+}
+
+type parsedProp struct {
+	name  string // without prefix
+	value string
+	node  *node
+}
+
+// collectProps returns the properties of the item n. Nested items are not
+// traversed, but are returned as properties if they have a property class.
+func collectProps(n *node, legacy bool) []parsedProp {
+	var props []parsedProp
+	var walk func(n *node)
+	walk = func(n *node) {
+		for _, child := range n.children {
+			if child.tag == "" {
+				continue
+			}
+			for _, class := range properties(child, legacy) {
+				i := strings.IndexByte(class, '-')
+				props = append(props, parsedProp{
+					name:  class[i+1:],
+					value: propValue(child, class[:i]),
+					node:  child,
+				})
+			}
+			if root, _ := rootClass(child); root || isAddress(child, legacy) {
+				continue
+			}
+			walk(child)
+		}
+	}
+	walk(n)
+	return props
+}
+
+func isAddress(n *node, legacy bool) bool {
+	for _, class := range n.classes() {
+		if class == "h-adr" || (legacy && class == "adr") {
+			return true
+		}
+	}
+	return false
+}
+
+// propValue parses a property value, following the microformats2 parsing
+// rules.
+func propValue(n *node, prefix string) string {
+	switch prefix {
+	case "u":
+		switch n.tag {
+		case "a", "area", "link":
+			if v, ok := n.attrs["href"]; ok {
+				return v
+			}
+		case "img", "audio", "video", "source", "iframe":
+			if v, ok := n.attrs["src"]; ok {
+				return v
+			}
+		case "object":
+			if v, ok := n.attrs["data"]; ok {
+				return v
+			}
+		}
+	case "dt":
+		switch n.tag {
+		case "time", "ins", "del":
+			if v, ok := n.attrs["datetime"]; ok {
+				return v
+			}
+		}
+	}
+
+	switch n.tag {
+	case "abbr", "acronym":
+		if v, ok := n.attrs["title"]; ok {
+			return v
+		}
+	case "data", "input":
+		if v, ok := n.attrs["value"]; ok {
+			return v
+		}
+	case "img", "area":
+		if v, ok := n.attrs["alt"]; ok {
+			return v
+		}
+	}
+	return n.textContent()
+}
+
+func parseCard(n *node, legacy bool) vcard.Card {
+	card := make(vcard.Card)
+	card.SetValue(vcard.FieldVersion, "4.0")
+
+	var name vcard.Name
+	var hasName bool
+	var flatAddress vcard.Address
+	var hasFlatAddress bool
+	var org []string
+	var sex vcard.Sex
+	var identity string
+	var lat, lon string
+
+	for _, p := range collectProps(n, legacy) {
+		if component, ok := nameComponent(p.name, &name); ok {
+			*component = p.value
+			hasName = true
+			continue
+		}
+		if component, ok := addressComponent(p.name, &flatAddress); ok {
+			*component = p.value
+			hasFlatAddress = true
+			continue
+		}
+
+		switch p.name {
+		case "adr":
+			if isAddress(p.node, legacy) {
+				card.AddAddress(parseAddress(p.node, legacy))
+			} else {
+				card.AddAddress(&vcard.Address{StreetAddress: p.value})
+			}
+		case "org", "organization-name":
+			if len(org) > 0 && p.name == "organization-name" {
+				org[0] = p.value
+			} else {
+				org = append([]string{p.value}, org...)
+			}
+		case "organization-unit":
+			org = append(org, p.value)
+		case "email":
+			card.AddValue(vcard.FieldEmail, strings.TrimPrefix(p.value, "mailto:"))
+		case "tel":
+			card.AddValue(vcard.FieldTelephone, strings.TrimPrefix(p.value, "tel:"))
+		case "sex":
+			sex = vcard.Sex(strings.ToUpper(p.value))
+		case "gender-identity":
+			identity = p.value
+		case "latitude":
+			lat = p.value
+		case "longitude":
+			lon = p.value
+		case "geo":
+			if v := strings.TrimPrefix(p.value, "geo:"); strings.Contains(v, ";") {
+				l := strings.SplitN(v, ";", 2)
+				lat, lon = l[0], l[1]
+			} else {
+				card.AddValue(vcard.FieldGeolocation, p.value)
+			}
+		default:
+			if k, ok := simpleProps[p.name]; ok {
+				if k == vcard.FieldFormattedName && card.Get(k) != nil {
+					// The name of the card may contain nested names
+					continue
+				}
+				card.AddValue(k, p.value)
+			}
+		}
+	}
+
+	if hasName {
+		card.SetName(&name)
+	}
+	if hasFlatAddress {
+		card.AddAddress(&flatAddress)
+	}
+	if len(org) > 0 {
+		card.AddValue(vcard.FieldOrganization, strings.Join(org, ";"))
+	}
+	if sex != vcard.SexUnspecified || identity != "" {
+		card.SetGender(sex, identity)
+	}
+	if lat != "" && lon != "" {
+		card.AddValue(vcard.FieldGeolocation, "geo:"+lat+","+lon)
+	}
+	if card.Get(vcard.FieldFormattedName) == nil {
+		card.SetValue(vcard.FieldFormattedName, n.textContent())
+	}
+	if card.Get(vcard.FieldCategories) != nil {
+		card.SetCategories(card.Values(vcard.FieldCategories))
+	}
+
+	return card
+}
+
+func parseAddress(n *node, legacy bool) *vcard.Address {
+	address := new(vcard.Address)
+	for _, p := range collectProps(n, legacy) {
+		if component, ok := addressComponent(p.name, address); ok {
+			*component = p.value
+		}
+	}
+	return address
+}
+
+func nameComponent(prop string, name *vcard.Name) (*string, bool) {
+	components := []*string{
+		&name.FamilyName,
+		&name.GivenName,
+		&name.AdditionalName,
+		&name.HonorificPrefix,
+		&name.HonorificSuffix,
+	}
+	for i, p := range nameProps {
+		if p == prop {
+			return components[i], true
+		}
+	}
+	return nil, false
+}
+
+func addressComponent(prop string, address *vcard.Address) (*string, bool) {
+	components := []*string{
+		&address.PostOfficeBox,
+		&address.ExtendedAddress,
+		&address.StreetAddress,
+		&address.Locality,
+		&address.Region,
+		&address.PostalCode,
+		&address.Country,
+	}
+	for i, p := range addressProps {
+		if p == prop {
+			return components[i], true
+		}
+	}
+	return nil, false
+}
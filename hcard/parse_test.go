@@ -0,0 +1,123 @@
+package hcard
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/emersion/go-vcard"
+)
+
+const testHTML = `<!DOCTYPE html>
+<html>
+<head>
+<script>if (a < b && c) {}</script>
+</head>
+<body>
+<div class="h-card">
+	<img class="u-photo" src="/joe.png" alt="Joe">
+	<a class="p-name u-url" href="https://joebloggs.com">Joe Bloggs</a>
+	<span class="p-org">Example Inc.</span>
+	<a class="u-email" href="mailto:me@joebloggs.com">email me</a>
+	<p class="p-adr h-adr">
+		<span class="p-street-address">1 Trafalgar Square</span>,
+		<span class="p-locality">London</span><br>
+		<span class="p-country-name">United Kingdom</span>
+	</p>
+	<time class="dt-bday" datetime="1985-04-12">April 12</time>
+	<span class="p-category">friends</span> <span class="p-category">london</span>
+	<div class="p-author h-card">Nested Card</div>
+</div>
+<div class="vcard">
+	<span class="fn n"><span class="given-name">Jane</span> <span class="family-name">Doe</span></span>
+	<a class="email" href="mailto:jane@example.com">jane@example.com</a>
+	<div class="org"><span class="organization-name">ACME</span> <span class="organization-unit">Sales</span></div>
+	<abbr class="bday" title="1990-01-01">1st of January</abbr>
+	<div class="adr"><span class="locality">Paris</span></div>
+</div>
+</body>
+</html>`
+
+func TestParse(t *testing.T) {
+	cards, err := Parse(strings.NewReader(testHTML))
+	if err != nil {
+		t.Fatal("Expected no error when parsing HTML, got:", err)
+	}
+	if len(cards) != 2 {
+		t.Fatalf("Expected two cards, got %v", len(cards))
+	}
+
+	expected := vcard.Card{
+		vcard.FieldVersion:       {{Value: "4.0"}},
+		vcard.FieldPhoto:         {{Value: "/joe.png"}},
+		vcard.FieldFormattedName: {{Value: "Joe Bloggs"}},
+		vcard.FieldURL:           {{Value: "https://joebloggs.com"}},
+		vcard.FieldOrganization:  {{Value: "Example Inc."}},
+		vcard.FieldEmail:         {{Value: "me@joebloggs.com"}},
+		vcard.FieldAddress:       {{Value: ";;1 Trafalgar Square;London;;;United Kingdom"}},
+		vcard.FieldBirthday:      {{Value: "1985-04-12"}},
+		vcard.FieldCategories:    {{Value: "friends,london"}},
+	}
+	if !reflect.DeepEqual(cards[0], expected) {
+		t.Errorf("Invalid h-card: expected \n%+v\n but got \n%+v", expected, cards[0])
+	}
+
+	legacy := cards[1]
+	if v := legacy.Value(vcard.FieldFormattedName); v != "Jane Doe" {
+		t.Errorf("Expected legacy FN to be %q, got %q", "Jane Doe", v)
+	}
+	if name := legacy.Name(); name == nil || name.GivenName != "Jane" || name.FamilyName != "Doe" {
+		t.Errorf("Invalid legacy name: %+v", name)
+	}
+	if v := legacy.Value(vcard.FieldEmail); v != "jane@example.com" {
+		t.Errorf("Expected legacy email to be %q, got %q", "jane@example.com", v)
+	}
+	if v := legacy.Value(vcard.FieldOrganization); v != "ACME;Sales" {
+		t.Errorf("Expected legacy org to be %q, got %q", "ACME;Sales", v)
+	}
+	if v := legacy.Value(vcard.FieldBirthday); v != "1990-01-01" {
+		t.Errorf("Expected legacy birthday to be %q, got %q", "1990-01-01", v)
+	}
+	if address := legacy.Address(); address == nil || address.Locality != "Paris" {
+		t.Errorf("Invalid legacy address: %+v", address)
+	}
+}
+
+func TestParse_lenient(t *testing.T) {
+	tests := []struct {
+		name string
+		html string
+	}{
+		{"bare <", `<p>if a < b then</p>`},
+		{"ampersand", `<p>Smith & Sons &copy; 2023 &unknown;</p>`},
+		{"comment", `<!-- <div class="h-card">Mallory</div> -->`},
+		{"script", `<script>document.write("</div>"); if (a < b) {}</script>`},
+		{"style", `<style>p::after { content: "</style"; }</style>`},
+	}
+	for _, tc := range tests {
+		html := `<div class="h-card">Carol</div>` + tc.html + `<div class="h-card">Dave</div>`
+		cards, err := Parse(strings.NewReader(html))
+		if err != nil {
+			t.Errorf("%v: Expected no error when parsing HTML, got: %v", tc.name, err)
+			continue
+		}
+		var names []string
+		for _, card := range cards {
+			names = append(names, card.Value(vcard.FieldFormattedName))
+		}
+		if want := []string{"Carol", "Dave"}; !reflect.DeepEqual(names, want) {
+			t.Errorf("%v: Expected cards %v, got %v", tc.name, want, names)
+		}
+	}
+}
+
+func TestParse_unclosed(t *testing.T) {
+	html := `<html><body><div class="h-card">Carol</div><div class="h-card">Dave`
+	cards, err := Parse(strings.NewReader(html))
+	if err != nil {
+		t.Fatal("Expected no error when parsing HTML, got:", err)
+	}
+	if len(cards) != 2 {
+		t.Errorf("Expected 2 cards, got %d", len(cards))
+	}
+}
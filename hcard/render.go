@@ -0,0 +1,189 @@
+package hcard
+
+import (
+	"html/template"
+	"io"
+	"strings"
+
+	"github.com/emersion/go-vcard"
+)
+
+var cardTemplate = template.Must(template.New("h-card").Parse(`<div class="h-card">
+{{- range .Photos}}
+	<img class="u-photo" src="{{.}}" alt="">
+{{- end}}
+{{- if .Name}}
+	<span class="p-name">
+	{{- range $i, $p := .Name}}{{if $i}} {{end}}<span class="p-{{$p.Class}}">{{$p.Value}}</span>{{end -}}
+	</span>
+{{- else}}
+	<span class="p-name">{{.FormattedName}}</span>
+{{- end}}
+{{- range .Props}}
+	{{if .Href}}<a class="{{.Class}}" href="{{.Href}}">{{.Value}}</a>
+	{{- else if .DateTime}}<time class="{{.Class}}" datetime="{{.DateTime}}">{{.Value}}</time>
+	{{- else if .Data}}<data class="{{.Class}}" value="{{.Data}}"></data>
+	{{- else}}<span class="{{.Class}}">{{.Value}}</span>
+	{{- end}}
+{{- end}}
+{{- range .Addresses}}
+	<div class="p-adr h-adr">
+	{{- range .}}
+		<span class="p-{{.Class}}">{{.Value}}</span>
+	{{- end}}
+	</div>
+{{- end}}
+</div>
+`))
+
+type renderProp struct {
+	Class    string
+	Value    string
+	Href     template.URL
+	DateTime string
+	Data     string
+}
+
+type renderCard struct {
+	FormattedName string
+	Name          []renderProp
+	Photos        []template.URL
+	Props         []renderProp
+	Addresses     [][]renderProp
+}
+
+// Render writes a card as h-card HTML.
+func Render(w io.Writer, card vcard.Card) error {
+	rc := renderCard{FormattedName: card.PreferredValue(vcard.FieldFormattedName)}
+
+	// Only render name components inside the name if they add up to the
+	// formatted name, to avoid displaying the name twice
+	if name := card.Name(); name != nil {
+		var parts []renderProp
+		var values []string
+		components := []string{name.HonorificPrefix, name.GivenName, name.AdditionalName, name.FamilyName, name.HonorificSuffix}
+		classes := []string{nameProps[3], nameProps[1], nameProps[2], nameProps[0], nameProps[4]}
+		for i, v := range components {
+			if v != "" {
+				parts = append(parts, renderProp{Class: classes[i], Value: v})
+				values = append(values, v)
+			}
+		}
+		if len(parts) > 0 && (rc.FormattedName == "" || strings.Join(values, " ") == rc.FormattedName) {
+			rc.Name = parts
+		} else {
+			for _, p := range parts {
+				p.Class = "p-" + p.Class
+				rc.Props = append(rc.Props, p)
+			}
+		}
+	}
+
+	for _, photo := range card.Values(vcard.FieldPhoto) {
+		rc.Photos = append(rc.Photos, safeURL(photo))
+	}
+	for _, nickname := range card.Values(vcard.FieldNickname) {
+		rc.Props = append(rc.Props, renderProp{Class: "p-nickname", Value: nickname})
+	}
+	for _, org := range card[vcard.FieldOrganization] {
+		rc.Props = append(rc.Props, renderProp{Class: "p-org", Value: strings.Replace(org.Value, ";", ", ", -1)})
+	}
+	for _, title := range card.Values(vcard.FieldTitle) {
+		rc.Props = append(rc.Props, renderProp{Class: "p-job-title", Value: title})
+	}
+	for _, role := range card.Values(vcard.FieldRole) {
+		rc.Props = append(rc.Props, renderProp{Class: "p-role", Value: role})
+	}
+	for _, email := range card.Values(vcard.FieldEmail) {
+		rc.Props = append(rc.Props, renderProp{
+			Class: "u-email",
+			Value: strings.TrimPrefix(email, "mailto:"),
+			Href:  safeURL("mailto:" + strings.TrimPrefix(email, "mailto:")),
+		})
+	}
+	for _, tel := range card.Values(vcard.FieldTelephone) {
+		number := strings.TrimPrefix(tel, "tel:")
+		rc.Props = append(rc.Props, renderProp{
+			Class: "p-tel",
+			Value: number,
+			Href:  safeURL("tel:" + strings.Replace(number, " ", "", -1)),
+		})
+	}
+	for _, k := range []string{vcard.FieldURL, vcard.FieldIMPP, vcard.FieldKey, vcard.FieldLogo} {
+		class := "u-" + strings.ToLower(k)
+		for _, u := range card.Values(k) {
+			rc.Props = append(rc.Props, renderProp{Class: class, Value: u, Href: safeURL(u)})
+		}
+	}
+	for _, k := range []string{vcard.FieldBirthday, vcard.FieldAnniversary} {
+		class := "dt-" + strings.ToLower(k)
+		for _, v := range card.Values(k) {
+			rc.Props = append(rc.Props, renderProp{Class: class, Value: v, DateTime: v})
+		}
+	}
+	if sex, identity := card.Gender(); sex != vcard.SexUnspecified || identity != "" {
+		if sex != vcard.SexUnspecified {
+			rc.Props = append(rc.Props, renderProp{Class: "p-sex", Value: string(sex)})
+		}
+		if identity != "" {
+			rc.Props = append(rc.Props, renderProp{Class: "p-gender-identity", Value: identity})
+		}
+	}
+	if len(card[vcard.FieldCategories]) > 0 {
+		for _, category := range card.Categories() {
+			rc.Props = append(rc.Props, renderProp{Class: "p-category", Value: category})
+		}
+	}
+	for _, note := range card.Values(vcard.FieldNote) {
+		rc.Props = append(rc.Props, renderProp{Class: "p-note", Value: note})
+	}
+	if uid := card.Value(vcard.FieldUID); uid != "" {
+		rc.Props = append(rc.Props, renderProp{Class: "u-uid", Data: uid})
+	}
+
+	for _, address := range card.Addresses() {
+		components := []string{
+			address.PostOfficeBox,
+			address.ExtendedAddress,
+			address.StreetAddress,
+			address.Locality,
+			address.Region,
+			address.PostalCode,
+			address.Country,
+		}
+		var props []renderProp
+		for i, v := range components {
+			if v != "" {
+				props = append(props, renderProp{Class: addressProps[i], Value: v})
+			}
+		}
+		rc.Addresses = append(rc.Addresses, props)
+	}
+
+	return cardTemplate.Execute(w, &rc)
+}
+
+// safeURL marks u as safe for use in an URL attribute if it's a relative URL,
+// uses a well-known scheme or is an inline image.
+func safeURL(u string) template.URL {
+	if strings.HasPrefix(strings.ToLower(u), "data:image/") {
+		return template.URL(u)
+	}
+	return template.URL(sanitizeURL(u))
+}
+
+var safeSchemes = []string{"http:", "https:", "mailto:", "tel:", "xmpp:", "sip:", "urn:", "geo:", "skype:"}
+
+func sanitizeURL(u string) string {
+	i := strings.IndexAny(u, ":/?#")
+	if i < 0 || u[i] != ':' {
+		return u // relative URL
+	}
+	scheme := strings.ToLower(u[:i+1])
+	for _, s := range safeSchemes {
+		if scheme == s {
+			return u
+		}
+	}
+	return "#ZgotmplZ"
+}
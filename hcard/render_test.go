@@ -0,0 +1,69 @@
+package hcard
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/emersion/go-vcard"
+)
+
+func TestRender(t *testing.T) {
+	card := vcard.Card{
+		vcard.FieldVersion:       {{Value: "4.0"}},
+		vcard.FieldFormattedName: {{Value: "Joe Bloggs"}},
+		vcard.FieldName:          {{Value: "Bloggs;Joe;;;"}},
+		vcard.FieldEmail:         {{Value: "me@joebloggs.com"}},
+		vcard.FieldURL:           {{Value: "javascript:alert(1)"}},
+		vcard.FieldNote:          {{Value: "<b>Hi</b>"}},
+		vcard.FieldAddress:       {{Value: ";;1 Trafalgar Square;London;;WC2N;United Kingdom"}},
+	}
+
+	var sb strings.Builder
+	if err := Render(&sb, card); err != nil {
+		t.Fatal("Expected no error when rendering card, got:", err)
+	}
+	s := sb.String()
+
+	for _, want := range []string{
+		`<div class="h-card">`,
+		`<span class="p-name"><span class="p-given-name">Joe</span> <span class="p-family-name">Bloggs</span></span>`,
+		`<a class="u-email" href="mailto:me@joebloggs.com">me@joebloggs.com</a>`,
+		`<a class="u-url" href="#ZgotmplZ">javascript:alert(1)</a>`,
+		`<span class="p-note">&lt;b&gt;Hi&lt;/b&gt;</span>`,
+		`<span class="p-locality">London</span>`,
+	} {
+		if !strings.Contains(s, want) {
+			t.Errorf("Expected rendered card to contain %q, got:\n%v", want, s)
+		}
+	}
+}
+
+func TestRender_roundTrip(t *testing.T) {
+	card := vcard.Card{
+		vcard.FieldVersion:       {{Value: "4.0"}},
+		vcard.FieldFormattedName: {{Value: "Joe Bloggs"}},
+		vcard.FieldName:          {{Value: "Bloggs;Joe;;;"}},
+		vcard.FieldEmail:         {{Value: "me@joebloggs.com"}},
+		vcard.FieldTelephone:     {{Value: "+44 20 1234 5678"}},
+		vcard.FieldAddress:       {{Value: ";;1 Trafalgar Square;London;;WC2N;United Kingdom"}},
+		vcard.FieldBirthday:      {{Value: "19850412"}},
+	}
+
+	var sb strings.Builder
+	if err := Render(&sb, card); err != nil {
+		t.Fatal("Expected no error when rendering card, got:", err)
+	}
+
+	cards, err := Parse(strings.NewReader(sb.String()))
+	if err != nil {
+		t.Fatal("Expected no error when parsing card, got:", err)
+	}
+	if len(cards) != 1 {
+		t.Fatalf("Expected a single card, got %v", len(cards))
+	}
+	for k := range card {
+		if got, want := cards[0].Value(k), card.Value(k); got != want {
+			t.Errorf("Expected %v to be %q, got %q", k, want, got)
+		}
+	}
+}
@@ -0,0 +1,1070 @@
+package jscontact
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/emersion/go-vcard"
+)
+
+const (
+	timestampLayout = "20060102T150405Z"
+	fieldLabel      = "X-ABLABEL"
+)
+
+var nameComponentKinds = []string{
+	NameSurname,
+	NameGiven,
+	NameGiven2,
+	NameTitle,
+	NameCredential,
+	NameSurname2,
+	NameGeneration,
+}
+
+// Address component kinds, in the order of the vCard ADR components. Empty
+// kinds are special-cased.
+var addressComponentKinds = []string{
+	AddressPostOfficeBox,
+	"", // extended address
+	"", // street address
+	AddressLocality,
+	AddressRegion,
+	AddressPostcode,
+	AddressCountry,
+	AddressRoom,
+	AddressApartment,
+	AddressFloor,
+	AddressNumber,
+	AddressName,
+	AddressBuilding,
+	AddressBlock,
+	AddressSubdistrict,
+	AddressDistrict,
+	AddressLandmark,
+	AddressDirection,
+}
+
+var phoneFeatures = map[string]string{
+	vcard.TypeVoice:     PhoneVoice,
+	vcard.TypeFax:       PhoneFax,
+	vcard.TypeCell:      PhoneMobile,
+	vcard.TypeVideo:     PhoneVideo,
+	vcard.TypePager:     PhonePager,
+	vcard.TypeText:      PhoneText,
+	vcard.TypeTextPhone: PhoneTextPhone,
+	"main-number":       PhoneMainNum,
+}
+
+var expertiseLevels = map[vcard.Level]string{
+	vcard.LevelBeginner: LevelLow,
+	vcard.LevelAverage:  LevelMedium,
+	vcard.LevelExpert:   LevelHigh,
+}
+
+// FromVCard converts a vCard to a JSContact card, following the rules defined
+// in RFC 9555 section 2. vCard properties without a JSContact equivalent are
+// stored in VCardProps. Parameters without a JSContact equivalent are
+// discarded.
+func FromVCard(card vcard.Card) (*Card, error) {
+	c := &Card{Type: "Card", Version: Version}
+
+	keys := make([]string, 0, len(card))
+	for k := range card {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	labels := make(map[string]string)
+	for _, f := range card[fieldLabel] {
+		if f.Group != "" {
+			labels[strings.ToLower(f.Group)] = f.Value
+		}
+	}
+	usedLabels := make(map[string]bool)
+	label := func(f *vcard.Field) string {
+		if f.Group == "" {
+			return ""
+		}
+		group := strings.ToLower(f.Group)
+		l, ok := labels[group]
+		if ok {
+			usedLabels[group] = true
+		}
+		return l
+	}
+
+	for _, k := range keys {
+		if strings.EqualFold(k, fieldLabel) {
+			continue
+		}
+		for i, f := range card[k] {
+			if !c.addField(strings.ToUpper(k), f, i, label) {
+				c.VCardProps = append(c.VCardProps, newVCardProp(k, f))
+			}
+		}
+	}
+
+	for _, f := range card[fieldLabel] {
+		if !usedLabels[strings.ToLower(f.Group)] {
+			c.VCardProps = append(c.VCardProps, newVCardProp(fieldLabel, f))
+		}
+	}
+
+	if c.UID == "" {
+		// RFC 9555 section 2.1.9 requires a UID
+		uid, err := vcard.NewUIDv4()
+		if err != nil {
+			return nil, err
+		}
+		c.UID = uid
+	}
+
+	return c, nil
+}
+
+// addField converts a vCard field. It returns false if the field has no
+// JSContact equivalent.
+func (c *Card) addField(k string, f *vcard.Field, i int, label func(*vcard.Field) string) bool {
+	id := f.Params.Get(vcard.ParamPropID)
+	if id == "" {
+		id = strconv.Itoa(i + 1)
+	}
+
+	switch k {
+	case vcard.FieldVersion:
+		return true
+	case vcard.FieldUID:
+		c.UID = f.Value
+	case vcard.FieldKind:
+		c.Kind = strings.ToLower(f.Value)
+	case vcard.FieldProductID:
+		c.ProdID = f.Value
+	case vcard.FieldDefaultLanguage:
+		c.Language = f.Value
+	case vcard.FieldRevision, vcard.FieldCreated:
+		t, err := time.Parse(timestampLayout, f.Value)
+		if err != nil {
+			return false
+		}
+		if k == vcard.FieldRevision {
+			c.Updated = &t
+		} else {
+			c.Created = &t
+		}
+	case vcard.FieldFormattedName:
+		if i > 0 {
+			return false
+		}
+		c.name().Full = f.Value
+	case vcard.FieldName:
+		if i > 0 {
+			return false
+		}
+		n := c.name()
+		for j, v := range strings.Split(f.Value, ";") {
+			if j >= len(nameComponentKinds) {
+				break
+			}
+			for _, vv := range splitList(v) {
+				n.Components = append(n.Components, &NameComponent{Kind: nameComponentKinds[j], Value: vv})
+			}
+		}
+		if sortAs := f.Params[vcard.ParamSortAs]; len(sortAs) > 0 {
+			n.SortAs = map[string]string{NameSurname: sortAs[0]}
+			if len(sortAs) > 1 {
+				n.SortAs[NameGiven] = sortAs[1]
+			}
+		}
+	case vcard.FieldNickname:
+		if c.Nicknames == nil {
+			c.Nicknames = make(map[string]*Nickname)
+		}
+		for j, v := range splitList(f.Value) {
+			nid := id
+			if j > 0 {
+				nid += "-" + strconv.Itoa(j+1)
+			}
+			c.Nicknames[nid] = &Nickname{Name: v, Contexts: contexts(f), Pref: pref(f)}
+		}
+	case vcard.FieldOrganization:
+		if c.Organizations == nil {
+			c.Organizations = make(map[string]*Organization)
+		}
+		components := strings.Split(f.Value, ";")
+		org := &Organization{
+			Name:     components[0],
+			SortAs:   f.Params.Get(vcard.ParamSortAs),
+			Contexts: contexts(f),
+		}
+		for _, unit := range components[1:] {
+			org.Units = append(org.Units, &OrgUnit{Name: unit})
+		}
+		c.Organizations[id] = org
+	case vcard.FieldTitle, vcard.FieldRole:
+		if c.Titles == nil {
+			c.Titles = make(map[string]*Title)
+		}
+		kind := TitleTitle
+		if k == vcard.FieldRole {
+			kind = TitleRole
+			id = "r" + id
+		}
+		c.Titles[id] = &Title{Kind: kind, Name: f.Value}
+	case vcard.FieldGrammaticalGender:
+		if i > 0 {
+			return false
+		}
+		c.speakToAs().GrammaticalGender = strings.ToLower(f.Value)
+	case vcard.FieldPronouns:
+		s := c.speakToAs()
+		if s.Pronouns == nil {
+			s.Pronouns = make(map[string]*Pronouns)
+		}
+		s.Pronouns[id] = &Pronouns{Pronouns: f.Value, Contexts: contexts(f), Pref: pref(f)}
+	case vcard.FieldEmail:
+		if c.Emails == nil {
+			c.Emails = make(map[string]*EmailAddress)
+		}
+		c.Emails[id] = &EmailAddress{
+			Address:  f.Value,
+			Contexts: contexts(f),
+			Pref:     pref(f),
+			Label:    label(f),
+		}
+	case vcard.FieldIMPP, vcard.FieldSocialProfile:
+		if c.OnlineServices == nil {
+			c.OnlineServices = make(map[string]*OnlineService)
+		}
+		s := &OnlineService{
+			Service:  f.Params.Get(vcard.ParamServiceType),
+			User:     f.Params.Get(vcard.ParamUsername),
+			Contexts: contexts(f),
+			Pref:     pref(f),
+			Label:    label(f),
+		}
+		if s.Service == "" {
+			s.Service = f.Params.Get("X-SERVICE-TYPE")
+		}
+		if strings.EqualFold(f.Params.Get(vcard.ParamValue), "text") {
+			s.User = f.Value
+		} else {
+			s.URI = f.Value
+		}
+		if k == vcard.FieldIMPP {
+			s.VCardName = "impp"
+			id = "i" + id
+		}
+		c.OnlineServices[id] = s
+	case vcard.FieldTelephone:
+		if c.Phones == nil {
+			c.Phones = make(map[string]*Phone)
+		}
+		p := &Phone{
+			Number:   f.Value,
+			Contexts: contexts(f),
+			Pref:     pref(f),
+			Label:    label(f),
+		}
+		for _, t := range f.Params.Types() {
+			if feature, ok := phoneFeatures[t]; ok {
+				if p.Features == nil {
+					p.Features = make(map[string]bool)
+				}
+				p.Features[feature] = true
+			}
+		}
+		c.Phones[id] = p
+	case vcard.FieldLanguage:
+		if c.PreferredLanguages == nil {
+			c.PreferredLanguages = make(map[string]*LanguagePref)
+		}
+		c.PreferredLanguages[id] = &LanguagePref{Language: f.Value, Contexts: contexts(f), Pref: pref(f)}
+	case vcard.FieldCalendarURI, vcard.FieldFreeOrBusyURL:
+		kind := CalendarCalendar
+		if k == vcard.FieldFreeOrBusyURL {
+			kind = CalendarFreeBusy
+			id = "f" + id
+		}
+		c.Calendars = addResource(c.Calendars, id, kind, f, label)
+	case vcard.FieldCalendarAddressURI:
+		c.SchedulingAddresses = addResource(c.SchedulingAddresses, id, "", f, label)
+	case vcard.FieldKey:
+		c.CryptoKeys = addResource(c.CryptoKeys, id, "", f, label)
+	case vcard.FieldSource, vcard.FieldOrgDirectory:
+		kind := DirectoryEntry
+		if k == vcard.FieldOrgDirectory {
+			kind = DirectoryDirectory
+			id = "d" + id
+		}
+		c.Directories = addResource(c.Directories, id, kind, f, label)
+		if index, err := strconv.Atoi(f.Params.Get(vcard.ParamIndex)); err == nil {
+			c.Directories[id].ListAs = index
+		}
+	case vcard.FieldURL:
+		c.Links = addResource(c.Links, id, "", f, label)
+	case vcard.FieldPhoto, vcard.FieldLogo, vcard.FieldSound:
+		kind := strings.ToLower(k)
+		if k == vcard.FieldLogo {
+			kind = MediaLogo
+		}
+		c.Media = addResource(c.Media, kind[:1]+id, kind, f, label)
+	case vcard.FieldAddress:
+		if c.Addresses == nil {
+			c.Addresses = make(map[string]*Address)
+		}
+		c.Addresses[id] = newAddress(f, label(f))
+	case vcard.FieldBirthday, vcard.FieldAnniversary, vcard.FieldDeathDate:
+		if i > 0 {
+			return false
+		}
+		date, ok := parseDate(f.Value)
+		if !ok || strings.EqualFold(f.Params.Get(vcard.ParamValue), "text") {
+			return false
+		}
+		kind := map[string]string{
+			vcard.FieldBirthday:    AnniversaryBirth,
+			vcard.FieldAnniversary: AnniversaryWedding,
+			vcard.FieldDeathDate:   AnniversaryDeath,
+		}[k]
+		if c.Anniversaries == nil {
+			c.Anniversaries = make(map[string]*Anniversary)
+		}
+		c.Anniversaries[kind] = &Anniversary{Kind: kind, Date: date}
+	case vcard.FieldBirthPlace, vcard.FieldDeathPlace:
+		kind := AnniversaryBirth
+		if k == vcard.FieldDeathPlace {
+			kind = AnniversaryDeath
+		}
+		a := c.Anniversaries[kind]
+		if i > 0 || a == nil {
+			return false
+		}
+		if strings.EqualFold(f.Params.Get(vcard.ParamValue), "uri") {
+			if !strings.HasPrefix(strings.ToLower(f.Value), "geo:") {
+				return false
+			}
+			a.Place = &Address{Coordinates: f.Value}
+		} else {
+			a.Place = &Address{Full: f.Value}
+		}
+	case vcard.FieldCategories:
+		if c.Keywords == nil {
+			c.Keywords = make(map[string]bool)
+		}
+		for _, v := range splitList(f.Value) {
+			c.Keywords[v] = true
+		}
+	case vcard.FieldNote:
+		if c.Notes == nil {
+			c.Notes = make(map[string]*Note)
+		}
+		n := &Note{Note: f.Value}
+		if created, err := time.Parse(timestampLayout, f.Params.Get(vcard.ParamCreated)); err == nil {
+			n.Created = &created
+		}
+		if name, uri := f.Params.Get(vcard.ParamAuthorName), f.Params.Get(vcard.ParamAuthor); name != "" || uri != "" {
+			n.Author = &Author{Name: name, URI: uri}
+		}
+		c.Notes[id] = n
+	case vcard.FieldExpertise, vcard.FieldHobby, vcard.FieldInterest:
+		if c.PersonalInfo == nil {
+			c.PersonalInfo = make(map[string]*PersonalInfo)
+		}
+		kind := strings.ToLower(k)
+		level := strings.ToLower(f.Params.Get(vcard.ParamLevel))
+		if k == vcard.FieldExpertise {
+			level = expertiseLevels[vcard.Level(level)]
+		}
+		info := &PersonalInfo{Kind: kind, Value: f.Value, Level: level, Label: label(f)}
+		if index, err := strconv.Atoi(f.Params.Get(vcard.ParamIndex)); err == nil {
+			info.ListAs = index
+		}
+		c.PersonalInfo[kind[:1]+id] = info
+	case vcard.FieldMember:
+		if c.Members == nil {
+			c.Members = make(map[string]bool)
+		}
+		c.Members[f.Value] = true
+	case vcard.FieldRelated:
+		if c.RelatedTo == nil {
+			c.RelatedTo = make(map[string]*Relation)
+		}
+		rel := &Relation{}
+		for _, t := range f.Params.Types() {
+			if rel.Relation == nil {
+				rel.Relation = make(map[string]bool)
+			}
+			rel.Relation[t] = true
+		}
+		c.RelatedTo[f.Value] = rel
+	default:
+		return false
+	}
+	return true
+}
+
+func (c *Card) name() *Name {
+	if c.Name == nil {
+		c.Name = new(Name)
+	}
+	return c.Name
+}
+
+func (c *Card) speakToAs() *SpeakToAs {
+	if c.SpeakToAs == nil {
+		c.SpeakToAs = new(SpeakToAs)
+	}
+	return c.SpeakToAs
+}
+
+func addResource(m map[string]*Resource, id, kind string, f *vcard.Field, label func(*vcard.Field) string) map[string]*Resource {
+	if m == nil {
+		m = make(map[string]*Resource)
+	}
+	m[id] = &Resource{
+		Kind:      kind,
+		URI:       f.Value,
+		MediaType: f.Params.Get(vcard.ParamMediaType),
+		Contexts:  contexts(f),
+		Pref:      pref(f),
+		Label:     label(f),
+	}
+	return m
+}
+
+func newAddress(f *vcard.Field, label string) *Address {
+	a := &Address{
+		CountryCode: f.Params.Get("CC"),
+		Coordinates: f.Params.Get(vcard.ParamGeolocation),
+		TimeZone:    f.Params.Get(vcard.ParamTimezone),
+		Contexts:    contexts(f),
+		Full:        f.Params.Get(vcard.ParamLabel),
+		Pref:        pref(f),
+		Label:       label,
+	}
+
+	components := strings.Split(f.Value, ";")
+	extended := len(components) > 7
+	for i, v := range components {
+		if i >= len(addressComponentKinds) {
+			break
+		}
+		kind := addressComponentKinds[i]
+		switch i {
+		case 1:
+			// The extended and street address components are deprecated
+			// by RFC 9554, only use them if the new ones are missing
+			if extended && maybeGet(components, 8) != "" {
+				continue
+			}
+			kind = AddressApartment
+		case 2:
+			if extended && maybeGet(components, 11) != "" {
+				continue
+			}
+			kind = AddressName
+		}
+		for _, vv := range splitList(v) {
+			a.Components = append(a.Components, &AddressComponent{Kind: kind, Value: vv})
+		}
+	}
+	return a
+}
+
+func newVCardProp(k string, f *vcard.Field) *VCardProp {
+	p := &VCardProp{
+		Name:   strings.ToLower(k),
+		Params: make(map[string][]string, len(f.Params)),
+		Type:   "unknown",
+		Value:  f.Value,
+	}
+	for pk, pv := range f.Params {
+		if strings.EqualFold(pk, vcard.ParamValue) {
+			continue
+		}
+		p.Params[strings.ToLower(pk)] = pv
+	}
+	if f.Group != "" {
+		p.Params["group"] = []string{f.Group}
+	}
+
+	info := vcard.LookupProperty(k)
+	if v := f.Params.Get(vcard.ParamValue); v != "" {
+		p.Type = strings.ToLower(v)
+	} else if info != nil {
+		p.Type = string(info.DefaultValueType())
+	}
+	if info != nil && info.Structured {
+		components := strings.Split(f.Value, ";")
+		values := make([]interface{}, len(components))
+		for i, c := range components {
+			values[i] = c
+		}
+		p.Value = values
+	}
+	return p
+}
+
+// ToVCard converts a JSContact card to a vCard 4.0, following the rules
+// defined in RFC 9555 section 2.
+func ToVCard(c *Card) (vcard.Card, error) {
+	if c.Type != "" && c.Type != "Card" {
+		return nil, fmt.Errorf("jscontact: invalid @type %q", c.Type)
+	}
+
+	card := make(vcard.Card)
+	card.SetValue(vcard.FieldVersion, "4.0")
+
+	groups := 0
+	addLabel := func(f *vcard.Field, label string) *vcard.Field {
+		if label != "" {
+			groups++
+			f.Group = "item" + strconv.Itoa(groups)
+			card.Add(fieldLabel, &vcard.Field{Value: label, Group: f.Group})
+		}
+		return f
+	}
+
+	if c.UID != "" {
+		card.SetValue(vcard.FieldUID, c.UID)
+	}
+	if c.Kind != "" {
+		card.SetValue(vcard.FieldKind, c.Kind)
+	}
+	if c.ProdID != "" {
+		card.SetValue(vcard.FieldProductID, c.ProdID)
+	}
+	if c.Language != "" {
+		card.SetValue(vcard.FieldDefaultLanguage, c.Language)
+	}
+	if c.Updated != nil {
+		card.SetRevision(c.Updated.UTC())
+	}
+	if c.Created != nil {
+		card.SetCreated(c.Created.UTC())
+	}
+
+	if c.Name != nil {
+		if c.Name.Full != "" {
+			card.SetValue(vcard.FieldFormattedName, c.Name.Full)
+		}
+		if len(c.Name.Components) > 0 {
+			values := make(map[string][]string)
+			for _, comp := range c.Name.Components {
+				values[comp.Kind] = append(values[comp.Kind], comp.Value)
+			}
+			components := make([]string, len(nameComponentKinds))
+			for i, kind := range nameComponentKinds {
+				components[i] = strings.Join(values[kind], ",")
+			}
+			if components[5] == "" && components[6] == "" {
+				components = components[:5]
+			}
+			f := &vcard.Field{Value: strings.Join(components, ";")}
+			if surname, ok := c.Name.SortAs[NameSurname]; ok {
+				f.Params = vcard.Params{vcard.ParamSortAs: {surname}}
+				if given, ok := c.Name.SortAs[NameGiven]; ok {
+					f.Params.Add(vcard.ParamSortAs, given)
+				}
+			}
+			card.Set(vcard.FieldName, f)
+		}
+	}
+
+	for _, id := range sortedKeys(c.Nicknames) {
+		n := c.Nicknames[id]
+		card.Add(vcard.FieldNickname, newField(n.Name, n.Contexts, n.Pref))
+	}
+	for _, id := range sortedKeys(c.Organizations) {
+		org := c.Organizations[id]
+		components := []string{org.Name}
+		for _, unit := range org.Units {
+			components = append(components, unit.Name)
+		}
+		f := newField(strings.Join(components, ";"), org.Contexts, 0)
+		if org.SortAs != "" {
+			f.Params.Set(vcard.ParamSortAs, org.SortAs)
+		}
+		card.Add(vcard.FieldOrganization, f)
+	}
+	for _, id := range sortedKeys(c.Titles) {
+		t := c.Titles[id]
+		k := vcard.FieldTitle
+		if t.Kind == TitleRole {
+			k = vcard.FieldRole
+		}
+		card.AddValue(k, t.Name)
+	}
+	if s := c.SpeakToAs; s != nil {
+		if s.GrammaticalGender != "" {
+			card.SetValue(vcard.FieldGrammaticalGender, s.GrammaticalGender)
+		}
+		for _, id := range sortedKeys(s.Pronouns) {
+			p := s.Pronouns[id]
+			card.Add(vcard.FieldPronouns, newField(p.Pronouns, p.Contexts, p.Pref))
+		}
+	}
+
+	for _, id := range sortedKeys(c.Emails) {
+		e := c.Emails[id]
+		card.Add(vcard.FieldEmail, addLabel(newField(e.Address, e.Contexts, e.Pref), e.Label))
+	}
+	for _, id := range sortedKeys(c.OnlineServices) {
+		s := c.OnlineServices[id]
+		k := vcard.FieldSocialProfile
+		if strings.EqualFold(s.VCardName, "impp") {
+			k = vcard.FieldIMPP
+		}
+		f := newField(s.URI, s.Contexts, s.Pref)
+		if s.URI == "" {
+			f.Value = s.User
+			f.Params.Set(vcard.ParamValue, "text")
+		} else if s.User != "" {
+			f.Params.Set(vcard.ParamUsername, s.User)
+		}
+		if s.Service != "" {
+			f.Params.Set(vcard.ParamServiceType, s.Service)
+		}
+		card.Add(k, addLabel(f, s.Label))
+	}
+	for _, id := range sortedKeys(c.Phones) {
+		p := c.Phones[id]
+		f := newField(p.Number, p.Contexts, p.Pref)
+		for _, t := range sortedKeys(p.Features) {
+			for vt, feature := range phoneFeatures {
+				if feature == t && p.Features[t] {
+					f.Params.Add(vcard.ParamType, vt)
+				}
+			}
+		}
+		card.Add(vcard.FieldTelephone, addLabel(f, p.Label))
+	}
+	for _, id := range sortedKeys(c.PreferredLanguages) {
+		l := c.PreferredLanguages[id]
+		card.Add(vcard.FieldLanguage, newField(l.Language, l.Contexts, l.Pref))
+	}
+	for _, id := range sortedKeys(c.Calendars) {
+		r := c.Calendars[id]
+		k := vcard.FieldCalendarURI
+		if r.Kind == CalendarFreeBusy {
+			k = vcard.FieldFreeOrBusyURL
+		}
+		card.Add(k, addLabel(resourceField(r), r.Label))
+	}
+	for _, id := range sortedKeys(c.SchedulingAddresses) {
+		r := c.SchedulingAddresses[id]
+		card.Add(vcard.FieldCalendarAddressURI, addLabel(resourceField(r), r.Label))
+	}
+
+	for _, id := range sortedKeys(c.Addresses) {
+		a := c.Addresses[id]
+		card.Add(vcard.FieldAddress, addLabel(addressField(a), a.Label))
+	}
+
+	for _, id := range sortedKeys(c.CryptoKeys) {
+		r := c.CryptoKeys[id]
+		card.Add(vcard.FieldKey, addLabel(resourceField(r), r.Label))
+	}
+	for _, id := range sortedKeys(c.Directories) {
+		r := c.Directories[id]
+		k := vcard.FieldSource
+		f := resourceField(r)
+		if r.Kind == DirectoryDirectory {
+			k = vcard.FieldOrgDirectory
+		}
+		if r.ListAs > 0 {
+			f.Params.Set(vcard.ParamIndex, strconv.Itoa(r.ListAs))
+		}
+		card.Add(k, addLabel(f, r.Label))
+	}
+	for _, id := range sortedKeys(c.Links) {
+		r := c.Links[id]
+		card.Add(vcard.FieldURL, addLabel(resourceField(r), r.Label))
+	}
+	for _, id := range sortedKeys(c.Media) {
+		r := c.Media[id]
+		var k string
+		switch r.Kind {
+		case MediaLogo:
+			k = vcard.FieldLogo
+		case MediaSound:
+			k = vcard.FieldSound
+		default:
+			k = vcard.FieldPhoto
+		}
+		card.Add(k, addLabel(resourceField(r), r.Label))
+	}
+
+	for _, id := range sortedKeys(c.Anniversaries) {
+		a := c.Anniversaries[id]
+		var k, placeKey string
+		switch a.Kind {
+		case AnniversaryBirth:
+			k, placeKey = vcard.FieldBirthday, vcard.FieldBirthPlace
+		case AnniversaryDeath:
+			k, placeKey = vcard.FieldDeathDate, vcard.FieldDeathPlace
+		case AnniversaryWedding:
+			k = vcard.FieldAnniversary
+		default:
+			continue
+		}
+		if a.Date != nil {
+			card.Set(k, dateField(a.Date))
+		}
+		if a.Place != nil && placeKey != "" {
+			if a.Place.Full != "" {
+				card.SetValue(placeKey, a.Place.Full)
+			} else if a.Place.Coordinates != "" {
+				card.Set(placeKey, &vcard.Field{
+					Value:  a.Place.Coordinates,
+					Params: vcard.Params{vcard.ParamValue: {"uri"}},
+				})
+			}
+		}
+	}
+	if len(c.Keywords) > 0 {
+		var keywords []string
+		for _, k := range sortedKeys(c.Keywords) {
+			if c.Keywords[k] {
+				keywords = append(keywords, k)
+			}
+		}
+		card.SetCategories(keywords)
+	}
+	for _, id := range sortedKeys(c.Notes) {
+		n := c.Notes[id]
+		f := &vcard.Field{Value: n.Note, Params: make(vcard.Params)}
+		if n.Created != nil {
+			f.Params.Set(vcard.ParamCreated, n.Created.UTC().Format(timestampLayout))
+		}
+		if n.Author != nil {
+			if n.Author.Name != "" {
+				f.Params.Set(vcard.ParamAuthorName, n.Author.Name)
+			}
+			if n.Author.URI != "" {
+				f.Params.Set(vcard.ParamAuthor, n.Author.URI)
+			}
+		}
+		card.Add(vcard.FieldNote, f)
+	}
+	for _, id := range sortedKeys(c.PersonalInfo) {
+		info := c.PersonalInfo[id]
+		var k string
+		level := info.Level
+		switch info.Kind {
+		case PersonalInfoExpertise:
+			k = vcard.FieldExpertise
+			for l, ll := range expertiseLevels {
+				if ll == info.Level {
+					level = string(l)
+				}
+			}
+		case PersonalInfoHobby:
+			k = vcard.FieldHobby
+		case PersonalInfoInterest:
+			k = vcard.FieldInterest
+		default:
+			continue
+		}
+		f := &vcard.Field{Value: info.Value, Params: make(vcard.Params)}
+		if level != "" {
+			f.Params.Set(vcard.ParamLevel, level)
+		}
+		if info.ListAs > 0 {
+			f.Params.Set(vcard.ParamIndex, strconv.Itoa(info.ListAs))
+		}
+		card.Add(k, addLabel(f, info.Label))
+	}
+
+	for _, uid := range sortedKeys(c.Members) {
+		if c.Members[uid] {
+			card.AddValue(vcard.FieldMember, uid)
+		}
+	}
+	for _, uri := range sortedKeys(c.RelatedTo) {
+		f := &vcard.Field{Value: uri, Params: make(vcard.Params)}
+		for _, t := range sortedKeys(c.RelatedTo[uri].Relation) {
+			f.Params.Add(vcard.ParamType, t)
+		}
+		if !strings.Contains(uri, ":") {
+			f.Params.Set(vcard.ParamValue, "text")
+		}
+		card.Add(vcard.FieldRelated, f)
+	}
+
+	for _, p := range c.VCardProps {
+		k, f := p.field()
+		if strings.EqualFold(k, vcard.FieldVersion) {
+			continue
+		}
+		card.Add(k, f)
+	}
+
+	for _, fields := range card {
+		for _, f := range fields {
+			if len(f.Params) == 0 {
+				f.Params = nil
+			}
+		}
+	}
+
+	return card, nil
+}
+
+func (p *VCardProp) field() (string, *vcard.Field) {
+	k := strings.ToUpper(p.Name)
+	f := &vcard.Field{Value: formatJCardValue(p.Value, ";")}
+	for pk, pv := range p.Params {
+		if pk == "group" {
+			if len(pv) > 0 {
+				f.Group = pv[0]
+			}
+			continue
+		}
+		if f.Params == nil {
+			f.Params = make(vcard.Params)
+		}
+		f.Params[strings.ToUpper(pk)] = pv
+	}
+
+	info := vcard.LookupProperty(k)
+	t := vcard.ValueType(p.Type)
+	if p.Type != "" && p.Type != "unknown" && (info == nil || t != info.DefaultValueType()) {
+		if f.Params == nil {
+			f.Params = make(vcard.Params)
+		}
+		f.Params.Set(vcard.ParamValue, p.Type)
+	}
+	return k, f
+}
+
+func formatJCardValue(v interface{}, sep string) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case []interface{}:
+		l := make([]string, len(v))
+		for i, vv := range v {
+			l[i] = formatJCardValue(vv, ",")
+		}
+		return strings.Join(l, sep)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+func newField(v string, ctx map[string]bool, pref int) *vcard.Field {
+	f := &vcard.Field{Value: v, Params: make(vcard.Params)}
+	if ctx[ContextPrivate] {
+		f.Params.Add(vcard.ParamType, vcard.TypeHome)
+	}
+	if ctx[ContextWork] {
+		f.Params.Add(vcard.ParamType, vcard.TypeWork)
+	}
+	if pref > 0 {
+		f.Params.Set(vcard.ParamPreferred, strconv.Itoa(pref))
+	}
+	return f
+}
+
+func resourceField(r *Resource) *vcard.Field {
+	f := newField(r.URI, r.Contexts, r.Pref)
+	if r.MediaType != "" {
+		f.Params.Set(vcard.ParamMediaType, r.MediaType)
+	}
+	return f
+}
+
+func addressField(a *Address) *vcard.Field {
+	values := make(map[string][]string)
+	extended := false
+	for _, comp := range a.Components {
+		values[comp.Kind] = append(values[comp.Kind], comp.Value)
+		switch comp.Kind {
+		case AddressApartment, AddressName, AddressSeparator, AddressPostOfficeBox,
+			AddressLocality, AddressRegion, AddressPostcode, AddressCountry:
+		default:
+			extended = true
+		}
+	}
+
+	// The deprecated extended and street address components are always
+	// populated, for compatibility with RFC 6350 implementations
+	components := make([]string, len(addressComponentKinds))
+	for i, kind := range addressComponentKinds {
+		switch i {
+		case 1:
+			kind = AddressApartment
+		case 2:
+			kind = AddressName
+		}
+		components[i] = strings.Join(values[kind], ",")
+	}
+	if !extended {
+		components = components[:7]
+	}
+
+	f := newField(strings.Join(components, ";"), a.Contexts, a.Pref)
+	if a.Full != "" {
+		f.Params.Set(vcard.ParamLabel, a.Full)
+	}
+	if a.Coordinates != "" {
+		f.Params.Set(vcard.ParamGeolocation, a.Coordinates)
+	}
+	if a.TimeZone != "" {
+		f.Params.Set(vcard.ParamTimezone, a.TimeZone)
+	}
+	if a.CountryCode != "" {
+		f.Params.Set("CC", a.CountryCode)
+	}
+	return f
+}
+
+func contexts(f *vcard.Field) map[string]bool {
+	var ctx map[string]bool
+	for _, t := range f.Params.Types() {
+		var c string
+		switch t {
+		case vcard.TypeHome:
+			c = ContextPrivate
+		case vcard.TypeWork:
+			c = ContextWork
+		default:
+			continue
+		}
+		if ctx == nil {
+			ctx = make(map[string]bool)
+		}
+		ctx[c] = true
+	}
+	return ctx
+}
+
+func pref(f *vcard.Field) int {
+	if v := f.Params.Get(vcard.ParamPreferred); v != "" {
+		n, _ := strconv.Atoi(v)
+		return n
+	}
+	if f.Params.HasType("pref") {
+		return 1
+	}
+	return 0
+}
+
+func maybeGet(l []string, i int) string {
+	if i < len(l) {
+		return l[i]
+	}
+	return ""
+}
+
+func splitList(v string) []string {
+	if v == "" {
+		return nil
+	}
+	return strings.Split(v, ",")
+}
+
+// parseDate parses a vCard date or timestamp. Dates may be truncated, e.g.
+// "--0412" or "1985".
+func parseDate(s string) (*Date, bool) {
+	if t, err := time.Parse(timestampLayout, s); err == nil {
+		return &Date{UTC: t}, true
+	}
+
+	var year, month, day string
+	switch {
+	case strings.HasPrefix(s, "---"): // ---DD
+		day = s[3:]
+	case strings.HasPrefix(s, "--"): // --MM or --MMDD
+		s = s[2:]
+		if len(s) != 2 && len(s) != 4 {
+			return nil, false
+		}
+		month = s[:2]
+		day = s[2:]
+	case len(s) == 7 && s[4] == '-': // YYYY-MM
+		year, month = s[:4], s[5:]
+	default: // YYYY, YYYYMMDD or YYYY-MM-DD
+		s = strings.Replace(s, "-", "", -1)
+		switch len(s) {
+		case 8:
+			year, month, day = s[:4], s[4:6], s[6:]
+		case 4:
+			year = s
+		default:
+			return nil, false
+		}
+	}
+
+	var d Date
+	for _, p := range []struct {
+		s string
+		v *int
+	}{{year, &d.Year}, {month, &d.Month}, {day, &d.Day}} {
+		if p.s == "" {
+			continue
+		}
+		n, err := strconv.Atoi(p.s)
+		if err != nil || n <= 0 {
+			return nil, false
+		}
+		*p.v = n
+	}
+	return &d, true
+}
+
+func dateField(d *Date) *vcard.Field {
+	if !d.UTC.IsZero() {
+		return &vcard.Field{Value: d.UTC.UTC().Format(timestampLayout)}
+	}
+
+	var s string
+	switch {
+	case d.Year > 0 && d.Month > 0 && d.Day > 0:
+		s = fmt.Sprintf("%04d%02d%02d", d.Year, d.Month, d.Day)
+	case d.Year > 0 && d.Month > 0:
+		s = fmt.Sprintf("%04d-%02d", d.Year, d.Month)
+	case d.Year > 0:
+		s = fmt.Sprintf("%04d", d.Year)
+	case d.Month > 0 && d.Day > 0:
+		s = fmt.Sprintf("--%02d%02d", d.Month, d.Day)
+	case d.Month > 0:
+		s = fmt.Sprintf("--%02d", d.Month)
+	default:
+		s = fmt.Sprintf("---%02d", d.Day)
+	}
+	return &vcard.Field{Value: s}
+}
+
+// sortedKeys returns the keys of a map with string keys, sorted in natural
+// order so that "2" comes before "10".
+func sortedKeys(m interface{}) []string {
+	values := reflect.ValueOf(m).MapKeys()
+	keys := make([]string, len(values))
+	for i, v := range values {
+		keys[i] = v.String()
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return naturalLess(keys[i], keys[j])
+	})
+	return keys
+}
+
+func naturalLess(a, b string) bool {
+	ai, aerr := strconv.Atoi(strings.TrimLeft(a, "abcdefghijklmnopqrstuvwxyz"))
+	bi, berr := strconv.Atoi(strings.TrimLeft(b, "abcdefghijklmnopqrstuvwxyz"))
+	pa := strings.TrimRight(a, "0123456789-")
+	pb := strings.TrimRight(b, "0123456789-")
+	if aerr == nil && berr == nil && pa == pb && ai != bi {
+		return ai < bi
+	}
+	return a < b
+}
@@ -0,0 +1,134 @@
+package jscontact
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/emersion/go-vcard"
+)
+
+const testCardString = `BEGIN:VCARD
+VERSION:4.0
+UID:urn:uuid:4fbe8971-0bc3-424c-9c26-36c3e1eff6b1
+FN:Joe Bloggs
+N;SORT-AS=Bloggs,Joe:Bloggs;Joe;;Dr.;
+EMAIL;TYPE=home;PREF=1:me@joebloggs.com
+EMAIL;TYPE=work:joe@example.com
+TEL;TYPE=home;TYPE=cell:tel:+44-20-1234-5678
+ADR;TYPE=home;LABEL=1 Trafalgar Square\nLondon:;;1 Trafalgar Square;London;;WC2N;United Kingdom
+BDAY:--0412
+DEATHDATE:20200101
+DEATHPLACE:London
+CATEGORIES:friends,london
+ORG:Example Inc.;Marketing
+TITLE:Director
+NOTE;AUTHOR-NAME=Jane:Met at a conference
+HOBBY;LEVEL=high;INDEX=1:chess
+MEMBER:urn:uuid:03a0e51f-d1aa-4385-8a53-e29025acd8af
+REV:20220101T000000Z
+item1.URL:https://joebloggs.com
+item1.X-ABLabel:Blog
+GENDER:M
+X-FOO;X-BAR=baz:qux
+END:VCARD
+`
+
+func TestRoundTrip(t *testing.T) {
+	card, err := vcard.NewDecoder(strings.NewReader(testCardString)).Decode()
+	if err != nil {
+		t.Fatal("Expected no error when decoding card, got:", err)
+	}
+
+	c, err := FromVCard(card)
+	if err != nil {
+		t.Fatal("Expected no error when converting to JSContact, got:", err)
+	}
+
+	if c.UID != "urn:uuid:4fbe8971-0bc3-424c-9c26-36c3e1eff6b1" {
+		t.Errorf("Expected UID to be preserved, got %q", c.UID)
+	}
+	if c.Name == nil || c.Name.Full != "Joe Bloggs" || len(c.Name.Components) != 3 {
+		t.Errorf("Invalid name: %+v", c.Name)
+	}
+	if e := c.Emails["1"]; e == nil || e.Address != "me@joebloggs.com" || !e.Contexts[ContextPrivate] || e.Pref != 1 {
+		t.Errorf("Invalid first email: %+v", e)
+	}
+	if p := c.Phones["1"]; p == nil || !p.Features[PhoneMobile] {
+		t.Errorf("Invalid phone: %+v", p)
+	}
+	if a := c.Anniversaries[AnniversaryBirth]; a == nil || a.Date.Year != 0 || a.Date.Month != 4 || a.Date.Day != 12 {
+		t.Errorf("Invalid birthday: %+v", a)
+	}
+	if a := c.Anniversaries[AnniversaryDeath]; a == nil || a.Place == nil || a.Place.Full != "London" {
+		t.Errorf("Invalid death anniversary: %+v", a)
+	}
+	if l := c.Links["1"]; l == nil || l.Label != "Blog" {
+		t.Errorf("Invalid link: %+v", l)
+	}
+	if info := c.PersonalInfo["h1"]; info == nil || info.Value != "chess" || info.Level != LevelHigh || info.ListAs != 1 {
+		t.Errorf("Invalid personal info: %+v", info)
+	}
+
+	var names []string
+	for _, p := range c.VCardProps {
+		names = append(names, p.Name)
+	}
+	if expected := []string{"gender", "x-foo"}; !reflect.DeepEqual(names, expected) {
+		t.Errorf("Expected vCard props to be %v, got %v", expected, names)
+	}
+
+	back, err := ToVCard(c)
+	if err != nil {
+		t.Fatal("Expected no error when converting to vCard, got:", err)
+	}
+	if !reflect.DeepEqual(back, card) {
+		var want, got bytes.Buffer
+		vcard.NewEncoder(&want).Encode(card)
+		vcard.NewEncoder(&got).Encode(back)
+		t.Errorf("Invalid round-tripped card: expected \n%v\n but got \n%v", want.String(), got.String())
+	}
+}
+
+func TestFromVCard_missingUID(t *testing.T) {
+	card := vcard.Card{
+		vcard.FieldVersion:       {{Value: "4.0"}},
+		vcard.FieldFormattedName: {{Value: "Joe Bloggs"}},
+	}
+	c, err := FromVCard(card)
+	if err != nil {
+		t.Fatal("Expected no error when converting to JSContact, got:", err)
+	}
+	if !strings.HasPrefix(c.UID, "urn:uuid:") {
+		t.Errorf("Expected a generated UID, got %q", c.UID)
+	}
+}
+
+var testDates = []struct {
+	s    string
+	date Date
+}{
+	{"19850412", Date{Year: 1985, Month: 4, Day: 12}},
+	{"1985-04", Date{Year: 1985, Month: 4}},
+	{"1985", Date{Year: 1985}},
+	{"--0412", Date{Month: 4, Day: 12}},
+	{"--04", Date{Month: 4}},
+	{"---12", Date{Day: 12}},
+}
+
+func TestParseDate(t *testing.T) {
+	for _, test := range testDates {
+		date, ok := parseDate(test.s)
+		if !ok {
+			t.Errorf("parseDate(%q): expected no error", test.s)
+			continue
+		}
+		if *date != test.date {
+			t.Errorf("parseDate(%q): expected %+v, got %+v", test.s, test.date, *date)
+		}
+		if s := dateField(date).Value; s != test.s {
+			t.Errorf("dateField(%+v): expected %q, got %q", test.date, test.s, s)
+		}
+	}
+}
@@ -0,0 +1,437 @@
+// Package jscontact implements the JSContact format, defined in RFC 9553, and
+// its conversion from and to vCard, defined in RFC 9555.
+package jscontact
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// MIME type for JSContact, defined in RFC 9553 section 4.1.
+const MIMEType = "application/jscontact+json"
+
+// Version is the JSContact version implemented by this package.
+const Version = "1.0"
+
+// A Card contains information about a person, organization or company,
+// defined in RFC 9553 section 2.
+type Card struct {
+	Type     string     `json:"@type"`
+	Version  string     `json:"version"`
+	UID      string     `json:"uid"`
+	Created  *time.Time `json:"created,omitempty"`
+	Updated  *time.Time `json:"updated,omitempty"`
+	Kind     string     `json:"kind,omitempty"`
+	Language string     `json:"language,omitempty"`
+	ProdID   string     `json:"prodId,omitempty"`
+
+	Members   map[string]bool      `json:"members,omitempty"`
+	RelatedTo map[string]*Relation `json:"relatedTo,omitempty"`
+
+	Name          *Name                    `json:"name,omitempty"`
+	Nicknames     map[string]*Nickname     `json:"nicknames,omitempty"`
+	Organizations map[string]*Organization `json:"organizations,omitempty"`
+	SpeakToAs     *SpeakToAs               `json:"speakToAs,omitempty"`
+	Titles        map[string]*Title        `json:"titles,omitempty"`
+
+	Emails              map[string]*EmailAddress  `json:"emails,omitempty"`
+	OnlineServices      map[string]*OnlineService `json:"onlineServices,omitempty"`
+	Phones              map[string]*Phone         `json:"phones,omitempty"`
+	PreferredLanguages  map[string]*LanguagePref  `json:"preferredLanguages,omitempty"`
+	Calendars           map[string]*Resource      `json:"calendars,omitempty"`
+	SchedulingAddresses map[string]*Resource      `json:"schedulingAddresses,omitempty"`
+
+	Addresses map[string]*Address `json:"addresses,omitempty"`
+
+	CryptoKeys  map[string]*Resource `json:"cryptoKeys,omitempty"`
+	Directories map[string]*Resource `json:"directories,omitempty"`
+	Links       map[string]*Resource `json:"links,omitempty"`
+	Media       map[string]*Resource `json:"media,omitempty"`
+
+	Anniversaries map[string]*Anniversary  `json:"anniversaries,omitempty"`
+	Keywords      map[string]bool          `json:"keywords,omitempty"`
+	Notes         map[string]*Note         `json:"notes,omitempty"`
+	PersonalInfo  map[string]*PersonalInfo `json:"personalInfo,omitempty"`
+
+	// VCardProps contains the vCard properties without a JSContact
+	// equivalent, defined in RFC 9555 section 3.3.
+	VCardProps []*VCardProp `json:"vCardProps,omitempty"`
+}
+
+// Contexts in which a contact information may be used.
+const (
+	ContextPrivate = "private"
+	ContextWork    = "work"
+)
+
+// A Relation describes how a card is related to another one.
+type Relation struct {
+	Relation map[string]bool `json:"relation,omitempty"`
+}
+
+// Name is the name of the entity represented by a card.
+type Name struct {
+	Components []*NameComponent  `json:"components,omitempty"`
+	Full       string            `json:"full,omitempty"`
+	SortAs     map[string]string `json:"sortAs,omitempty"`
+}
+
+// Values for NameComponent.Kind.
+const (
+	NameTitle      = "title"
+	NameGiven      = "given"
+	NameGiven2     = "given2"
+	NameSurname    = "surname"
+	NameSurname2   = "surname2"
+	NameCredential = "credential"
+	NameGeneration = "generation"
+	NameSeparator  = "separator"
+)
+
+// A NameComponent is a part of a name.
+type NameComponent struct {
+	Kind  string `json:"kind"`
+	Value string `json:"value"`
+}
+
+// A Nickname is an informal name.
+type Nickname struct {
+	Name     string          `json:"name"`
+	Contexts map[string]bool `json:"contexts,omitempty"`
+	Pref     int             `json:"pref,omitempty"`
+}
+
+// An Organization is a company or organization the entity belongs to.
+type Organization struct {
+	Name     string          `json:"name,omitempty"`
+	Units    []*OrgUnit      `json:"units,omitempty"`
+	SortAs   string          `json:"sortAs,omitempty"`
+	Contexts map[string]bool `json:"contexts,omitempty"`
+}
+
+// An OrgUnit is a unit of an organization, e.g. a department.
+type OrgUnit struct {
+	Name string `json:"name"`
+}
+
+// SpeakToAs describes how to address the entity.
+type SpeakToAs struct {
+	GrammaticalGender string               `json:"grammaticalGender,omitempty"`
+	Pronouns          map[string]*Pronouns `json:"pronouns,omitempty"`
+}
+
+// Pronouns are the pronouns to use when referring to the entity.
+type Pronouns struct {
+	Pronouns string          `json:"pronouns"`
+	Contexts map[string]bool `json:"contexts,omitempty"`
+	Pref     int             `json:"pref,omitempty"`
+}
+
+// Values for Title.Kind.
+const (
+	TitleTitle = "title"
+	TitleRole  = "role"
+)
+
+// A Title is a job title or a functional position.
+type Title struct {
+	Kind           string `json:"kind,omitempty"`
+	Name           string `json:"name"`
+	OrganizationID string `json:"organizationId,omitempty"`
+}
+
+// An EmailAddress is an email address.
+type EmailAddress struct {
+	Address  string          `json:"address"`
+	Contexts map[string]bool `json:"contexts,omitempty"`
+	Pref     int             `json:"pref,omitempty"`
+	Label    string          `json:"label,omitempty"`
+}
+
+// An OnlineService is an online service, e.g. an instant messaging or social
+// media account.
+type OnlineService struct {
+	Service  string          `json:"service,omitempty"`
+	URI      string          `json:"uri,omitempty"`
+	User     string          `json:"user,omitempty"`
+	Contexts map[string]bool `json:"contexts,omitempty"`
+	Pref     int             `json:"pref,omitempty"`
+	Label    string          `json:"label,omitempty"`
+	// VCardName is the name of the vCard property the service has been
+	// converted from. It's empty for the SOCIALPROFILE property.
+	VCardName string `json:"vCardName,omitempty"`
+}
+
+// Values for the keys of Phone.Features.
+const (
+	PhoneMobile    = "mobile"
+	PhoneVoice     = "voice"
+	PhoneText      = "text"
+	PhoneVideo     = "video"
+	PhoneMainNum   = "main-number"
+	PhoneTextPhone = "textphone"
+	PhoneFax       = "fax"
+	PhonePager     = "pager"
+)
+
+// A Phone is a phone number.
+type Phone struct {
+	Number   string          `json:"number"`
+	Features map[string]bool `json:"features,omitempty"`
+	Contexts map[string]bool `json:"contexts,omitempty"`
+	Pref     int             `json:"pref,omitempty"`
+	Label    string          `json:"label,omitempty"`
+}
+
+// A LanguagePref is a language the entity prefers to communicate in.
+type LanguagePref struct {
+	Language string          `json:"language"`
+	Contexts map[string]bool `json:"contexts,omitempty"`
+	Pref     int             `json:"pref,omitempty"`
+}
+
+// Values for Resource.Kind.
+const (
+	CalendarCalendar = "calendar"
+	CalendarFreeBusy = "freeBusy"
+
+	DirectoryDirectory = "directory"
+	DirectoryEntry     = "entry"
+
+	LinkContact = "contact"
+
+	MediaPhoto = "photo"
+	MediaSound = "sound"
+	MediaLogo  = "logo"
+)
+
+// A Resource is a calendar, a scheduling address, a cryptographic key, a
+// directory, a link or a media, identified by its URI.
+type Resource struct {
+	Kind      string          `json:"kind,omitempty"`
+	URI       string          `json:"uri"`
+	MediaType string          `json:"mediaType,omitempty"`
+	Contexts  map[string]bool `json:"contexts,omitempty"`
+	Pref      int             `json:"pref,omitempty"`
+	Label     string          `json:"label,omitempty"`
+	ListAs    int             `json:"listAs,omitempty"` // directories only
+}
+
+// Values for AddressComponent.Kind.
+const (
+	AddressRoom          = "room"
+	AddressApartment     = "apartment"
+	AddressFloor         = "floor"
+	AddressBuilding      = "building"
+	AddressNumber        = "number"
+	AddressName          = "name"
+	AddressBlock         = "block"
+	AddressSubdistrict   = "subdistrict"
+	AddressDistrict      = "district"
+	AddressLocality      = "locality"
+	AddressRegion        = "region"
+	AddressPostcode      = "postcode"
+	AddressCountry       = "country"
+	AddressDirection     = "direction"
+	AddressLandmark      = "landmark"
+	AddressPostOfficeBox = "postOfficeBox"
+	AddressSeparator     = "separator"
+)
+
+// An Address is a postal address or a place.
+type Address struct {
+	Components  []*AddressComponent `json:"components,omitempty"`
+	CountryCode string              `json:"countryCode,omitempty"`
+	Coordinates string              `json:"coordinates,omitempty"`
+	TimeZone    string              `json:"timeZone,omitempty"`
+	Contexts    map[string]bool     `json:"contexts,omitempty"`
+	Full        string              `json:"full,omitempty"`
+	Pref        int                 `json:"pref,omitempty"`
+	Label       string              `json:"label,omitempty"`
+}
+
+// An AddressComponent is a part of an address.
+type AddressComponent struct {
+	Kind  string `json:"kind"`
+	Value string `json:"value"`
+}
+
+// Values for Anniversary.Kind.
+const (
+	AnniversaryBirth   = "birth"
+	AnniversaryDeath   = "death"
+	AnniversaryWedding = "wedding"
+)
+
+// An Anniversary is a memorable date.
+type Anniversary struct {
+	Kind  string   `json:"kind"`
+	Date  *Date    `json:"date"`
+	Place *Address `json:"place,omitempty"`
+}
+
+// A Date is either a possibly incomplete calendar date (a PartialDate in RFC
+// 9553), or a point in time (a Timestamp) if UTC is not zero.
+type Date struct {
+	Year  int
+	Month int
+	Day   int
+	UTC   time.Time
+}
+
+type partialDate struct {
+	Type  string     `json:"@type"`
+	Year  int        `json:"year,omitempty"`
+	Month int        `json:"month,omitempty"`
+	Day   int        `json:"day,omitempty"`
+	UTC   *time.Time `json:"utc,omitempty"`
+}
+
+// MarshalJSON implements json.Marshaler.
+func (d *Date) MarshalJSON() ([]byte, error) {
+	if !d.UTC.IsZero() {
+		utc := d.UTC.UTC()
+		return json.Marshal(&partialDate{Type: "Timestamp", UTC: &utc})
+	}
+	return json.Marshal(&partialDate{
+		Type:  "PartialDate",
+		Year:  d.Year,
+		Month: d.Month,
+		Day:   d.Day,
+	})
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (d *Date) UnmarshalJSON(b []byte) error {
+	var raw partialDate
+	if err := json.Unmarshal(b, &raw); err != nil {
+		return err
+	}
+	switch raw.Type {
+	case "Timestamp":
+		if raw.UTC == nil {
+			return errors.New("jscontact: missing utc in Timestamp")
+		}
+		*d = Date{UTC: *raw.UTC}
+	case "PartialDate", "":
+		*d = Date{Year: raw.Year, Month: raw.Month, Day: raw.Day}
+	default:
+		return fmt.Errorf("jscontact: unknown date type %q", raw.Type)
+	}
+	return nil
+}
+
+// A Note is free-text information about the entity.
+type Note struct {
+	Note    string     `json:"note"`
+	Created *time.Time `json:"created,omitempty"`
+	Author  *Author    `json:"author,omitempty"`
+}
+
+// An Author is the author of a note.
+type Author struct {
+	Name string `json:"name,omitempty"`
+	URI  string `json:"uri,omitempty"`
+}
+
+// Values for PersonalInfo.Kind.
+const (
+	PersonalInfoExpertise = "expertise"
+	PersonalInfoHobby     = "hobby"
+	PersonalInfoInterest  = "interest"
+)
+
+// Values for PersonalInfo.Level.
+const (
+	LevelHigh   = "high"
+	LevelMedium = "medium"
+	LevelLow    = "low"
+)
+
+// A PersonalInfo is an expertise, a hobby or an interest.
+type PersonalInfo struct {
+	Kind   string `json:"kind"`
+	Value  string `json:"value"`
+	Level  string `json:"level,omitempty"`
+	ListAs int    `json:"listAs,omitempty"`
+	Label  string `json:"label,omitempty"`
+}
+
+// A VCardProp is a vCard property which can't be represented in JSContact. It
+// is serialized as a jCard property, defined in RFC 7095 section 3.3.
+type VCardProp struct {
+	Name   string
+	Params map[string][]string
+	Type   string
+	// Value is a string for non-structured values, and a list of components
+	// for structured values.
+	Value interface{}
+}
+
+// MarshalJSON implements json.Marshaler.
+func (p *VCardProp) MarshalJSON() ([]byte, error) {
+	params := make(map[string]interface{}, len(p.Params))
+	for k, values := range p.Params {
+		if len(values) == 1 {
+			params[k] = values[0]
+		} else {
+			params[k] = values
+		}
+	}
+	return json.Marshal([]interface{}{p.Name, params, p.Type, p.Value})
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (p *VCardProp) UnmarshalJSON(b []byte) error {
+	var raw []json.RawMessage
+	if err := json.Unmarshal(b, &raw); err != nil {
+		return err
+	}
+	if len(raw) < 4 {
+		return errors.New("jscontact: malformed vCard property")
+	}
+
+	if err := json.Unmarshal(raw[0], &p.Name); err != nil {
+		return err
+	}
+	var params map[string]interface{}
+	if err := json.Unmarshal(raw[1], &params); err != nil {
+		return err
+	}
+	p.Params = make(map[string][]string, len(params))
+	for k, v := range params {
+		switch v := v.(type) {
+		case []interface{}:
+			for _, vv := range v {
+				p.Params[k] = append(p.Params[k], fmt.Sprint(vv))
+			}
+		default:
+			p.Params[k] = []string{fmt.Sprint(v)}
+		}
+	}
+	if err := json.Unmarshal(raw[2], &p.Type); err != nil {
+		return err
+	}
+
+	if err := json.Unmarshal(raw[3], &p.Value); err != nil {
+		return err
+	}
+	if len(raw) == 4 {
+		return nil
+	}
+
+	// Multiple values are joined into a single list value
+	values := []string{formatJCardValue(p.Value, ",")}
+	for _, r := range raw[4:] {
+		var v interface{}
+		if err := json.Unmarshal(r, &v); err != nil {
+			return err
+		}
+		values = append(values, formatJCardValue(v, ","))
+	}
+	p.Value = strings.Join(values, ",")
+	return nil
+}
@@ -0,0 +1,265 @@
+package ldif
+
+import (
+	"bufio"
+	"encoding/base64"
+	"errors"
+	"io"
+	"strings"
+
+	"github.com/emersion/go-vcard"
+)
+
+// A Decoder parses LDIF records.
+type Decoder struct {
+	r *bufio.Reader
+}
+
+// NewDecoder creates a new Decoder reading LDIF records from an io.Reader.
+func NewDecoder(r io.Reader) *Decoder {
+	return &Decoder{r: bufio.NewReader(r)}
+}
+
+// readLine reads an unfolded line, skipping comments.
+func (dec *Decoder) readLine() (string, error) {
+	for {
+		l, err := dec.r.ReadString('\n')
+		l = strings.TrimRight(l, "\r\n")
+		if err == io.EOF && len(l) == 0 {
+			return "", io.EOF
+		} else if err != nil && err != io.EOF {
+			return l, err
+		}
+
+		for err == nil {
+			next, err := dec.r.Peek(1)
+			if err == io.EOF {
+				break
+			} else if err != nil {
+				return l, err
+			}
+
+			if next[0] != ' ' {
+				break
+			}
+
+			if _, err := dec.r.Discard(1); err != nil {
+				return l, err
+			}
+
+			folded, err := dec.r.ReadString('\n')
+			if err != nil && err != io.EOF {
+				return l, err
+			}
+			l += strings.TrimRight(folded, "\r\n")
+		}
+
+		if !strings.HasPrefix(l, "#") {
+			return l, nil
+		}
+	}
+}
+
+type attribute struct {
+	name  string // lower-case, without options
+	value []byte
+	url   bool // value is an URL
+}
+
+func parseAttribute(l string) (*attribute, error) {
+	i := strings.IndexByte(l, ':')
+	if i <= 0 {
+		return nil, errors.New("ldif: malformed attribute")
+	}
+
+	name := l[:i]
+	if j := strings.IndexByte(name, ';'); j >= 0 {
+		name = name[:j]
+	}
+	attr := &attribute{name: strings.ToLower(name)}
+
+	v := l[i+1:]
+	switch {
+	case strings.HasPrefix(v, ":"):
+		b, err := base64.StdEncoding.DecodeString(strings.TrimLeft(v[1:], " "))
+		if err != nil {
+			return nil, errors.New("ldif: malformed base64 value")
+		}
+		attr.value = b
+	case strings.HasPrefix(v, "<"):
+		attr.value = []byte(strings.TrimLeft(v[1:], " "))
+		attr.url = true
+	default:
+		attr.value = []byte(strings.TrimLeft(v, " "))
+	}
+	return attr, nil
+}
+
+// Decode parses a single LDIF record. It returns io.EOF when there are no
+// more records.
+func (dec *Decoder) Decode() (vcard.Card, error) {
+	var attrs []*attribute
+	for {
+		l, err := dec.readLine()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, err
+		}
+
+		if l == "" {
+			if len(attrs) == 0 {
+				continue
+			}
+			break
+		}
+
+		attr, err := parseAttribute(l)
+		if err != nil {
+			return nil, err
+		}
+		switch attr.name {
+		case "version":
+			continue
+		case "changetype":
+			if !strings.EqualFold(string(attr.value), "add") {
+				return nil, errors.New("ldif: unsupported changetype")
+			}
+			continue
+		}
+		attrs = append(attrs, attr)
+	}
+
+	if len(attrs) == 0 {
+		return nil, io.EOF
+	}
+	if attrs[0].name != "dn" {
+		return nil, errors.New("ldif: record doesn't start with a DN")
+	}
+	return newCard(attrs), nil
+}
+
+func newCard(attrs []*attribute) vcard.Card {
+	card := make(vcard.Card)
+	card.SetValue(vcard.FieldVersion, "4.0")
+
+	var name vcard.Name
+	var hasName bool
+	work := &vcard.Address{Field: &vcard.Field{Params: vcard.Params{vcard.ParamType: {vcard.TypeWork}}}}
+	var hasWork bool
+	var org []string
+	var displayName string
+
+	addTel := func(v string, types ...string) {
+		f := &vcard.Field{Value: v}
+		if len(types) > 0 {
+			f.Params = vcard.Params{vcard.ParamType: types}
+		}
+		card.Add(vcard.FieldTelephone, f)
+	}
+
+	for _, attr := range attrs {
+		if attr.url {
+			if attr.name == "jpegphoto" {
+				card.AddValue(vcard.FieldPhoto, string(attr.value))
+			}
+			continue
+		}
+
+		v := string(attr.value)
+		switch attr.name {
+		case "dn":
+			card.SetValue(vcard.FieldSource, sourceURL(v))
+		case "cn":
+			card.AddValue(vcard.FieldFormattedName, v)
+		case "displayname":
+			displayName = v
+		case "sn":
+			if name.FamilyName == "" {
+				name.FamilyName = v
+			}
+			hasName = true
+		case "givenname":
+			if name.GivenName == "" {
+				name.GivenName = v
+			}
+			hasName = true
+		case "mail":
+			card.AddValue(vcard.FieldEmail, v)
+		case "telephonenumber":
+			addTel(v, vcard.TypeWork)
+		case "mobile":
+			addTel(v, vcard.TypeCell)
+		case "homephone":
+			addTel(v, vcard.TypeHome)
+		case "facsimiletelephonenumber":
+			addTel(v, vcard.TypeFax)
+		case "pager":
+			addTel(v, vcard.TypePager)
+		case "postaladdress":
+			work.Params.Set(vcard.ParamLabel, parsePostalAddress(v))
+			hasWork = true
+		case "postofficebox":
+			work.PostOfficeBox = v
+			hasWork = true
+		case "street":
+			work.StreetAddress = v
+			hasWork = true
+		case "l":
+			work.Locality = v
+			hasWork = true
+		case "st":
+			work.Region = v
+			hasWork = true
+		case "postalcode":
+			work.PostalCode = v
+			hasWork = true
+		case "homepostaladdress":
+			card.AddAddress(&vcard.Address{Field: &vcard.Field{Params: vcard.Params{
+				vcard.ParamType:  {vcard.TypeHome},
+				vcard.ParamLabel: {parsePostalAddress(v)},
+			}}})
+		case "jpegphoto":
+			card.AddValue(vcard.FieldPhoto, "data:image/jpeg;base64,"+base64.StdEncoding.EncodeToString(attr.value))
+		case "o":
+			if len(org) == 0 {
+				org = append(org, v)
+			} else {
+				org[0] = v
+			}
+		case "ou":
+			if len(org) == 0 {
+				org = append(org, "")
+			}
+			org = append(org, v)
+		case "title":
+			card.AddValue(vcard.FieldTitle, v)
+		case "description":
+			card.AddValue(vcard.FieldNote, v)
+		case "labeleduri":
+			// The URI may be followed by a label
+			if l := strings.Fields(v); len(l) > 0 {
+				card.AddValue(vcard.FieldURL, l[0])
+			}
+		case "preferredlanguage":
+			card.AddValue(vcard.FieldLanguage, v)
+		}
+	}
+
+	if hasName {
+		card.SetName(&name)
+	}
+	if hasWork {
+		card.AddAddress(work)
+	}
+	if len(org) > 0 {
+		card.AddValue(vcard.FieldOrganization, strings.Join(org, ";"))
+	}
+	if card.Get(vcard.FieldFormattedName) == nil {
+		if displayName == "" && hasName {
+			displayName = strings.TrimSpace(name.GivenName + " " + name.FamilyName)
+		}
+		card.SetValue(vcard.FieldFormattedName, displayName)
+	}
+	return card
+}
@@ -0,0 +1,96 @@
+package ldif
+
+import (
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/emersion/go-vcard"
+)
+
+var testLDIF = `version: 1
+
+# Barbara's entry
+dn: cn=Barbara Jensen,ou=Product Development,dc=example,dc=com
+objectClass: top
+objectClass: person
+objectClass: organizationalPerson
+objectClass: inetOrgPerson
+cn: Barbara Jensen
+sn: Jensen
+givenName: Barbara
+o: Example
+ou: Product Development
+mail: bjensen@example.com
+telephoneNumber: +1 408 555 1212
+mobile: +1 408 555 1213
+postalAddress: 123 Main St$Cupertino, CA 95014
+jpegPhoto:: /9j/4AAQ
+labeledURI: https://example.com/~bjensen My home page
+
+dn:: Y249R8O2cmFuIETDvHJyLGRjPWV4YW1wbGUsZGM9Y29t
+cn:: R8O2cmFuIETDvHJy
+sn:: RMO8cnI=
+description: A long description that is folded
+  over two lines
+`
+
+func TestDecoder(t *testing.T) {
+	dec := NewDecoder(strings.NewReader(testLDIF))
+
+	card, err := dec.Decode()
+	if err != nil {
+		t.Fatalf("Decode() = %v", err)
+	}
+	want := vcard.Card{
+		vcard.FieldVersion:       {{Value: "4.0"}},
+		vcard.FieldSource:        {{Value: "ldap:///cn=Barbara%20Jensen,ou=Product%20Development,dc=example,dc=com"}},
+		vcard.FieldFormattedName: {{Value: "Barbara Jensen"}},
+		vcard.FieldName:          {{Value: "Jensen;Barbara;;;"}},
+		vcard.FieldOrganization:  {{Value: "Example;Product Development"}},
+		vcard.FieldEmail:         {{Value: "bjensen@example.com"}},
+		vcard.FieldTelephone: {
+			{Value: "+1 408 555 1212", Params: vcard.Params{vcard.ParamType: {"work"}}},
+			{Value: "+1 408 555 1213", Params: vcard.Params{vcard.ParamType: {"cell"}}},
+		},
+		vcard.FieldAddress: {{
+			Value: ";;;;;;",
+			Params: vcard.Params{
+				vcard.ParamType:  {"work"},
+				vcard.ParamLabel: {"123 Main St\nCupertino, CA 95014"},
+			},
+		}},
+		vcard.FieldPhoto: {{Value: "data:image/jpeg;base64,/9j/4AAQ"}},
+		vcard.FieldURL:   {{Value: "https://example.com/~bjensen"}},
+	}
+	if !reflect.DeepEqual(card, want) {
+		t.Errorf("Decode() = \n%#v\nbut want:\n%#v", card, want)
+	}
+
+	card, err = dec.Decode()
+	if err != nil {
+		t.Fatalf("Decode() = %v", err)
+	}
+	want = vcard.Card{
+		vcard.FieldVersion:       {{Value: "4.0"}},
+		vcard.FieldSource:        {{Value: "ldap:///cn=G%C3%B6ran%20D%C3%BCrr,dc=example,dc=com"}},
+		vcard.FieldFormattedName: {{Value: "Göran Dürr"}},
+		vcard.FieldName:          {{Value: "Dürr;;;;"}},
+		vcard.FieldNote:          {{Value: "A long description that is folded over two lines"}},
+	}
+	if !reflect.DeepEqual(card, want) {
+		t.Errorf("Decode() = \n%#v\nbut want:\n%#v", card, want)
+	}
+
+	if _, err := dec.Decode(); err != io.EOF {
+		t.Errorf("Decode() = %v, want io.EOF", err)
+	}
+}
+
+func TestDecoder_changeType(t *testing.T) {
+	ldif := "dn: cn=Barbara Jensen,dc=example,dc=com\nchangetype: delete\n"
+	if _, err := NewDecoder(strings.NewReader(ldif)).Decode(); err == nil {
+		t.Error("Decode() = nil, want an error")
+	}
+}
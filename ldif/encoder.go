@@ -0,0 +1,207 @@
+package ldif
+
+import (
+	"encoding/base64"
+	"errors"
+	"io"
+	"strings"
+
+	"github.com/emersion/go-vcard"
+)
+
+const maxLineLength = 76
+
+// An Encoder formats LDIF records.
+type Encoder struct {
+	w           io.Writer
+	wroteHeader bool
+
+	// BaseDN is the DN entries are created under, for cards without an LDAP
+	// SOURCE. Entries are named after their common name.
+	BaseDN string
+}
+
+// NewEncoder creates a new Encoder that writes LDIF records to w.
+func NewEncoder(w io.Writer) *Encoder {
+	return &Encoder{w: w}
+}
+
+// isSafeString returns true if v can be written as-is in an attribute value,
+// as described in RFC 2849.
+func isSafeString(v []byte) bool {
+	if len(v) == 0 {
+		return true
+	}
+	if v[0] == ' ' || v[0] == ':' || v[0] == '<' || v[len(v)-1] == ' ' {
+		return false
+	}
+	for _, b := range v {
+		if b == 0 || b == '\n' || b == '\r' || b >= 0x80 {
+			return false
+		}
+	}
+	return true
+}
+
+func formatAttribute(name string, value []byte) string {
+	var l string
+	if isSafeString(value) {
+		l = name + ": " + string(value)
+	} else {
+		l = name + ":: " + base64.StdEncoding.EncodeToString(value)
+	}
+
+	// Safe strings and base64 are ASCII, so lines can be folded anywhere
+	var sb strings.Builder
+	for len(l) > maxLineLength {
+		sb.WriteString(l[:maxLineLength])
+		sb.WriteString("\n ")
+		l = l[maxLineLength:]
+	}
+	sb.WriteString(l)
+	sb.WriteString("\n")
+	return sb.String()
+}
+
+// Encode formats a card as an inetOrgPerson LDIF record.
+func (enc *Encoder) Encode(card vcard.Card) error {
+	cn := card.PreferredValue(vcard.FieldFormattedName)
+	if cn == "" {
+		return errors.New("ldif: FN field missing")
+	}
+
+	dn := sourceDN(card)
+	if dn == "" {
+		dn = "cn=" + escapeDN(cn)
+		if enc.BaseDN != "" {
+			dn += "," + enc.BaseDN
+		}
+	}
+
+	var sb strings.Builder
+	if !enc.wroteHeader {
+		sb.WriteString("version: 1\n")
+		enc.wroteHeader = true
+	}
+	sb.WriteString("\n")
+
+	add := func(name, value string) {
+		if value != "" {
+			sb.WriteString(formatAttribute(name, []byte(value)))
+		}
+	}
+
+	add("dn", dn)
+	for _, class := range objectClasses {
+		add("objectClass", class)
+	}
+	add("cn", cn)
+
+	// sn is required by the person object class
+	var sn, givenName string
+	if name := card.Name(); name != nil {
+		sn, givenName = name.FamilyName, name.GivenName
+	}
+	if sn == "" {
+		sn = cn
+	}
+	add("sn", sn)
+	add("givenName", givenName)
+
+	if org := card.Value(vcard.FieldOrganization); org != "" {
+		components := strings.Split(org, ";")
+		add("o", components[0])
+		for _, unit := range components[1:] {
+			add("ou", unit)
+		}
+	}
+	for _, title := range card.Values(vcard.FieldTitle) {
+		add("title", title)
+	}
+	for _, email := range card.Values(vcard.FieldEmail) {
+		add("mail", strings.TrimPrefix(email, "mailto:"))
+	}
+	for _, tel := range card[vcard.FieldTelephone] {
+		add(telAttribute(tel), strings.TrimPrefix(tel.Value, "tel:"))
+	}
+
+	var work, home *vcard.Address
+	for _, address := range card.Addresses() {
+		if address.Params.HasType(vcard.TypeHome) {
+			if home == nil {
+				home = address
+			}
+		} else if work == nil || (!work.Params.HasType(vcard.TypeWork) && address.Params.HasType(vcard.TypeWork)) {
+			work = address
+		}
+	}
+	if work != nil {
+		add("postalAddress", formatPostalAddress(addressLabel(work)))
+		add("postOfficeBox", work.PostOfficeBox)
+		add("street", work.StreetAddress)
+		add("l", work.Locality)
+		add("st", work.Region)
+		add("postalCode", work.PostalCode)
+	}
+	if home != nil {
+		add("homePostalAddress", formatPostalAddress(addressLabel(home)))
+	}
+
+	for _, photo := range card[vcard.FieldPhoto] {
+		if b := jpegPhoto(photo); b != nil {
+			sb.WriteString(formatAttribute("jpegPhoto", b))
+		}
+	}
+	for _, u := range card.Values(vcard.FieldURL) {
+		add("labeledURI", u)
+	}
+	for _, note := range card.Values(vcard.FieldNote) {
+		add("description", note)
+	}
+	if lang := card.PreferredValue(vcard.FieldLanguage); lang != "" {
+		add("preferredLanguage", lang)
+	}
+
+	_, err := io.WriteString(enc.w, sb.String())
+	return err
+}
+
+func telAttribute(tel *vcard.Field) string {
+	switch {
+	case tel.Params.HasType(vcard.TypeCell):
+		return "mobile"
+	case tel.Params.HasType(vcard.TypeFax):
+		return "facsimileTelephoneNumber"
+	case tel.Params.HasType(vcard.TypePager):
+		return "pager"
+	case tel.Params.HasType(vcard.TypeHome) && !tel.Params.HasType(vcard.TypeWork):
+		return "homePhone"
+	default:
+		return "telephoneNumber"
+	}
+}
+
+// jpegPhoto returns the JPEG data of a photo field. It returns nil if the
+// photo isn't an inline JPEG image.
+func jpegPhoto(photo *vcard.Field) []byte {
+	var data string
+	if strings.EqualFold(photo.Params.Get(vcard.ParamEncoding), "b") {
+		// vCard 3.0 inline data
+		if t := photo.Params.Get(vcard.ParamType); t != "" && !strings.EqualFold(t, "jpeg") {
+			return nil
+		}
+		data = photo.Value
+	} else {
+		const prefix = "data:image/jpeg;base64,"
+		if len(photo.Value) < len(prefix) || !strings.EqualFold(photo.Value[:len(prefix)], prefix) {
+			return nil
+		}
+		data = photo.Value[len(prefix):]
+	}
+
+	b, err := base64.StdEncoding.DecodeString(data)
+	if err != nil {
+		return nil
+	}
+	return b
+}
@@ -0,0 +1,94 @@
+package ldif
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/emersion/go-vcard"
+)
+
+func TestEncoder(t *testing.T) {
+	card := vcard.Card{
+		vcard.FieldVersion:       {{Value: "4.0"}},
+		vcard.FieldFormattedName: {{Value: "Göran Dürr, Jr."}},
+		vcard.FieldName:          {{Value: "Dürr;Göran;;;Jr."}},
+		vcard.FieldOrganization:  {{Value: "Example;Sales"}},
+		vcard.FieldEmail:         {{Value: "goran@example.com"}},
+		vcard.FieldTelephone: {
+			{Value: "tel:+46-8-555-0100", Params: vcard.Params{vcard.ParamType: {"work", "voice"}}},
+			{Value: "+46 70 555 0101", Params: vcard.Params{vcard.ParamType: {"cell"}}},
+		},
+		vcard.FieldAddress: {
+			{Value: ";;Drottninggatan 1;Stockholm;;111 51;Sweden", Params: vcard.Params{vcard.ParamType: {"work"}}},
+		},
+		vcard.FieldPhoto: {{Value: "data:image/jpeg;base64,/9j/4AAQ"}},
+		vcard.FieldNote:  {{Value: strings.Repeat("a", 80)}},
+	}
+
+	var b bytes.Buffer
+	enc := NewEncoder(&b)
+	enc.BaseDN = "dc=example,dc=com"
+	if err := enc.Encode(card); err != nil {
+		t.Fatalf("Encode() = %v", err)
+	}
+
+	want := `version: 1
+
+dn:: Y249R8O2cmFuIETDvHJyXCwgSnIuLGRjPWV4YW1wbGUsZGM9Y29t
+objectClass: top
+objectClass: person
+objectClass: organizationalPerson
+objectClass: inetOrgPerson
+cn:: R8O2cmFuIETDvHJyLCBKci4=
+sn:: RMO8cnI=
+givenName:: R8O2cmFu
+o: Example
+ou: Sales
+mail: goran@example.com
+telephoneNumber: +46-8-555-0100
+mobile: +46 70 555 0101
+postalAddress: Drottninggatan 1$Stockholm 111 51$Sweden
+street: Drottninggatan 1
+l: Stockholm
+postalCode: 111 51
+jpegPhoto:: /9j/4AAQ
+description: aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa
+ aaaaaaaaaaaaaaaaa
+`
+	if b.String() != want {
+		t.Errorf("Encode() = \n%v\nbut want:\n%v", b.String(), want)
+	}
+}
+
+func TestEncoder_roundTrip(t *testing.T) {
+	var cards []vcard.Card
+	dec := NewDecoder(strings.NewReader(testLDIF))
+	for {
+		card, err := dec.Decode()
+		if err != nil {
+			break
+		}
+		cards = append(cards, card)
+	}
+
+	var b bytes.Buffer
+	enc := NewEncoder(&b)
+	for _, card := range cards {
+		if err := enc.Encode(card); err != nil {
+			t.Fatalf("Encode() = %v", err)
+		}
+	}
+
+	dec = NewDecoder(&b)
+	for _, want := range cards {
+		card, err := dec.Decode()
+		if err != nil {
+			t.Fatalf("Decode() = %v", err)
+		}
+		if !reflect.DeepEqual(card, want) {
+			t.Errorf("round trip = \n%#v\nbut want:\n%#v", card, want)
+		}
+	}
+}
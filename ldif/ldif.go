@@ -0,0 +1,98 @@
+// Package ldif converts cards from and to LDIF records, defined in RFC 2849,
+// using the inetOrgPerson object class defined in RFC 2798.
+//
+// The DN of an entry is stored in the SOURCE property of the card, as an LDAP
+// URL.
+package ldif
+
+import (
+	"net/url"
+	"strings"
+
+	"github.com/emersion/go-vcard"
+)
+
+var objectClasses = []string{"top", "person", "organizationalPerson", "inetOrgPerson"}
+
+// sourceDN returns the DN stored in the SOURCE property of a card.
+func sourceDN(card vcard.Card) string {
+	for _, source := range card.Values(vcard.FieldSource) {
+		u, err := url.Parse(source)
+		if err != nil || !strings.EqualFold(u.Scheme, "ldap") {
+			continue
+		}
+		return strings.TrimPrefix(u.Path, "/")
+	}
+	return ""
+}
+
+// sourceURL formats a DN as an LDAP URL.
+func sourceURL(dn string) string {
+	u := url.URL{Scheme: "ldap", Path: "/" + dn}
+	return u.String()
+}
+
+// escapeDN escapes an attribute value for use in a DN, as described in RFC
+// 4514 section 2.4.
+func escapeDN(v string) string {
+	var sb strings.Builder
+	for i, r := range v {
+		switch {
+		case strings.ContainsRune(`,+"\<>;=`, r),
+			i == 0 && (r == ' ' || r == '#'),
+			i == len(v)-1 && r == ' ':
+			sb.WriteByte('\\')
+		}
+		sb.WriteRune(r)
+	}
+	return sb.String()
+}
+
+// parsePostalAddress parses a postal address attribute value, whose lines are
+// separated with "$", as described in RFC 4517 section 3.3.28.
+func parsePostalAddress(v string) string {
+	lines := strings.Split(v, "$")
+	for i, l := range lines {
+		l = strings.Replace(l, `\24`, "$", -1)
+		l = strings.Replace(l, `\5C`, `\`, -1)
+		l = strings.Replace(l, `\5c`, `\`, -1)
+		lines[i] = strings.TrimSpace(l)
+	}
+	return strings.Join(lines, "\n")
+}
+
+func formatPostalAddress(v string) string {
+	lines := strings.Split(v, "\n")
+	for i, l := range lines {
+		l = strings.Replace(l, `\`, `\5C`, -1)
+		lines[i] = strings.Replace(l, "$", `\24`, -1)
+	}
+	return strings.Join(lines, "$")
+}
+
+// addressLabel returns the delivery label of an address, formatting it from
+// the address components if it has no LABEL parameter.
+func addressLabel(address *vcard.Address) string {
+	if label := address.Params.Get(vcard.ParamLabel); label != "" {
+		return label
+	}
+
+	var lines []string
+	add := func(parts ...string) {
+		var l []string
+		for _, p := range parts {
+			if p != "" {
+				l = append(l, p)
+			}
+		}
+		if len(l) > 0 {
+			lines = append(lines, strings.Join(l, " "))
+		}
+	}
+	add(address.PostOfficeBox)
+	add(address.ExtendedAddress)
+	add(address.StreetAddress)
+	add(address.Locality, address.Region, address.PostalCode)
+	add(address.Country)
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,426 @@
+package vcard
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	fieldType   = reflect.TypeOf((*Field)(nil))
+	nameType    = reflect.TypeOf(Name{})
+	addressType = reflect.TypeOf(Address{})
+	timeType    = reflect.TypeOf(time.Time{})
+)
+
+// Marshal returns the card representing v, which must be a struct or a
+// pointer to a struct. If v doesn't have a VERSION field, the card version is
+// set to 4.0.
+//
+// Struct fields are mapped to properties with the "vcard" tag. The tag
+// contains the property name, optionally followed by a comma-separated list
+// of options:
+//
+//   - "type=<value>": the TYPE parameter contains <value>. This option can be
+//     repeated.
+//   - "pref": the property is preferred. When unmarshaling, the preferred
+//     property is picked.
+//
+// For instance, `vcard:"EMAIL,type=work,pref"` maps to the preferred work
+// email address. Fields without a tag are ignored, except anonymous struct
+// fields, whose fields are mapped as if they were in the outer struct.
+//
+// The supported field types are:
+//
+//   - string
+//   - []string, mapped to one property per element, or to a single property
+//     for list properties such as CATEGORIES
+//   - time.Time, for dates and timestamps such as BDAY and REV
+//   - Name and Address, for structured values
+//   - structs whose fields are strings tagged with a component index, e.g.
+//     `vcard:"0"`, for other structured values
+//   - *Field, for raw access to the property
+//   - pointers to and slices of the above types
+//
+// Empty values are omitted.
+func Marshal(v interface{}) (Card, error) {
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Ptr && !rv.IsNil() {
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("vcard: cannot marshal %T: not a struct", v)
+	}
+
+	fields, err := structFields(rv.Type())
+	if err != nil {
+		return nil, err
+	}
+
+	card := make(Card)
+	for _, sf := range fields {
+		l, err := marshalValue(sf.tag.name, rv.FieldByIndex(sf.index))
+		if err != nil {
+			return nil, fmt.Errorf("vcard: cannot marshal field %v: %v", sf.goName, err)
+		}
+		for _, f := range l {
+			if f.Params == nil && (len(sf.tag.types) > 0 || sf.tag.pref) {
+				f.Params = make(Params)
+			}
+			for _, t := range sf.tag.types {
+				if !f.Params.HasType(t) {
+					f.Params.Add(ParamType, t)
+				}
+			}
+			if sf.tag.pref {
+				f.Params.Set(ParamPreferred, "1")
+			}
+			card.Add(sf.tag.name, f)
+		}
+	}
+
+	if _, ok := card[FieldVersion]; !ok {
+		card.SetValue(FieldVersion, Version40)
+	}
+	return card, nil
+}
+
+// Unmarshal stores the properties of the card in v, which must be a pointer
+// to a struct. See Marshal for the struct tags format. Struct fields without
+// a matching property are left untouched.
+func Unmarshal(card Card, v interface{}) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() {
+		return fmt.Errorf("vcard: cannot unmarshal into %T: not a non-nil pointer", v)
+	}
+	rv = rv.Elem()
+	if rv.Kind() != reflect.Struct {
+		return fmt.Errorf("vcard: cannot unmarshal into %T: not a pointer to a struct", v)
+	}
+
+	fields, err := structFields(rv.Type())
+	if err != nil {
+		return err
+	}
+
+	for _, sf := range fields {
+		var matching []*Field
+		for _, f := range card[sf.tag.name] {
+			if matchTypes(f, sf.tag.types) {
+				matching = append(matching, f)
+			}
+		}
+		if len(matching) == 0 {
+			continue
+		}
+		if sf.tag.pref {
+			// Move the preferred field first
+			pref := Card{sf.tag.name: matching}.Preferred(sf.tag.name)
+			l := []*Field{pref}
+			for _, f := range matching {
+				if f != pref {
+					l = append(l, f)
+				}
+			}
+			matching = l
+		}
+
+		if err := unmarshalValue(sf.tag.name, matching, rv.FieldByIndex(sf.index)); err != nil {
+			return fmt.Errorf("vcard: cannot unmarshal field %v: %v", sf.goName, err)
+		}
+	}
+	return nil
+}
+
+type tagInfo struct {
+	name  string
+	types []string
+	pref  bool
+}
+
+func parseTag(tag string) (*tagInfo, error) {
+	parts := strings.Split(tag, ",")
+	info := &tagInfo{name: strings.ToUpper(strings.TrimSpace(parts[0]))}
+	if info.name == "" {
+		return nil, errors.New("vcard: missing property name in struct tag")
+	}
+	for _, opt := range parts[1:] {
+		opt = strings.TrimSpace(opt)
+		switch {
+		case opt == "pref":
+			info.pref = true
+		case strings.HasPrefix(opt, "type="):
+			info.types = append(info.types, strings.ToLower(strings.TrimPrefix(opt, "type=")))
+		case opt == "":
+			// ignore
+		default:
+			return nil, fmt.Errorf("vcard: unknown struct tag option %q", opt)
+		}
+	}
+	return info, nil
+}
+
+type structField struct {
+	index  []int
+	goName string
+	tag    *tagInfo
+}
+
+func structFields(t reflect.Type) ([]structField, error) {
+	var fields []structField
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag, ok := f.Tag.Lookup("vcard")
+		if tag == "-" {
+			continue
+		}
+		if !ok {
+			if f.Anonymous && f.Type.Kind() == reflect.Struct {
+				embedded, err := structFields(f.Type)
+				if err != nil {
+					return nil, err
+				}
+				for _, ef := range embedded {
+					ef.index = append([]int{i}, ef.index...)
+					fields = append(fields, ef)
+				}
+			}
+			continue
+		}
+		if f.PkgPath != "" {
+			return nil, fmt.Errorf("vcard: struct field %v is unexported", f.Name)
+		}
+
+		info, err := parseTag(tag)
+		if err != nil {
+			return nil, err
+		}
+		fields = append(fields, structField{index: []int{i}, goName: f.Name, tag: info})
+	}
+	return fields, nil
+}
+
+func matchTypes(f *Field, types []string) bool {
+	for _, t := range types {
+		if !f.Params.HasType(t) {
+			return false
+		}
+	}
+	return true
+}
+
+func isListProperty(k string) bool {
+	info := LookupProperty(k)
+	return info != nil && info.List
+}
+
+func marshalValue(k string, v reflect.Value) ([]*Field, error) {
+	t := v.Type()
+	switch {
+	case t == fieldType:
+		if v.IsNil() {
+			return nil, nil
+		}
+		f := *v.Interface().(*Field)
+		f.Params = cloneParams(f.Params)
+		return []*Field{&f}, nil
+	case t == timeType:
+		tm := v.Interface().(time.Time)
+		if tm.IsZero() {
+			return nil, nil
+		}
+		return []*Field{{Value: formatTime(k, tm)}}, nil
+	case t == nameType:
+		if v.IsZero() {
+			return nil, nil
+		}
+		name := v.Interface().(Name)
+		if name.Field != nil {
+			name.Field = copyField(name.Field)
+		}
+		return []*Field{name.field()}, nil
+	case t == addressType:
+		if v.IsZero() {
+			return nil, nil
+		}
+		addr := v.Interface().(Address)
+		if addr.Field != nil {
+			addr.Field = copyField(addr.Field)
+		}
+		return []*Field{addr.field()}, nil
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		if v.Len() == 0 {
+			return nil, nil
+		}
+		return []*Field{{Value: v.String()}}, nil
+	case reflect.Struct:
+		if v.IsZero() {
+			return nil, nil
+		}
+		value, err := marshalComponents(v)
+		if err != nil {
+			return nil, err
+		}
+		return []*Field{{Value: value}}, nil
+	case reflect.Ptr:
+		if v.IsNil() {
+			return nil, nil
+		}
+		return marshalValue(k, v.Elem())
+	case reflect.Slice:
+		if t.Elem().Kind() == reflect.String && isListProperty(k) {
+			if v.Len() == 0 {
+				return nil, nil
+			}
+			values := make([]string, v.Len())
+			for i := range values {
+				values[i] = v.Index(i).String()
+			}
+			return []*Field{{Value: strings.Join(values, ",")}}, nil
+		}
+		var fields []*Field
+		for i := 0; i < v.Len(); i++ {
+			l, err := marshalValue(k, v.Index(i))
+			if err != nil {
+				return nil, err
+			}
+			fields = append(fields, l...)
+		}
+		return fields, nil
+	default:
+		return nil, fmt.Errorf("unsupported type %v", t)
+	}
+}
+
+func unmarshalValue(k string, fields []*Field, v reflect.Value) error {
+	t := v.Type()
+	switch {
+	case t == fieldType:
+		v.Set(reflect.ValueOf(fields[0]))
+		return nil
+	case t == timeType:
+		tm, err := parseDateTime(fields[0].Value)
+		if err != nil {
+			return err
+		}
+		v.Set(reflect.ValueOf(tm))
+		return nil
+	case t == nameType:
+		v.Set(reflect.ValueOf(*newName(fields[0])))
+		return nil
+	case t == addressType:
+		v.Set(reflect.ValueOf(*newAddress(fields[0])))
+		return nil
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		v.SetString(fields[0].Value)
+		return nil
+	case reflect.Struct:
+		return unmarshalComponents(fields[0].Value, v)
+	case reflect.Ptr:
+		elem := reflect.New(t.Elem())
+		if err := unmarshalValue(k, fields, elem.Elem()); err != nil {
+			return err
+		}
+		v.Set(elem)
+		return nil
+	case reflect.Slice:
+		if t.Elem().Kind() == reflect.String && isListProperty(k) {
+			var values []string
+			for _, f := range fields {
+				values = append(values, splitParamValues(f.Value)...)
+			}
+			l := reflect.MakeSlice(t, len(values), len(values))
+			for i, value := range values {
+				l.Index(i).SetString(parseValue(value))
+			}
+			v.Set(l)
+			return nil
+		}
+		l := reflect.MakeSlice(t, len(fields), len(fields))
+		for i, f := range fields {
+			if err := unmarshalValue(k, []*Field{f}, l.Index(i)); err != nil {
+				return err
+			}
+		}
+		v.Set(l)
+		return nil
+	default:
+		return fmt.Errorf("unsupported type %v", t)
+	}
+}
+
+// componentFields returns the struct field index of each component of a
+// structured value.
+func componentFields(t reflect.Type) (map[int]int, error) {
+	m := make(map[int]int)
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag, ok := f.Tag.Lookup("vcard")
+		if !ok || tag == "-" {
+			continue
+		}
+		n, err := strconv.Atoi(tag)
+		if err != nil || n < 0 {
+			return nil, fmt.Errorf("invalid component index %q for field %v", tag, f.Name)
+		}
+		if f.Type.Kind() != reflect.String || f.PkgPath != "" {
+			return nil, fmt.Errorf("component field %v must be an exported string", f.Name)
+		}
+		m[n] = i
+	}
+	if len(m) == 0 {
+		return nil, fmt.Errorf("unsupported type %v: no component field", t)
+	}
+	return m, nil
+}
+
+func marshalComponents(v reflect.Value) (string, error) {
+	m, err := componentFields(v.Type())
+	if err != nil {
+		return "", err
+	}
+	n := 0
+	for i := range m {
+		if i >= n {
+			n = i + 1
+		}
+	}
+	components := make([]string, n)
+	for i, fi := range m {
+		components[i] = v.Field(fi).String()
+	}
+	return strings.Join(components, ";"), nil
+}
+
+func unmarshalComponents(s string, v reflect.Value) error {
+	m, err := componentFields(v.Type())
+	if err != nil {
+		return err
+	}
+	components := strings.Split(s, ";")
+	for i, fi := range m {
+		v.Field(fi).SetString(maybeGet(components, i))
+	}
+	return nil
+}
+
+// formatTime formats a time for the property k: timestamps are formatted in
+// UTC, and times at midnight are formatted as dates for other properties.
+func formatTime(k string, t time.Time) string {
+	if info := LookupProperty(k); info != nil && info.DefaultValueType() == ValueTimestamp {
+		return t.UTC().Format(timestampLayout)
+	}
+	if t.Hour() == 0 && t.Minute() == 0 && t.Second() == 0 && t.Nanosecond() == 0 {
+		return t.Format(dateLayout)
+	}
+	return t.UTC().Format(timestampLayout)
+}
@@ -0,0 +1,179 @@
+package vcard
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+type testGeo struct {
+	Latitude  string `vcard:"0"`
+	Longitude string `vcard:"1"`
+}
+
+type testContactBase struct {
+	UID string `vcard:"UID"`
+}
+
+type testContact struct {
+	testContactBase
+
+	FormattedName string    `vcard:"FN"`
+	Name          *Name     `vcard:"N"`
+	WorkEmail     string    `vcard:"EMAIL,type=work,pref"`
+	Emails        []string  `vcard:"EMAIL"`
+	Categories    []string  `vcard:"CATEGORIES"`
+	Birthday      time.Time `vcard:"BDAY"`
+	Revision      time.Time `vcard:"REV"`
+	Addresses     []Address `vcard:"ADR"`
+	Org           testGeo   `vcard:"X-TEST-STRUCTURED"`
+	Note          *Field    `vcard:"NOTE"`
+	Ignored       string
+	Skipped       string `vcard:"-"`
+}
+
+func TestMarshal(t *testing.T) {
+	c := &testContact{
+		testContactBase: testContactBase{UID: "urn:uuid:1"},
+		FormattedName:   "Alice Liddell",
+		Name:            &Name{FamilyName: "Liddell", GivenName: "Alice"},
+		WorkEmail:       "alice@example.com",
+		Emails:          []string{"alice@example.org"},
+		Categories:      []string{"friends", "wonderland"},
+		Birthday:        time.Date(1852, 5, 4, 0, 0, 0, 0, time.UTC),
+		Revision:        time.Date(2023, 9, 18, 10, 0, 0, 0, time.UTC),
+		Addresses:       []Address{{Locality: "Oxford", Country: "United Kingdom"}},
+		Org:             testGeo{"51.75", "-1.25"},
+		Note:            &Field{Value: "Curious", Params: Params{ParamLanguage: {"en"}}},
+		Ignored:         "ignored",
+		Skipped:         "skipped",
+	}
+
+	card, err := Marshal(c)
+	if err != nil {
+		t.Fatalf("Marshal() = %v", err)
+	}
+
+	want := Card{
+		FieldVersion:       {{Value: "4.0"}},
+		FieldUID:           {{Value: "urn:uuid:1"}},
+		FieldFormattedName: {{Value: "Alice Liddell"}},
+		FieldName:          {{Value: "Liddell;Alice;;;"}},
+		FieldEmail: {
+			{Value: "alice@example.com", Params: Params{ParamType: {"work"}, ParamPreferred: {"1"}}},
+			{Value: "alice@example.org"},
+		},
+		FieldCategories:     {{Value: "friends,wonderland"}},
+		FieldBirthday:       {{Value: "18520504"}},
+		FieldRevision:       {{Value: "20230918T100000Z"}},
+		FieldAddress:        {{Value: ";;;Oxford;;;United Kingdom"}},
+		"X-TEST-STRUCTURED": {{Value: "51.75;-1.25"}},
+		FieldNote:           {{Value: "Curious", Params: Params{ParamLanguage: {"en"}}}},
+	}
+	if !reflect.DeepEqual(card, want) {
+		t.Errorf("Marshal() = \n%v\nbut want \n%v", card, want)
+	}
+}
+
+func TestUnmarshal(t *testing.T) {
+	s := "BEGIN:VCARD\r\n" +
+		"VERSION:4.0\r\n" +
+		"UID:urn:uuid:1\r\n" +
+		"FN:Alice Liddell\r\n" +
+		"N:Liddell;Alice;;;\r\n" +
+		"EMAIL;TYPE=home:alice@example.org\r\n" +
+		"EMAIL;TYPE=work:alice@example.net\r\n" +
+		"EMAIL;TYPE=work;PREF=1:alice@example.com\r\n" +
+		"CATEGORIES:friends,wonderland\r\n" +
+		"BDAY:1852-05-04\r\n" +
+		"REV:20230918T100000Z\r\n" +
+		"ADR:;;;Oxford;;;United Kingdom\r\n" +
+		"ADR:;;;London;;;United Kingdom\r\n" +
+		"X-TEST-STRUCTURED:51.75;-1.25\r\n" +
+		"NOTE;LANGUAGE=en:Curious\r\n" +
+		"END:VCARD\r\n"
+	card, err := NewDecoder(strings.NewReader(s)).Decode()
+	if err != nil {
+		t.Fatalf("Decode() = %v", err)
+	}
+
+	c := testContact{Ignored: "untouched"}
+	if err := Unmarshal(card, &c); err != nil {
+		t.Fatalf("Unmarshal() = %v", err)
+	}
+
+	if c.UID != "urn:uuid:1" || c.FormattedName != "Alice Liddell" {
+		t.Errorf("Unmarshal(): UID = %q, FN = %q", c.UID, c.FormattedName)
+	}
+	if c.Name == nil || c.Name.FamilyName != "Liddell" || c.Name.GivenName != "Alice" {
+		t.Errorf("Unmarshal(): Name = %+v", c.Name)
+	}
+	if c.WorkEmail != "alice@example.com" {
+		t.Errorf("Unmarshal(): WorkEmail = %q", c.WorkEmail)
+	}
+	if want := []string{"alice@example.org", "alice@example.net", "alice@example.com"}; !reflect.DeepEqual(c.Emails, want) {
+		t.Errorf("Unmarshal(): Emails = %v, want %v", c.Emails, want)
+	}
+	if want := []string{"friends", "wonderland"}; !reflect.DeepEqual(c.Categories, want) {
+		t.Errorf("Unmarshal(): Categories = %v, want %v", c.Categories, want)
+	}
+	if want := time.Date(1852, 5, 4, 0, 0, 0, 0, time.UTC); !c.Birthday.Equal(want) {
+		t.Errorf("Unmarshal(): Birthday = %v, want %v", c.Birthday, want)
+	}
+	if want := time.Date(2023, 9, 18, 10, 0, 0, 0, time.UTC); !c.Revision.Equal(want) {
+		t.Errorf("Unmarshal(): Revision = %v, want %v", c.Revision, want)
+	}
+	if len(c.Addresses) != 2 || c.Addresses[1].Locality != "London" {
+		t.Errorf("Unmarshal(): Addresses = %+v", c.Addresses)
+	}
+	if want := (testGeo{"51.75", "-1.25"}); c.Org != want {
+		t.Errorf("Unmarshal(): Org = %+v, want %+v", c.Org, want)
+	}
+	if c.Note == nil || c.Note.Params.Get(ParamLanguage) != "en" {
+		t.Errorf("Unmarshal(): Note = %+v", c.Note)
+	}
+	if c.Ignored != "untouched" {
+		t.Errorf("Unmarshal(): untagged field modified")
+	}
+}
+
+type testCategory string
+
+func TestUnmarshal_namedList(t *testing.T) {
+	card := Card{FieldCategories: {{Value: `friends,Alice\, Bob and Carol`}, {Value: "work"}}}
+
+	var c struct {
+		Categories []testCategory `vcard:"CATEGORIES"`
+	}
+	if err := Unmarshal(card, &c); err != nil {
+		t.Fatalf("Unmarshal() = %v", err)
+	}
+	want := []testCategory{"friends", "Alice, Bob and Carol", "work"}
+	if !reflect.DeepEqual(c.Categories, want) {
+		t.Errorf("Unmarshal(): Categories = %q, want %q", c.Categories, want)
+	}
+}
+
+func TestUnmarshal_invalid(t *testing.T) {
+	card := Card{FieldFormattedName: {{Value: "Alice"}}}
+
+	var c testContact
+	if err := Unmarshal(card, c); err == nil {
+		t.Error("Unmarshal() into non-pointer: expected an error")
+	}
+
+	var unsupported struct {
+		FN int `vcard:"FN"`
+	}
+	if err := Unmarshal(card, &unsupported); err == nil {
+		t.Error("Unmarshal() into int: expected an error")
+	}
+
+	var badOption struct {
+		FN string `vcard:"FN,unknown"`
+	}
+	if err := Unmarshal(card, &badOption); err == nil {
+		t.Error("Unmarshal() with unknown tag option: expected an error")
+	}
+}
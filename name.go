@@ -0,0 +1,255 @@
+package vcard
+
+import (
+	"strings"
+	"unicode"
+)
+
+// familyFirstLanguages lists the languages in which the family name is
+// written before the given name.
+var familyFirstLanguages = []string{"hu", "ja", "ko", "vi", "zh"}
+
+var honorificPrefixes = []string{
+	"mr", "mrs", "ms", "miss", "mx", "dr", "prof", "sir", "dame", "lord", "lady",
+	"rev", "fr", "hon", "capt", "col", "gen", "lt", "sgt", "herr", "frau",
+	"mme", "mlle", "sr", "sra", "srta",
+}
+
+var honorificSuffixes = []string{
+	"phd", "md", "dds", "esq", "mba", "cpa", "rn", "obe", "mbe", "kbe", "qc",
+	"kc",
+}
+
+// generationMarkers are the suffixes stored in the Generation component.
+var generationMarkers = []string{"jr", "sr", "ii", "iii", "iv"}
+
+// familyNameParticles are the words which are part of the family name when
+// they precede it, e.g. "van" in "Ludwig van Beethoven".
+var familyNameParticles = []string{
+	"al", "bin", "da", "das", "de", "del", "della", "der", "di", "dos", "du",
+	"ibn", "la", "le", "ten", "ter", "van", "von",
+}
+
+// Format returns the name formatted for display, e.g. "Dr. John Q. Public".
+// The order of the components depends on lang, a language tag such as "en"
+// or "ja-JP": the family name is first for Chinese, Japanese, Korean,
+// Hungarian and Vietnamese. If lang is empty, names written with CJK scripts
+// are formatted family name first. CJK names are formatted without spaces.
+func (n *Name) Format(lang string) string {
+	components := []string{n.FamilyName, n.SecondaryFamilyName, n.GivenName, n.AdditionalName}
+	cjk := isCJKName(components)
+	familyFirst := isFamilyFirst(lang) || lang == "" && cjk
+
+	var l []string
+	if familyFirst {
+		l = []string{n.HonorificPrefix, n.FamilyName, n.SecondaryFamilyName, n.GivenName, n.AdditionalName, n.Generation, n.HonorificSuffix}
+	} else {
+		l = []string{n.HonorificPrefix, n.GivenName, n.AdditionalName, n.FamilyName, n.SecondaryFamilyName, n.Generation, n.HonorificSuffix}
+	}
+
+	sep := " "
+	if familyFirst && cjk {
+		sep = ""
+	}
+	var parts []string
+	for _, s := range l {
+		// Components may contain comma-separated values
+		for _, v := range strings.Split(s, ",") {
+			if v = strings.TrimSpace(v); v != "" {
+				parts = append(parts, v)
+			}
+		}
+	}
+	return strings.Join(parts, sep)
+}
+
+// ParseName splits a formatted name into name components. This is a
+// heuristic: it recognizes common honorific prefixes and suffixes,
+// generation markers such as "Jr.", the
+// "Family, Given" form and family name particles such as "van". lang is a
+// language tag, see Name.Format. CJK names written without spaces are split
+// after the first character, or after the first two characters for Japanese
+// names of at least three characters.
+func ParseName(fn, lang string) *Name {
+	name := new(Name)
+	fn = strings.TrimSpace(fn)
+	if fn == "" {
+		return name
+	}
+
+	// Comma-separated honorific suffixes, e.g. "Jane Doe, PhD"
+	parts := strings.Split(fn, ",")
+	var suffixes []string
+	for len(parts) > 1 && isSuffix(parts[len(parts)-1]) {
+		suffixes = append([]string{strings.TrimSpace(parts[len(parts)-1])}, suffixes...)
+		parts = parts[:len(parts)-1]
+	}
+
+	if len(parts) == 2 {
+		// "Family, Given Additional"
+		given := strings.Fields(parts[1])
+		var prefixes []string
+		for len(given) > 0 && isHonorific(honorificPrefixes, given[0]) {
+			prefixes = append(prefixes, given[0])
+			given = given[1:]
+		}
+		name.HonorificPrefix = strings.Join(prefixes, ",")
+		name.FamilyName = strings.Join(strings.Fields(parts[0]), " ")
+		if len(given) > 0 {
+			name.GivenName = given[0]
+			name.AdditionalName = strings.Join(given[1:], ",")
+		}
+		name.setSuffixes(suffixes)
+		return name
+	}
+	tokens := strings.Fields(strings.Join(parts, ","))
+
+	var prefixes []string
+	for len(tokens) > 1 && isHonorific(honorificPrefixes, tokens[0]) {
+		prefixes = append(prefixes, tokens[0])
+		tokens = tokens[1:]
+	}
+	var trailing []string
+	for len(tokens) > 1 && isSuffix(tokens[len(tokens)-1]) {
+		trailing = append([]string{tokens[len(tokens)-1]}, trailing...)
+		tokens = tokens[:len(tokens)-1]
+	}
+	name.HonorificPrefix = strings.Join(prefixes, ",")
+	name.setSuffixes(append(trailing, suffixes...))
+
+	if len(tokens) == 1 && isCJKName(tokens) {
+		runes := []rune(tokens[0])
+		n := 1
+		if isJapanese(lang, tokens[0]) && len(runes) >= 3 {
+			n = 2
+		}
+		if len(runes) > n {
+			name.FamilyName = string(runes[:n])
+			name.GivenName = string(runes[n:])
+		} else {
+			name.GivenName = tokens[0]
+		}
+		return name
+	}
+
+	switch {
+	case len(tokens) == 1:
+		name.GivenName = tokens[0]
+	case isFamilyFirst(lang) || lang == "" && isCJKName(tokens):
+		name.FamilyName = tokens[0]
+		name.GivenName = tokens[1]
+		name.AdditionalName = strings.Join(tokens[2:], ",")
+	default:
+		i := len(tokens) - 1
+		for i > 1 && containsFold(familyNameParticles, tokens[i-1]) {
+			i--
+		}
+		name.GivenName = tokens[0]
+		name.AdditionalName = strings.Join(tokens[1:i], ",")
+		name.FamilyName = strings.Join(tokens[i:], " ")
+	}
+	return name
+}
+
+// setSuffixes sets the Generation and HonorificSuffix components.
+func (n *Name) setSuffixes(l []string) {
+	var generations, suffixes []string
+	for _, s := range l {
+		if isHonorific(generationMarkers, s) {
+			generations = append(generations, s)
+		} else {
+			suffixes = append(suffixes, s)
+		}
+	}
+	n.Generation = strings.Join(generations, ",")
+	n.HonorificSuffix = strings.Join(suffixes, ",")
+}
+
+// FillFormattedName sets the formatted name from the name, if the card
+// doesn't have a formatted name. The language is the one of the name, or the
+// default language of the card. It returns true if the formatted name has
+// been set.
+func (c Card) FillFormattedName() bool {
+	if c.Value(FieldFormattedName) != "" {
+		return false
+	}
+	name := c.Name()
+	if name == nil {
+		return false
+	}
+	fn := name.Format(fieldLanguage(c, name.Field))
+	if fn == "" {
+		return false
+	}
+	c.SetValue(FieldFormattedName, fn)
+	return true
+}
+
+// FillName sets the name from the preferred formatted name with ParseName,
+// if the card doesn't have a name. It returns true if the name has been set.
+func (c Card) FillName() bool {
+	if c.Get(FieldName) != nil {
+		return false
+	}
+	fn := c.Preferred(FieldFormattedName)
+	if fn == nil || strings.TrimSpace(fn.Value) == "" {
+		return false
+	}
+	c.SetName(ParseName(fn.Value, fieldLanguage(c, fn)))
+	return true
+}
+
+func fieldLanguage(c Card, f *Field) string {
+	if lang := f.Params.Get(ParamLanguage); lang != "" {
+		return lang
+	}
+	return c.DefaultLanguage()
+}
+
+func languagePrefix(lang string) string {
+	if i := strings.IndexAny(lang, "-_"); i >= 0 {
+		lang = lang[:i]
+	}
+	return strings.ToLower(lang)
+}
+
+func isFamilyFirst(lang string) bool {
+	return containsFold(familyFirstLanguages, languagePrefix(lang))
+}
+
+func isJapanese(lang, s string) bool {
+	if lang != "" {
+		return languagePrefix(lang) == "ja"
+	}
+	for _, r := range s {
+		if unicode.In(r, unicode.Hiragana, unicode.Katakana) {
+			return true
+		}
+	}
+	return false
+}
+
+// isCJKName checks whether the non-empty strings are written in CJK scripts.
+func isCJKName(l []string) bool {
+	found := false
+	for _, s := range l {
+		for _, r := range s {
+			if unicode.In(r, unicode.Han, unicode.Hangul, unicode.Hiragana, unicode.Katakana) {
+				found = true
+			} else if unicode.IsLetter(r) {
+				return false
+			}
+		}
+	}
+	return found
+}
+
+func isSuffix(s string) bool {
+	return isHonorific(honorificSuffixes, s) || isHonorific(generationMarkers, s)
+}
+
+func isHonorific(l []string, s string) bool {
+	s = strings.TrimSuffix(strings.TrimSpace(s), ".")
+	s = strings.Replace(s, ".", "", -1)
+	return containsFold(l, s)
+}
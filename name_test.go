@@ -0,0 +1,95 @@
+package vcard
+
+import (
+	"testing"
+)
+
+func TestName_Format(t *testing.T) {
+	tests := []struct {
+		name *Name
+		lang string
+		want string
+	}{
+		{&Name{HonorificPrefix: "Dr.", GivenName: "John", AdditionalName: "Q.", FamilyName: "Public", HonorificSuffix: "Esq."}, "", "Dr. John Q. Public Esq."},
+		{&Name{GivenName: "John", AdditionalName: "Quinlan,Quincy", FamilyName: "Public"}, "en-US", "John Quinlan Quincy Public"},
+		{&Name{GivenName: "János", FamilyName: "Kovács", HonorificPrefix: "Dr."}, "hu", "Dr. Kovács János"},
+		{&Name{GivenName: "太郎", FamilyName: "山田"}, "", "山田太郎"},
+		{&Name{GivenName: "Taro", FamilyName: "Yamada"}, "ja-Latn", "Yamada Taro"},
+		{&Name{GivenName: "Taro", FamilyName: "Yamada"}, "", "Taro Yamada"},
+		{&Name{GivenName: "Ana", FamilyName: "García", SecondaryFamilyName: "López"}, "es", "Ana García López"},
+		{&Name{GivenName: "Martin", FamilyName: "King", Generation: "Jr."}, "", "Martin King Jr."},
+		{&Name{}, "", ""},
+	}
+	for _, tc := range tests {
+		if got := tc.name.Format(tc.lang); got != tc.want {
+			t.Errorf("Format(%q) = %q, want %q", tc.lang, got, tc.want)
+		}
+	}
+}
+
+func TestParseName(t *testing.T) {
+	tests := []struct {
+		fn, lang string
+		want     Name
+	}{
+		{"John Public", "", Name{GivenName: "John", FamilyName: "Public"}},
+		{"Dr. John Q. Public, Esq.", "", Name{HonorificPrefix: "Dr.", GivenName: "John", AdditionalName: "Q.", FamilyName: "Public", HonorificSuffix: "Esq."}},
+		{"Mr. John Quinlan Quincy Public Jr.", "", Name{HonorificPrefix: "Mr.", GivenName: "John", AdditionalName: "Quinlan,Quincy", FamilyName: "Public", Generation: "Jr."}},
+		{"John Smith Jr.", "", Name{GivenName: "John", FamilyName: "Smith", Generation: "Jr."}},
+		{"Smith, John, III", "", Name{GivenName: "John", FamilyName: "Smith", Generation: "III"}},
+		{"John Smith Jr., PhD", "", Name{GivenName: "John", FamilyName: "Smith", Generation: "Jr.", HonorificSuffix: "PhD"}},
+		{"Public, Dr. John Q.", "", Name{HonorificPrefix: "Dr.", GivenName: "John", AdditionalName: "Q.", FamilyName: "Public"}},
+		{"Ludwig van Beethoven", "", Name{GivenName: "Ludwig", FamilyName: "van Beethoven"}},
+		{"Jane Doe, PhD, MD", "", Name{GivenName: "Jane", FamilyName: "Doe", HonorificSuffix: "PhD,MD"}},
+		{"Kovács János", "hu-HU", Name{GivenName: "János", FamilyName: "Kovács"}},
+		{"山田 太郎", "", Name{GivenName: "太郎", FamilyName: "山田"}},
+		{"山田太郎", "ja", Name{GivenName: "太郎", FamilyName: "山田"}},
+		{"王小明", "zh", Name{GivenName: "小明", FamilyName: "王"}},
+		{"김민준", "", Name{GivenName: "민준", FamilyName: "김"}},
+		{"Madonna", "", Name{GivenName: "Madonna"}},
+		{"Dr. Who", "", Name{HonorificPrefix: "Dr.", GivenName: "Who"}},
+		{"  ", "", Name{}},
+	}
+	for _, tc := range tests {
+		if got := ParseName(tc.fn, tc.lang); *got != tc.want {
+			t.Errorf("ParseName(%q, %q) = %+v, want %+v", tc.fn, tc.lang, *got, tc.want)
+		}
+	}
+}
+
+func TestCard_FillFormattedName(t *testing.T) {
+	card := make(Card)
+	if card.FillFormattedName() {
+		t.Errorf("FillFormattedName() = true without a name")
+	}
+
+	card.SetName(&Name{GivenName: "János", FamilyName: "Kovács"})
+	card.SetDefaultLanguage("hu")
+	if !card.FillFormattedName() {
+		t.Fatalf("FillFormattedName() = false")
+	}
+	if fn := card.Value(FieldFormattedName); fn != "Kovács János" {
+		t.Errorf("FillFormattedName(): FN = %q, want %q", fn, "Kovács János")
+	}
+	if card.FillFormattedName() {
+		t.Errorf("FillFormattedName() = true with an existing formatted name")
+	}
+}
+
+func TestCard_FillName(t *testing.T) {
+	card := Card{
+		FieldFormattedName: {
+			{Value: "Alice Liddell"},
+			{Value: "山田太郎", Params: Params{ParamLanguage: {"ja"}, ParamPreferred: {"1"}}},
+		},
+	}
+	if !card.FillName() {
+		t.Fatalf("FillName() = false")
+	}
+	if name := card.Name(); name.FamilyName != "山田" || name.GivenName != "太郎" {
+		t.Errorf("FillName(): N = %q", card.Value(FieldName))
+	}
+	if card.FillName() {
+		t.Errorf("FillName() = true with an existing name")
+	}
+}
@@ -0,0 +1,249 @@
+package vcard
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Clone returns a deep copy of the card.
+func (c Card) Clone() Card {
+	if c == nil {
+		return nil
+	}
+	cp := make(Card, len(c))
+	for k, fields := range c {
+		l := make([]*Field, len(fields))
+		for i, f := range fields {
+			l[i] = copyField(f)
+		}
+		cp[k] = l
+	}
+	return cp
+}
+
+func copyField(f *Field) *Field {
+	cp := *f
+	cp.Params = cloneParams(f.Params)
+	return &cp
+}
+
+func cloneParams(params Params) Params {
+	if params == nil {
+		return nil
+	}
+	cp := make(Params, len(params))
+	for k, v := range params {
+		cp[k] = append([]string(nil), v...)
+	}
+	return cp
+}
+
+// EqualOptions contains options for Card.Equal.
+type EqualOptions struct {
+	// IgnoreOrder ignores the order of the fields of a property and of the
+	// values of a parameter.
+	IgnoreOrder bool
+	// IgnoreProps lists properties which aren't compared, e.g. FieldRevision
+	// and FieldProductID.
+	IgnoreProps []string
+}
+
+// Equal checks whether two cards contain the same fields. Property and
+// parameter names are compared case-sensitively: call Normalize first to
+// compare semantically equal cards. If options is nil, fields must be in the
+// same order.
+func (c Card) Equal(other Card, options *EqualOptions) bool {
+	if options == nil {
+		options = new(EqualOptions)
+	}
+
+	for _, cards := range [2][2]Card{{c, other}, {other, c}} {
+		for k, fields := range cards[0] {
+			if containsFold(options.IgnoreProps, k) {
+				continue
+			}
+			if !fieldsEqual(fields, cards[1][k], options.IgnoreOrder) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func fieldsEqual(a, b []*Field, ignoreOrder bool) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	if !ignoreOrder {
+		for i := range a {
+			if !fieldEqual(a[i], b[i], false) {
+				return false
+			}
+		}
+		return true
+	}
+
+	used := make([]bool, len(b))
+	for _, fa := range a {
+		found := false
+		for j, fb := range b {
+			if !used[j] && fieldEqual(fa, fb, true) {
+				used[j] = true
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+func fieldEqual(a, b *Field, ignoreOrder bool) bool {
+	if a.Value != b.Value || !strings.EqualFold(a.Group, b.Group) {
+		return false
+	}
+	if len(a.Params) != len(b.Params) {
+		return false
+	}
+	for k, va := range a.Params {
+		vb, ok := b.Params[k]
+		if !ok || !stringsEqual(va, vb, ignoreOrder) {
+			return false
+		}
+	}
+	return true
+}
+
+func stringsEqual(a, b []string, ignoreOrder bool) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	if ignoreOrder {
+		a = append([]string(nil), a...)
+		b = append([]string(nil), b...)
+		sort.Strings(a)
+		sort.Strings(b)
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// Normalize converts the card to a canonical form, so that semantically
+// equal cards are equal. It:
+//
+//   - uppercases property and parameter names, and lowercases groups
+//   - trims whitespace around values
+//   - lowercases, deduplicates and sorts TYPE values, and sorts the values of
+//     other unordered list parameters such as PID
+//   - represents preferred fields with the PREF parameter in vCard 4.0, and
+//     with TYPE=pref in vCard 3.0
+//   - removes empty parameters and properties
+func (c Card) Normalize() {
+	v3 := strings.TrimSpace(c.Value(FieldVersion)) == Version30
+
+	for k, fields := range c {
+		if upper := strings.ToUpper(k); upper != k {
+			delete(c, k)
+			c[upper] = append(c[upper], fields...)
+		}
+	}
+
+	for k, fields := range c {
+		if len(fields) == 0 {
+			delete(c, k)
+			continue
+		}
+		for _, f := range fields {
+			f.Group = strings.ToLower(f.Group)
+			f.Value = strings.TrimSpace(f.Value)
+			f.Params = normalizeParams(f.Params, v3)
+		}
+	}
+}
+
+func normalizeParams(params Params, v3 bool) Params {
+	if len(params) == 0 {
+		return nil
+	}
+
+	norm := make(Params, len(params))
+	for k, values := range params {
+		k = strings.ToUpper(k)
+		for _, v := range values {
+			if v = strings.TrimSpace(v); v != "" {
+				norm[k] = append(norm[k], v)
+			}
+		}
+	}
+
+	if types, ok := norm[ParamType]; ok {
+		seen := make(map[string]bool, len(types))
+		l := types[:0]
+		for _, t := range types {
+			for _, t := range strings.Split(t, ",") {
+				t = strings.ToLower(strings.TrimSpace(t))
+				if t != "" && !seen[t] {
+					seen[t] = true
+					l = append(l, t)
+				}
+			}
+		}
+		norm[ParamType] = l
+	}
+
+	// Canonicalize PREF
+	pref := 0
+	if v := norm.Get(ParamPreferred); v != "" {
+		if n, err := strconv.Atoi(v); err == nil {
+			pref = n
+		}
+		if pref < 1 {
+			pref = 0
+		} else if pref > 100 {
+			pref = 100
+		}
+		delete(norm, ParamPreferred)
+	}
+	if types, ok := norm[ParamType]; ok {
+		l := types[:0]
+		for _, t := range types {
+			if t == "pref" {
+				if pref == 0 {
+					pref = 1
+				}
+			} else {
+				l = append(l, t)
+			}
+		}
+		norm[ParamType] = l
+	}
+	if pref > 0 {
+		if v3 {
+			norm.Add(ParamType, "pref")
+		} else {
+			norm.Set(ParamPreferred, strconv.Itoa(pref))
+		}
+	}
+
+	for k, values := range norm {
+		if len(values) == 0 {
+			delete(norm, k)
+			continue
+		}
+		// SORT-AS values are ordered components
+		if info := LookupParam(k); info != nil && info.List && k != ParamSortAs {
+			sort.Strings(values)
+		}
+	}
+	if len(norm) == 0 {
+		return nil
+	}
+	return norm
+}
@@ -0,0 +1,134 @@
+package vcard
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCard_Clone(t *testing.T) {
+	card := Card{
+		FieldFormattedName: {{Value: "Alice", Params: Params{ParamLanguage: {"en"}}}},
+		FieldNote:          {{Value: "note", Group: "item1"}},
+	}
+	cp := card.Clone()
+	if !reflect.DeepEqual(cp, card) {
+		t.Fatalf("Clone() = %v, want %v", cp, card)
+	}
+
+	cp[FieldFormattedName][0].Value = "Bob"
+	cp[FieldFormattedName][0].Params[ParamLanguage][0] = "fr"
+	cp.AddValue(FieldNote, "another note")
+	if card.Value(FieldFormattedName) != "Alice" || card[FieldFormattedName][0].Params.Get(ParamLanguage) != "en" {
+		t.Errorf("Clone(): modifying the copy changed the original")
+	}
+	if len(card[FieldNote]) != 1 {
+		t.Errorf("Clone(): adding a field to the copy changed the original")
+	}
+
+	if Card(nil).Clone() != nil {
+		t.Errorf("Clone() of a nil card is not nil")
+	}
+}
+
+func TestCard_Equal(t *testing.T) {
+	a := Card{
+		FieldVersion:  {{Value: "4.0"}},
+		FieldEmail:    {{Value: "alice@example.com", Params: Params{ParamType: {"work", "voice"}}}, {Value: "alice@example.org"}},
+		FieldRevision: {{Value: "20230918T100000Z"}},
+	}
+	b := Card{
+		FieldVersion:  {{Value: "4.0"}},
+		FieldEmail:    {{Value: "alice@example.org"}, {Value: "alice@example.com", Params: Params{ParamType: {"voice", "work"}}}},
+		FieldRevision: {{Value: "20240101T000000Z"}},
+	}
+
+	tests := []struct {
+		name    string
+		a, b    Card
+		options *EqualOptions
+		want    bool
+	}{
+		{"same", a, a.Clone(), nil, true},
+		{"order", a, b, &EqualOptions{IgnoreProps: []string{FieldRevision}}, false},
+		{"revision", a, b, &EqualOptions{IgnoreOrder: true}, false},
+		{"ignoreOrderAndRevision", a, b, &EqualOptions{IgnoreOrder: true, IgnoreProps: []string{"rev"}}, true},
+		{"missingProp", a, Card{FieldVersion: {{Value: "4.0"}}}, nil, false},
+		{"extraProp", Card{FieldVersion: {{Value: "4.0"}}}, a, nil, false},
+		{"nilParams", Card{FieldNote: {{Value: "x"}}}, Card{FieldNote: {{Value: "x", Params: Params{}}}}, nil, true},
+		{"group", Card{FieldNote: {{Value: "x", Group: "item1"}}}, Card{FieldNote: {{Value: "x", Group: "ITEM1"}}}, nil, true},
+		{"duplicates", Card{FieldNote: {{Value: "x"}, {Value: "x"}}}, Card{FieldNote: {{Value: "x"}, {Value: "y"}}}, &EqualOptions{IgnoreOrder: true}, false},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := tc.a.Equal(tc.b, tc.options); got != tc.want {
+				t.Errorf("Equal() = %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestCard_Normalize(t *testing.T) {
+	card := Card{
+		FieldVersion: {{Value: "4.0"}},
+		"email": {
+			{Value: " alice@example.com ", Params: Params{"type": {"WORK,Internet", "work"}, "pref": {"1"}}},
+		},
+		FieldEmail: {
+			{Value: "alice@example.org", Params: Params{ParamType: {"pref", "Home"}}},
+		},
+		FieldTelephone: {
+			{Value: "+1-555-0100", Group: "Item1", Params: Params{ParamPreferred: {"500"}, ParamPID: {"2.1", "1.1"}, ParamLanguage: {" "}}},
+		},
+		FieldName: {
+			{Value: "Liddell;Alice;;;", Params: Params{ParamSortAs: {"Liddell", "Alice"}}},
+		},
+		FieldNote: {},
+	}
+	card.Normalize()
+
+	want := Card{
+		FieldVersion: {{Value: "4.0"}},
+		FieldEmail: {
+			{Value: "alice@example.org", Params: Params{ParamType: {"home"}, ParamPreferred: {"1"}}},
+			{Value: "alice@example.com", Params: Params{ParamType: {"internet", "work"}, ParamPreferred: {"1"}}},
+		},
+		FieldTelephone: {
+			{Value: "+1-555-0100", Group: "item1", Params: Params{ParamPreferred: {"100"}, ParamPID: {"1.1", "2.1"}}},
+		},
+		FieldName: {
+			{Value: "Liddell;Alice;;;", Params: Params{ParamSortAs: {"Liddell", "Alice"}}},
+		},
+	}
+	if !card.Equal(want, &EqualOptions{IgnoreOrder: true}) {
+		t.Errorf("Normalize() = \n%v\nbut want \n%v", card, want)
+	}
+}
+
+func TestCard_Normalize_v3(t *testing.T) {
+	card := Card{
+		FieldVersion: {{Value: "3.0"}},
+		FieldEmail:   {{Value: "alice@example.com", Params: Params{ParamPreferred: {"1"}, ParamType: {"WORK"}}}},
+	}
+	card.Normalize()
+
+	want := Params{ParamType: {"pref", "work"}}
+	if params := card[FieldEmail][0].Params; !reflect.DeepEqual(params, want) {
+		t.Errorf("Normalize() params = %v, want %v", params, want)
+	}
+}
+
+func TestCard_Normalize_equal(t *testing.T) {
+	a := Card{
+		FieldVersion: {{Value: "4.0"}},
+		FieldEmail:   {{Value: "alice@example.com", Params: Params{ParamType: {"pref", "work"}}}},
+	}
+	b := Card{
+		FieldVersion: {{Value: "4.0"}},
+		"Email":      {{Value: "alice@example.com ", Params: Params{"Type": {"WORK"}, "Pref": {"01"}}}},
+	}
+	a.Normalize()
+	b.Normalize()
+	if !a.Equal(b, nil) {
+		t.Errorf("normalized cards aren't equal: \n%v\n%v", a, b)
+	}
+}
@@ -0,0 +1,254 @@
+package vcard
+
+import (
+	"bytes"
+	"io"
+	"runtime"
+	"strings"
+	"sync"
+)
+
+// A ParallelDecoder parses cards concurrently. The input is split into cards
+// sequentially, then cards are parsed by a pool of goroutines.
+//
+// Cards and errors are the same as the ones returned by a Decoder with the
+// same limits. Unless Unordered is set, they are returned in the same order
+// too.
+type ParallelDecoder struct {
+	// Workers is the number of goroutines parsing cards. Zero means
+	// GOMAXPROCS.
+	Workers int
+	// Unordered allows cards to be returned in any order, which reduces
+	// latency when some cards are much larger than others.
+	Unordered bool
+	// MaxLineLength and MaxCardSize are the limits of Decoder.
+	MaxLineLength int
+	MaxCardSize   int
+
+	r io.Reader
+
+	once    sync.Once
+	results chan parallelResult
+	// each card in flight holds a token, to bound memory usage
+	tokens  chan struct{}
+	done    chan struct{}
+	pending map[int]parallelResult
+	next    int
+}
+
+type parallelJob struct {
+	seq int
+	raw []byte
+	// err overrides the error returned by the decoder, if set
+	err error
+}
+
+type parallelResult struct {
+	seq  int
+	card Card
+	err  error
+	// last is set for the result following the last card
+	last bool
+}
+
+// NewParallelDecoder creates a new ParallelDecoder reading cards from an
+// io.Reader.
+func NewParallelDecoder(r io.Reader) *ParallelDecoder {
+	return &ParallelDecoder{r: r}
+}
+
+func (dec *ParallelDecoder) start() {
+	workers := dec.Workers
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+
+	jobs := make(chan parallelJob, workers)
+	dec.results = make(chan parallelResult, workers)
+	dec.tokens = make(chan struct{}, 4*workers)
+	dec.done = make(chan struct{})
+	dec.pending = make(map[int]parallelResult)
+
+	var wg sync.WaitGroup
+	wg.Add(workers)
+	for i := 0; i < workers; i++ {
+		go func() {
+			defer wg.Done()
+			for job := range jobs {
+				if !dec.send(dec.decodeJob(&job)) {
+					return
+				}
+			}
+		}()
+	}
+
+	go func() {
+		seq, err := dec.split(jobs)
+		close(jobs)
+		wg.Wait()
+		dec.send(parallelResult{seq: seq, err: err, last: true})
+	}()
+}
+
+func (dec *ParallelDecoder) send(res parallelResult) bool {
+	select {
+	case dec.results <- res:
+		return true
+	case <-dec.done:
+		return false
+	}
+}
+
+func (dec *ParallelDecoder) decodeJob(job *parallelJob) parallelResult {
+	d := NewDecoder(bytes.NewReader(job.raw))
+	d.MaxLineLength = dec.MaxLineLength
+	d.MaxCardSize = dec.MaxCardSize
+	card, err := d.Decode()
+	if job.err != nil {
+		if card == nil {
+			card = make(Card)
+		}
+		err = job.err
+	}
+	return parallelResult{seq: job.seq, card: card, err: err}
+}
+
+// split reads the input and sends one job per call to Decoder.Decode. It
+// mirrors the logic of Decoder.decode, but only parses the lines which
+// delimit cards. It returns the number of jobs and the error which stopped
+// the input, if any.
+func (dec *ParallelDecoder) split(jobs chan<- parallelJob) (int, error) {
+	d := NewDecoder(dec.r)
+	d.MaxLineLength = dec.MaxLineLength
+	d.MaxCardSize = dec.MaxCardSize
+	d.capture = true
+
+	seq := 0
+	emit := func(err error) bool {
+		select {
+		case dec.tokens <- struct{}{}:
+		case <-dec.done:
+			return false
+		}
+		select {
+		case jobs <- parallelJob{seq: seq, raw: d.raw, err: err}:
+		case <-dec.done:
+			return false
+		}
+		seq++
+		// The next card is likely to have a similar size
+		d.raw = make([]byte, 0, len(d.raw))
+		return true
+	}
+
+	var hasBegin bool
+	var start int64
+	for {
+		offset, rawLen := d.offset, len(d.raw)
+		b, err := d.readLineBytes()
+		if err == io.EOF {
+			if hasBegin {
+				emit(nil)
+			}
+			return seq, nil
+		} else if err != nil {
+			if err == ErrLineTooLong {
+				// Decoder.Decode doesn't add the truncated line to the card
+				d.raw = d.raw[:rawLen]
+			}
+			if !emit(err) {
+				return seq, nil
+			} else if err != ErrLineTooLong {
+				return seq, err
+			}
+			hasBegin = false
+			continue
+		}
+
+		if hasBegin && d.MaxCardSize > 0 && d.offset-start > int64(d.MaxCardSize) {
+			if !emit(nil) {
+				return seq, nil
+			}
+			hasBegin = false
+			continue
+		}
+
+		k := lineKey(b)
+		if k == nil || hasBegin && !bytes.EqualFold(k, []byte("END")) {
+			continue
+		}
+		key, f, err := parseLine(string(b))
+		if err != nil || hasBegin && key != "END" {
+			continue
+		}
+
+		if !hasBegin && key == "BEGIN" && strings.ToUpper(f.Value) == "VCARD" {
+			hasBegin = true
+			start = offset
+			continue
+		}
+
+		// Either END, or a line making Decode fail
+		if !emit(nil) {
+			return seq, nil
+		}
+		hasBegin = false
+	}
+}
+
+// lineKey returns the property name of a content line, or nil if the line is
+// malformed. It mirrors parseGroup and parseKey.
+func lineKey(l []byte) []byte {
+	i := bytes.IndexAny(l, ".;:")
+	if i >= 0 && l[i] == '.' {
+		l = l[i+1:]
+		i = bytes.IndexAny(l, ";:")
+	}
+	if i < 0 {
+		return nil
+	}
+	return l[:i]
+}
+
+// Decode parses the next card. It returns io.EOF when there are no more
+// cards.
+func (dec *ParallelDecoder) Decode() (Card, error) {
+	dec.once.Do(dec.start)
+
+	for {
+		if res, ok := dec.pending[dec.next]; ok {
+			if res.last {
+				if res.err == nil {
+					res.err = io.EOF
+				}
+				return nil, res.err
+			}
+			delete(dec.pending, dec.next)
+			dec.next++
+			<-dec.tokens
+			return res.card, res.err
+		}
+
+		res := <-dec.results
+		if dec.Unordered && !res.last {
+			// Renumber results in the order they're received
+			res.seq = dec.next
+		}
+		dec.pending[res.seq] = res
+	}
+}
+
+// Close stops the goroutines started by the decoder. It must be called if
+// Decode hasn't returned io.EOF or an input error. Decode must not be called
+// after Close.
+func (dec *ParallelDecoder) Close() error {
+	dec.once.Do(func() {
+		dec.done = make(chan struct{})
+	})
+	select {
+	case <-dec.done:
+	default:
+		close(dec.done)
+	}
+	return nil
+}
@@ -0,0 +1,154 @@
+package vcard
+
+import (
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+)
+
+type decodeResult struct {
+	Card Card
+	Err  string
+}
+
+func decodeAll(t *testing.T, decode func() (Card, error)) []decodeResult {
+	var results []decodeResult
+	for i := 0; ; i++ {
+		if i > 1000 {
+			t.Fatal("too many results")
+		}
+		card, err := decode()
+		if err == io.EOF {
+			break
+		}
+		res := decodeResult{Card: card}
+		if err != nil {
+			res.Err = err.Error()
+		}
+		results = append(results, res)
+	}
+	return results
+}
+
+func testParallelInput() string {
+	var sb strings.Builder
+	for i := 0; i < 50; i++ {
+		fmt.Fprintf(&sb, "BEGIN:VCARD\r\nVERSION:4.0\r\nFN:Card %v\r\nNOTE:folded\r\n  note %v\r\nEND:VCARD\r\n", i, i)
+		switch i % 10 {
+		case 3:
+			sb.WriteString("\r\n")
+		case 5:
+			sb.WriteString("BEGIN:VCARD\r\nNOTE:" + strings.Repeat("long ", 20) + "\r\nEND:VCARD\r\n")
+		case 7:
+			sb.WriteString("BEGIN:VCARD\r\nEND:INVALID\r\n")
+		case 9:
+			sb.WriteString("FN:outside of a card\r\n")
+		}
+	}
+	sb.WriteString(testCardGoogleString + "\r\n")
+	sb.WriteString("BEGIN:VCARD\r\nFN:Unterminated\r\n")
+	return sb.String()
+}
+
+func TestParallelDecoder(t *testing.T) {
+	input := testParallelInput()
+
+	dec := NewDecoder(strings.NewReader(input))
+	dec.MaxLineLength = 80
+	want := decodeAll(t, dec.Decode)
+
+	for _, workers := range []int{1, 3, 0} {
+		pdec := NewParallelDecoder(strings.NewReader(input))
+		pdec.Workers = workers
+		pdec.MaxLineLength = 80
+		got := decodeAll(t, pdec.Decode)
+		pdec.Close()
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("ParallelDecoder with %v workers: got \n%+v\nbut want \n%+v", workers, got, want)
+		}
+	}
+}
+
+func TestParallelDecoder_limits(t *testing.T) {
+	var sb strings.Builder
+	sb.WriteString(testParallelInput())
+	for i := 0; i < 5; i++ {
+		fmt.Fprintf(&sb, "BEGIN:VCARD\r\nFN:Folded %v\r\nNOTE:%v\r\n %v\r\nEND:VCARD\r\n", i, strings.Repeat("a", 60), strings.Repeat("b", 60))
+		fmt.Fprintf(&sb, "BEGIN:VCARD\r\nFN:Huge %v\r\nNOTE:%v\r\nEND:VCARD\r\n", i, strings.Repeat("c", 5000))
+		sb.WriteString("BEGIN:VCARD\r\nFN:Large\r\n")
+		for j := 0; j < 20; j++ {
+			fmt.Fprintf(&sb, "NOTE:note %v\r\n", j)
+		}
+		sb.WriteString("END:VCARD\r\n")
+	}
+	input := sb.String()
+
+	for _, limits := range [][2]int{{80, 0}, {0, 200}, {80, 200}, {4500, 0}, {4500, 300}} {
+		dec := NewDecoder(strings.NewReader(input))
+		dec.MaxLineLength, dec.MaxCardSize = limits[0], limits[1]
+		want := decodeAll(t, dec.Decode)
+
+		pdec := NewParallelDecoder(strings.NewReader(input))
+		pdec.MaxLineLength, pdec.MaxCardSize = limits[0], limits[1]
+		got := decodeAll(t, pdec.Decode)
+		pdec.Close()
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("ParallelDecoder with limits %v: got \n%+v\nbut want \n%+v", limits, got, want)
+		}
+	}
+}
+
+func TestParallelDecoder_unordered(t *testing.T) {
+	input := testParallelInput()
+	want := decodeAll(t, NewDecoder(strings.NewReader(input)).Decode)
+
+	pdec := NewParallelDecoder(strings.NewReader(input))
+	pdec.Workers = 4
+	pdec.Unordered = true
+	got := decodeAll(t, pdec.Decode)
+	pdec.Close()
+
+	sortResults := func(l []decodeResult) {
+		key := func(res decodeResult) string {
+			return fmt.Sprint(res.Card.Values(FieldFormattedName), res.Card.Values(FieldNote), res.Err)
+		}
+		sort.Slice(l, func(i, j int) bool {
+			return key(l[i]) < key(l[j])
+		})
+	}
+	sortResults(got)
+	sortResults(want)
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("unordered ParallelDecoder: got \n%+v\nbut want \n%+v", got, want)
+	}
+}
+
+func TestParallelDecoder_close(t *testing.T) {
+	pdec := NewParallelDecoder(strings.NewReader(testParallelInput()))
+	pdec.Workers = 2
+	if _, err := pdec.Decode(); err != nil {
+		t.Fatalf("Decode() = %v", err)
+	}
+	pdec.Close()
+	pdec.Close()
+}
+
+func BenchmarkParallelDecoder(b *testing.B) {
+	input := strings.Repeat(testCardBenchmarkString, 100)
+	b.SetBytes(int64(len(input)))
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		dec := NewParallelDecoder(strings.NewReader(input))
+		for {
+			if _, err := dec.Decode(); err == io.EOF {
+				break
+			} else if err != nil {
+				b.Fatal(err)
+			}
+		}
+		dec.Close()
+	}
+}
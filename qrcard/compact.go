@@ -0,0 +1,150 @@
+package qrcard
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+
+	"github.com/emersion/go-vcard"
+)
+
+// Properties kept in compact vCard payloads, by decreasing priority.
+var compactProps = []string{
+	vcard.FieldFormattedName,
+	vcard.FieldName,
+	vcard.FieldTelephone,
+	vcard.FieldEmail,
+	vcard.FieldOrganization,
+	vcard.FieldTitle,
+	vcard.FieldURL,
+	vcard.FieldAddress,
+	vcard.FieldIMPP,
+	vcard.FieldNickname,
+	vcard.FieldRole,
+	vcard.FieldBirthday,
+	vcard.FieldNote,
+	vcard.FieldPhoto,
+}
+
+type compactField struct {
+	k string
+	f *vcard.Field
+}
+
+// compactFields converts the fields of a card to vCard 3.0, and sorts them by
+// decreasing priority. Parameters other than TYPE are dropped.
+func compactFields(card vcard.Card) []compactField {
+	var fields []compactField
+	for _, k := range compactProps {
+		l := card[k]
+		switch k {
+		case vcard.FieldFormattedName, vcard.FieldName:
+			// Only keep the preferred name
+			if f := card.Preferred(k); f != nil {
+				l = []*vcard.Field{f}
+			}
+		}
+
+		for _, f := range l {
+			var params vcard.Params
+			if types := f.Params[vcard.ParamType]; len(types) > 0 {
+				params = vcard.Params{vcard.ParamType: types}
+			}
+
+			v := f.Value
+			switch k {
+			case vcard.FieldName:
+				name := vcard.Card{k: {f}}.Name()
+				v = strings.Join([]string{name.FamilyName, name.GivenName, name.AdditionalName, name.HonorificPrefix, name.HonorificSuffix}, ";")
+			case vcard.FieldAddress:
+				address := vcard.Card{k: {f}}.Address()
+				v = strings.Join([]string{address.PostOfficeBox, address.ExtendedAddress, address.StreetAddress,
+					address.Locality, address.Region, address.PostalCode, address.Country}, ";")
+			case vcard.FieldTelephone:
+				v = strings.TrimPrefix(v, "tel:")
+			case vcard.FieldEmail:
+				v = strings.TrimPrefix(v, "mailto:")
+			case vcard.FieldPhoto:
+				var ok bool
+				if params, ok = compactPhoto(f); !ok {
+					continue
+				}
+				if strings.HasPrefix(v, "data:") {
+					v = v[strings.IndexByte(v, ',')+1:]
+				}
+			}
+			fields = append(fields, compactField{k, &vcard.Field{Value: v, Params: params}})
+		}
+	}
+	return fields
+}
+
+// compactPhoto returns the vCard 3.0 parameters of a photo. It returns false
+// if the photo is an unsupported data URI.
+func compactPhoto(f *vcard.Field) (vcard.Params, bool) {
+	if strings.EqualFold(f.Params.Get(vcard.ParamEncoding), "b") {
+		// Already a vCard 3.0 inline photo
+		return vcard.Params{vcard.ParamEncoding: {"b"}, vcard.ParamType: f.Params[vcard.ParamType]}, true
+	}
+	if !strings.HasPrefix(f.Value, "data:") {
+		return vcard.Params{vcard.ParamValue: {"uri"}}, true
+	}
+
+	// data:image/jpeg;base64,...
+	header := f.Value[len("data:"):]
+	i := strings.IndexByte(header, ',')
+	if i < 0 || !strings.HasSuffix(header[:i], ";base64") {
+		return nil, false
+	}
+	mediaType := strings.TrimSuffix(header[:i], ";base64")
+	typ := strings.ToUpper(strings.TrimPrefix(mediaType, "image/"))
+	return vcard.Params{vcard.ParamEncoding: {"b"}, vcard.ParamType: {typ}}, true
+}
+
+func encodeCompact(card vcard.Card) []byte {
+	var b bytes.Buffer
+	// The card always has a VERSION field, so encoding can't fail
+	vcard.NewEncoder(&b).Encode(card)
+	return b.Bytes()
+}
+
+// VCard formats a card as a minimal vCard 3.0 payload of at most maxSize
+// bytes. Only the properties most useful to scanners are kept. Fields are
+// added by decreasing priority, and fields which don't fit are dropped: the
+// photo is dropped first, then notes, birthday, and so on. An error is
+// returned if the formatted name doesn't fit.
+func VCard(card vcard.Card, maxSize int) ([]byte, error) {
+	if len(card[vcard.FieldFormattedName]) == 0 {
+		return nil, errors.New("qrcard: FN field missing")
+	}
+
+	compact := make(vcard.Card)
+	compact.SetValue(vcard.FieldVersion, "3.0")
+	empty := len(encodeCompact(compact))
+	size := empty
+
+	fields := compactFields(card)
+	if len(card[vcard.FieldName]) == 0 {
+		// N is required in vCard 3.0
+		fields = append(fields[:1], append([]compactField{{vcard.FieldName, &vcard.Field{Value: ";;;;"}}}, fields[1:]...)...)
+	}
+
+	for i, cf := range fields {
+		line := encodeCompact(vcard.Card{
+			vcard.FieldVersion: {{Value: "3.0"}},
+			cf.k:               {cf.f},
+		})
+		n := len(line) - empty
+		if size+n > maxSize {
+			// FN and N are required
+			if i < 2 {
+				return nil, errors.New("qrcard: card doesn't fit in the size limit")
+			}
+			continue
+		}
+		compact.Add(cf.k, cf.f)
+		size += n
+	}
+
+	return encodeCompact(compact), nil
+}
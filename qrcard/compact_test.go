@@ -0,0 +1,64 @@
+package qrcard
+
+import (
+	"testing"
+)
+
+func TestVCard(t *testing.T) {
+	full := "BEGIN:VCARD\r\n" +
+		"VERSION:3.0\r\n" +
+		"ADR:;;1-2-3 Chiyoda;Tokyo;;100-0001;Japan\r\n" +
+		"BDAY:1980-04-01\r\n" +
+		"EMAIL:taro@example.jp\r\n" +
+		"FN:Taro Yamada\r\n" +
+		"N:Yamada;Taro;;;\r\n" +
+		"NOTE:Met at GopherCon; likes Go: a lot\r\n" +
+		"PHOTO;ENCODING=b;TYPE=JPEG:/9j/4AAQSkZJRgABAQ\r\n" +
+		"TEL;TYPE=work:+81-3-1234-5678\r\n" +
+		"TEL;TYPE=video:+81-90-1234-5678\r\n" +
+		"END:VCARD\r\n"
+
+	b, err := VCard(testCard, 1000)
+	if err != nil {
+		t.Fatalf("VCard() = %v", err)
+	}
+	if string(b) != full {
+		t.Errorf("VCard() = \n%v\nbut want:\n%v", string(b), full)
+	}
+
+	noPhoto := "BEGIN:VCARD\r\n" +
+		"VERSION:3.0\r\n" +
+		"ADR:;;1-2-3 Chiyoda;Tokyo;;100-0001;Japan\r\n" +
+		"BDAY:1980-04-01\r\n" +
+		"EMAIL:taro@example.jp\r\n" +
+		"FN:Taro Yamada\r\n" +
+		"N:Yamada;Taro;;;\r\n" +
+		"NOTE:Met at GopherCon; likes Go: a lot\r\n" +
+		"TEL;TYPE=work:+81-3-1234-5678\r\n" +
+		"TEL;TYPE=video:+81-90-1234-5678\r\n" +
+		"END:VCARD\r\n"
+	b, err = VCard(testCard, len(full)-1)
+	if err != nil {
+		t.Fatalf("VCard() = %v", err)
+	}
+	if string(b) != noPhoto {
+		t.Errorf("VCard() = \n%v\nbut want:\n%v", string(b), noPhoto)
+	}
+
+	minimal := "BEGIN:VCARD\r\n" +
+		"VERSION:3.0\r\n" +
+		"FN:Taro Yamada\r\n" +
+		"N:Yamada;Taro;;;\r\n" +
+		"END:VCARD\r\n"
+	b, err = VCard(testCard, len(minimal)+10)
+	if err != nil {
+		t.Fatalf("VCard() = %v", err)
+	}
+	if string(b) != minimal {
+		t.Errorf("VCard() = \n%v\nbut want:\n%v", string(b), minimal)
+	}
+
+	if _, err := VCard(testCard, len(minimal)-1); err == nil {
+		t.Error("VCard() = nil, want an error")
+	}
+}
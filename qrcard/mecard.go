@@ -0,0 +1,215 @@
+// Package qrcard formats cards as compact payloads suitable for QR codes.
+//
+// Two formats are supported: MeCard, defined by NTT DOCOMO, and minimal vCard
+// 3.0 payloads fitting in a byte budget.
+package qrcard
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/emersion/go-vcard"
+)
+
+const meCardPrefix = "MECARD:"
+
+var meCardEscaper = strings.NewReplacer(`\`, `\\`, `;`, `\;`, `,`, `\,`, `:`, `\:`, `"`, `\"`)
+
+// MeCard formats a card as a MeCard payload.
+func MeCard(card vcard.Card) string {
+	var sb strings.Builder
+	sb.WriteString(meCardPrefix)
+	add := func(k string, values ...string) {
+		empty := true
+		for _, v := range values {
+			if v != "" {
+				empty = false
+			}
+		}
+		if empty {
+			return
+		}
+		sb.WriteString(k)
+		sb.WriteString(":")
+		for i, v := range values {
+			if i > 0 {
+				sb.WriteString(",")
+			}
+			sb.WriteString(meCardEscaper.Replace(v))
+		}
+		sb.WriteString(";")
+	}
+
+	if name := card.Name(); name != nil && (name.FamilyName != "" || name.GivenName != "") {
+		if name.GivenName != "" {
+			add("N", name.FamilyName, name.GivenName)
+		} else {
+			add("N", name.FamilyName)
+		}
+	} else {
+		add("N", card.PreferredValue(vcard.FieldFormattedName))
+	}
+	if org := card.PreferredValue(vcard.FieldOrganization); org != "" {
+		add("ORG", strings.Replace(org, ";", ", ", -1))
+	}
+	for _, tel := range card[vcard.FieldTelephone] {
+		k := "TEL"
+		if tel.Params.HasType(vcard.TypeVideo) {
+			k = "TEL-AV"
+		}
+		add(k, strings.TrimPrefix(tel.Value, "tel:"))
+	}
+	for _, email := range card.Values(vcard.FieldEmail) {
+		add("EMAIL", strings.TrimPrefix(email, "mailto:"))
+	}
+	if address := card.Address(); address != nil {
+		add("ADR", address.PostOfficeBox, address.ExtendedAddress, address.StreetAddress,
+			address.Locality, address.Region, address.PostalCode, address.Country)
+	}
+	if bday := strings.Replace(card.Value(vcard.FieldBirthday), "-", "", -1); len(bday) == 8 {
+		add("BDAY", bday)
+	}
+	for _, u := range card.Values(vcard.FieldURL) {
+		add("URL", u)
+	}
+	for _, nickname := range card.Values(vcard.FieldNickname) {
+		add("NICKNAME", nickname)
+	}
+	add("NOTE", card.Value(vcard.FieldNote))
+
+	sb.WriteString(";")
+	return sb.String()
+}
+
+// splitEscaped splits s around unescaped occurrences of sep, and unescapes
+// the results.
+func splitEscaped(s string, sep byte) []string {
+	var l []string
+	var sb strings.Builder
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; {
+		case c == '\\' && i+1 < len(s):
+			i++
+			sb.WriteByte(s[i])
+		case c == sep:
+			l = append(l, sb.String())
+			sb.Reset()
+		default:
+			sb.WriteByte(c)
+		}
+	}
+	return append(l, sb.String())
+}
+
+// splitFields splits a MeCard payload into fields. Escape sequences are kept.
+func splitFields(s string) []string {
+	var l []string
+	var start int
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case ';':
+			l = append(l, s[start:i])
+			start = i + 1
+		}
+	}
+	if start < len(s) {
+		l = append(l, s[start:])
+	}
+	return l
+}
+
+// ParseMeCard parses a MeCard payload.
+func ParseMeCard(s string) (vcard.Card, error) {
+	s = strings.TrimSpace(s)
+	if len(s) < len(meCardPrefix) || !strings.EqualFold(s[:len(meCardPrefix)], meCardPrefix) {
+		return nil, errors.New("qrcard: missing MECARD prefix")
+	}
+	s = s[len(meCardPrefix):]
+
+	card := make(vcard.Card)
+	card.SetValue(vcard.FieldVersion, "4.0")
+
+	for _, field := range splitFields(s) {
+		i := strings.IndexByte(field, ':')
+		if i < 0 {
+			continue
+		}
+		k, v := strings.ToUpper(strings.TrimSpace(field[:i])), field[i+1:]
+
+		switch k {
+		case "N":
+			parts := splitEscaped(v, ',')
+			name := &vcard.Name{FamilyName: parts[0]}
+			if len(parts) > 1 {
+				name.GivenName = parts[1]
+			}
+			card.SetName(name)
+		case "ADR":
+			parts := splitEscaped(v, ',')
+			if len(parts) == 1 {
+				card.AddAddress(&vcard.Address{StreetAddress: parts[0]})
+				continue
+			}
+			for len(parts) < 7 {
+				parts = append(parts, "")
+			}
+			card.AddAddress(&vcard.Address{
+				PostOfficeBox:   parts[0],
+				ExtendedAddress: parts[1],
+				StreetAddress:   parts[2],
+				Locality:        parts[3],
+				Region:          parts[4],
+				PostalCode:      parts[5],
+				Country:         parts[6],
+			})
+		case "TEL-AV":
+			card.Add(vcard.FieldTelephone, &vcard.Field{
+				Value:  unescape(v),
+				Params: vcard.Params{vcard.ParamType: {vcard.TypeVideo}},
+			})
+		default:
+			if k, ok := meCardFields[k]; ok {
+				card.AddValue(k, unescape(v))
+			}
+		}
+	}
+
+	if name := card.Name(); name != nil {
+		fn := name.FamilyName
+		if name.GivenName != "" {
+			fn = name.GivenName + " " + fn
+		}
+		card.SetValue(vcard.FieldFormattedName, strings.TrimSpace(fn))
+	} else {
+		return nil, errors.New("qrcard: missing N field")
+	}
+	return card, nil
+}
+
+func unescape(s string) string {
+	if !strings.Contains(s, `\`) {
+		return s
+	}
+	var sb strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' && i+1 < len(s) {
+			i++
+		}
+		sb.WriteByte(s[i])
+	}
+	return sb.String()
+}
+
+// MeCard fields mapped to a single vCard property.
+var meCardFields = map[string]string{
+	"TEL":      vcard.FieldTelephone,
+	"EMAIL":    vcard.FieldEmail,
+	"NOTE":     vcard.FieldNote,
+	"BDAY":     vcard.FieldBirthday,
+	"URL":      vcard.FieldURL,
+	"NICKNAME": vcard.FieldNickname,
+	"ORG":      vcard.FieldOrganization,
+	"TITLE":    vcard.FieldTitle,
+}
@@ -0,0 +1,80 @@
+package qrcard
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/emersion/go-vcard"
+)
+
+var testCard = vcard.Card{
+	vcard.FieldVersion:       {{Value: "4.0"}},
+	vcard.FieldFormattedName: {{Value: "Taro Yamada"}},
+	vcard.FieldName:          {{Value: "Yamada;Taro;;;"}},
+	vcard.FieldTelephone: {
+		{Value: "tel:+81-3-1234-5678", Params: vcard.Params{vcard.ParamType: {"work"}}},
+		{Value: "+81-90-1234-5678", Params: vcard.Params{vcard.ParamType: {"video"}}},
+	},
+	vcard.FieldEmail:    {{Value: "taro@example.jp"}},
+	vcard.FieldAddress:  {{Value: ";;1-2-3 Chiyoda;Tokyo;;100-0001;Japan"}},
+	vcard.FieldBirthday: {{Value: "1980-04-01"}},
+	vcard.FieldNote:     {{Value: "Met at GopherCon; likes Go: a lot"}},
+	vcard.FieldPhoto:    {{Value: "data:image/jpeg;base64,/9j/4AAQSkZJRgABAQ"}},
+}
+
+const testMeCard = `MECARD:N:Yamada,Taro;TEL:+81-3-1234-5678;TEL-AV:+81-90-1234-5678;EMAIL:taro@example.jp;` +
+	`ADR:,,1-2-3 Chiyoda,Tokyo,,100-0001,Japan;BDAY:19800401;NOTE:Met at GopherCon\; likes Go\: a lot;;`
+
+func TestMeCard(t *testing.T) {
+	if s := MeCard(testCard); s != testMeCard {
+		t.Errorf("MeCard() = \n%v\nbut want:\n%v", s, testMeCard)
+	}
+}
+
+func TestParseMeCard(t *testing.T) {
+	card, err := ParseMeCard(testMeCard)
+	if err != nil {
+		t.Fatalf("ParseMeCard() = %v", err)
+	}
+
+	want := vcard.Card{
+		vcard.FieldVersion:       {{Value: "4.0"}},
+		vcard.FieldFormattedName: {{Value: "Taro Yamada"}},
+		vcard.FieldName:          {{Value: "Yamada;Taro;;;"}},
+		vcard.FieldTelephone: {
+			{Value: "+81-3-1234-5678"},
+			{Value: "+81-90-1234-5678", Params: vcard.Params{vcard.ParamType: {"video"}}},
+		},
+		vcard.FieldEmail:    {{Value: "taro@example.jp"}},
+		vcard.FieldAddress:  {{Value: ";;1-2-3 Chiyoda;Tokyo;;100-0001;Japan"}},
+		vcard.FieldBirthday: {{Value: "19800401"}},
+		vcard.FieldNote:     {{Value: "Met at GopherCon; likes Go: a lot"}},
+	}
+	if !reflect.DeepEqual(card, want) {
+		t.Errorf("ParseMeCard() = \n%#v\nbut want:\n%#v", card, want)
+	}
+}
+
+func TestParseMeCard_zxing(t *testing.T) {
+	card, err := ParseMeCard(`mecard:N:Sean Owen;ORG:Google;ADR:76 9th Avenue\, New York;;`)
+	if err != nil {
+		t.Fatalf("ParseMeCard() = %v", err)
+	}
+
+	want := vcard.Card{
+		vcard.FieldVersion:       {{Value: "4.0"}},
+		vcard.FieldFormattedName: {{Value: "Sean Owen"}},
+		vcard.FieldName:          {{Value: "Sean Owen;;;;"}},
+		vcard.FieldOrganization:  {{Value: "Google"}},
+		vcard.FieldAddress:       {{Value: ";;76 9th Avenue, New York;;;;"}},
+	}
+	if !reflect.DeepEqual(card, want) {
+		t.Errorf("ParseMeCard() = \n%#v\nbut want:\n%#v", card, want)
+	}
+}
+
+func TestParseMeCard_invalid(t *testing.T) {
+	if _, err := ParseMeCard("BEGIN:VCARD"); err == nil {
+		t.Error("ParseMeCard() = nil, want an error")
+	}
+}
@@ -0,0 +1,319 @@
+package vcard
+
+import (
+	"strings"
+	"sync"
+)
+
+// ValueType is a property value data type, defined in RFC 6350 section 4.
+type ValueType string
+
+// Values for ParamValue.
+const (
+	ValueText          ValueType = "text"
+	ValueURI           ValueType = "uri"
+	ValueDate          ValueType = "date"
+	ValueTime          ValueType = "time"
+	ValueDateTime      ValueType = "date-time"
+	ValueDateAndOrTime ValueType = "date-and-or-time"
+	ValueTimestamp     ValueType = "timestamp"
+	ValueBoolean       ValueType = "boolean"
+	ValueInteger       ValueType = "integer"
+	ValueFloat         ValueType = "float"
+	ValueUTCOffset     ValueType = "utc-offset"
+	ValueLanguageTag   ValueType = "language-tag"
+)
+
+// Cardinality is the number of instances of a property a card may contain,
+// defined in RFC 6350 section 6.
+type Cardinality int
+
+const (
+	// Zero or more instances ("*").
+	CardinalityAny Cardinality = iota
+	// Exactly one instance ("1").
+	CardinalityOne
+	// Zero or one instance ("*1").
+	CardinalityAtMostOne
+	// One or more instances ("1*").
+	CardinalityAtLeastOne
+)
+
+// Versions of the vCard format.
+const (
+	Version30 = "3.0"
+	Version40 = "4.0"
+)
+
+// PropertyInfo describes a property.
+type PropertyInfo struct {
+	// Name is the property name, e.g. "FN" or "X-ABLABEL".
+	Name string
+	// ValueTypes lists the allowed value types. The first one is the
+	// default. If empty, the value is free-form text.
+	ValueTypes  []ValueType
+	Cardinality Cardinality
+	// Structured is true if the value is made of several components
+	// separated by semicolons, e.g. FieldName.
+	Structured bool
+	// List is true if the value is a list of values separated by commas,
+	// e.g. FieldCategories.
+	List bool
+	// Params lists the allowed parameters, in addition to the extension
+	// parameters. If nil, any parameter is allowed.
+	Params []string
+	// Versions lists the vCard versions defining the property. If nil, the
+	// property exists in all versions.
+	Versions []string
+}
+
+// DefaultValueType returns the value type used when the ParamValue parameter
+// is missing.
+func (info *PropertyInfo) DefaultValueType() ValueType {
+	if len(info.ValueTypes) == 0 {
+		return ValueText
+	}
+	return info.ValueTypes[0]
+}
+
+// HasParam returns true if the parameter k may be used with the property.
+// Extension parameters, starting with "X-", are always allowed.
+func (info *PropertyInfo) HasParam(k string) bool {
+	if info.Params == nil || isExtension(k) {
+		return true
+	}
+	if param := LookupParam(k); param != nil && param.AnyProperty {
+		return true
+	}
+	return containsFold(info.Params, k)
+}
+
+// HasVersion returns true if the property is defined in the vCard version v.
+func (info *PropertyInfo) HasVersion(v string) bool {
+	return hasVersion(info.Versions, v)
+}
+
+func hasVersion(versions []string, v string) bool {
+	if versions == nil {
+		return true
+	}
+	for _, vv := range versions {
+		if vv == v {
+			return true
+		}
+	}
+	return false
+}
+
+// ParamInfo describes a property parameter.
+type ParamInfo struct {
+	// Name is the parameter name, e.g. "TYPE".
+	Name string
+	// ValueType is the parameter value type. If empty, the value is
+	// free-form text.
+	ValueType ValueType
+	// List is true if the parameter may have multiple values.
+	List bool
+	// AnyProperty is true if the parameter may be used with any property.
+	AnyProperty bool
+	// Versions lists the vCard versions defining the parameter. If nil, the
+	// parameter exists in all versions.
+	Versions []string
+}
+
+// HasVersion returns true if the parameter is defined in the vCard version v.
+func (info *ParamInfo) HasVersion(v string) bool {
+	return hasVersion(info.Versions, v)
+}
+
+var registry = struct {
+	sync.RWMutex
+	props  map[string]*PropertyInfo
+	params map[string]*ParamInfo
+}{
+	props:  make(map[string]*PropertyInfo),
+	params: make(map[string]*ParamInfo),
+}
+
+// RegisterProperty registers a property. Registering an already known
+// property replaces it. This is typically used by applications to describe
+// their own extension properties.
+func RegisterProperty(info *PropertyInfo) {
+	if info.Name == "" {
+		panic("vcard: cannot register property with empty name")
+	}
+	registry.Lock()
+	registry.props[strings.ToUpper(info.Name)] = info
+	registry.Unlock()
+}
+
+// LookupProperty returns the description of the property k. It returns nil if
+// the property isn't registered.
+func LookupProperty(k string) *PropertyInfo {
+	registry.RLock()
+	defer registry.RUnlock()
+	return registry.props[strings.ToUpper(k)]
+}
+
+// RegisterParam registers a property parameter. Registering an already known
+// parameter replaces it.
+func RegisterParam(info *ParamInfo) {
+	if info.Name == "" {
+		panic("vcard: cannot register parameter with empty name")
+	}
+	registry.Lock()
+	registry.params[strings.ToUpper(info.Name)] = info
+	registry.Unlock()
+}
+
+// LookupParam returns the description of the parameter k. It returns nil if
+// the parameter isn't registered.
+func LookupParam(k string) *ParamInfo {
+	registry.RLock()
+	defer registry.RUnlock()
+	return registry.params[strings.ToUpper(k)]
+}
+
+// valueType returns the value type of the field f for the property k.
+func valueType(k string, f *Field) ValueType {
+	if v := f.Params.Get(ParamValue); v != "" {
+		return ValueType(strings.ToLower(v))
+	}
+	if info := LookupProperty(k); info != nil {
+		return info.DefaultValueType()
+	}
+	return ValueText
+}
+
+func isExtension(k string) bool {
+	return len(k) > 2 && strings.EqualFold(k[:2], "X-")
+}
+
+func containsFold(l []string, s string) bool {
+	for _, v := range l {
+		if strings.EqualFold(v, s) {
+			return true
+		}
+	}
+	return false
+}
+
+var (
+	v4Only  = []string{Version40}
+	v3Only  = []string{Version30}
+	textURI = []ValueType{ValueText, ValueURI}
+	uriOnly = []ValueType{ValueURI}
+)
+
+func init() {
+	for _, info := range []*PropertyInfo{
+		// General Properties
+		{Name: FieldSource, ValueTypes: uriOnly, Params: []string{ParamValue, ParamPID, ParamPreferred, ParamAltID, ParamMediaType}},
+		{Name: FieldKind, Cardinality: CardinalityAtMostOne, Params: []string{ParamValue}, Versions: v4Only},
+		{Name: FieldXML, Params: []string{ParamValue, ParamAltID}, Versions: v4Only},
+
+		// Identification Properties
+		{Name: FieldFormattedName, Cardinality: CardinalityAtLeastOne, Params: []string{ParamValue, ParamType, ParamLanguage, ParamAltID, ParamPID, ParamPreferred}},
+		{Name: FieldName, Cardinality: CardinalityAtMostOne, Structured: true, Params: []string{ParamValue, ParamSortAs, ParamLanguage, ParamAltID, ParamPhonetic, ParamScript}},
+		{Name: FieldNickname, List: true, Params: []string{ParamValue, ParamType, ParamLanguage, ParamAltID, ParamPID, ParamPreferred}},
+		{Name: FieldPhoto, ValueTypes: uriOnly, Params: []string{ParamValue, ParamAltID, ParamType, ParamMediaType, ParamPreferred, ParamPID}},
+		{Name: FieldBirthday, ValueTypes: []ValueType{ValueDateAndOrTime, ValueText}, Cardinality: CardinalityAtMostOne, Params: []string{ParamValue, ParamAltID, ParamCalendarScale, ParamLanguage}},
+		{Name: FieldAnniversary, ValueTypes: []ValueType{ValueDateAndOrTime, ValueText}, Cardinality: CardinalityAtMostOne, Params: []string{ParamValue, ParamAltID, ParamCalendarScale}, Versions: v4Only},
+		{Name: FieldGender, Cardinality: CardinalityAtMostOne, Structured: true, Params: []string{ParamValue}, Versions: v4Only},
+
+		// Delivery Addressing Properties
+		{Name: FieldAddress, Structured: true, Params: []string{ParamValue, ParamLabel, ParamLanguage, ParamGeolocation, ParamTimezone, ParamAltID, ParamPID, ParamPreferred, ParamType, ParamPhonetic, ParamScript}},
+		{Name: FieldLabel, Versions: v3Only},
+
+		// Communications Properties
+		{Name: FieldTelephone, ValueTypes: textURI, Params: []string{ParamValue, ParamType, ParamPID, ParamPreferred, ParamAltID, ParamMediaType}},
+		{Name: FieldEmail, Params: []string{ParamValue, ParamPID, ParamPreferred, ParamType, ParamAltID}},
+		{Name: FieldIMPP, ValueTypes: uriOnly, Params: []string{ParamValue, ParamPID, ParamPreferred, ParamType, ParamMediaType, ParamAltID, ParamServiceType, ParamUsername}},
+		{Name: FieldLanguage, ValueTypes: []ValueType{ValueLanguageTag}, Params: []string{ParamValue, ParamPID, ParamPreferred, ParamAltID, ParamType}, Versions: v4Only},
+
+		// Geographical Properties
+		{Name: FieldTimezone, ValueTypes: []ValueType{ValueText, ValueURI, ValueUTCOffset}, Params: []string{ParamValue, ParamAltID, ParamPID, ParamPreferred, ParamType, ParamMediaType}},
+		{Name: FieldGeolocation, ValueTypes: uriOnly, Params: []string{ParamValue, ParamPID, ParamPreferred, ParamType, ParamMediaType, ParamAltID}},
+
+		// Organizational Properties
+		{Name: FieldTitle, Params: []string{ParamValue, ParamLanguage, ParamPID, ParamPreferred, ParamAltID, ParamType}},
+		{Name: FieldRole, Params: []string{ParamValue, ParamLanguage, ParamPID, ParamPreferred, ParamAltID, ParamType}},
+		{Name: FieldLogo, ValueTypes: uriOnly, Params: []string{ParamValue, ParamLanguage, ParamPID, ParamPreferred, ParamType, ParamMediaType, ParamAltID}},
+		{Name: FieldOrganization, Structured: true, Params: []string{ParamValue, ParamSortAs, ParamLanguage, ParamPID, ParamPreferred, ParamAltID, ParamType, ParamLabel, ParamPhonetic, ParamScript}},
+		{Name: FieldMember, ValueTypes: uriOnly, Params: []string{ParamValue, ParamPID, ParamPreferred, ParamAltID, ParamMediaType}, Versions: v4Only},
+		{Name: FieldRelated, ValueTypes: textURI, Params: []string{ParamValue, ParamMediaType, ParamLanguage, ParamPID, ParamPreferred, ParamAltID, ParamType}, Versions: v4Only},
+
+		// Explanatory Properties
+		{Name: FieldCategories, List: true, Params: []string{ParamValue, ParamPID, ParamPreferred, ParamType, ParamAltID}},
+		{Name: FieldNote, Params: []string{ParamValue, ParamLanguage, ParamPID, ParamPreferred, ParamType, ParamAltID}},
+		{Name: FieldProductID, Cardinality: CardinalityAtMostOne, Params: []string{ParamValue}},
+		{Name: FieldRevision, ValueTypes: []ValueType{ValueTimestamp}, Cardinality: CardinalityAtMostOne, Params: []string{ParamValue}},
+		{Name: FieldSound, ValueTypes: uriOnly, Params: []string{ParamValue, ParamLanguage, ParamPID, ParamPreferred, ParamType, ParamMediaType, ParamAltID}},
+		{Name: FieldUID, ValueTypes: []ValueType{ValueURI, ValueText}, Cardinality: CardinalityAtMostOne, Params: []string{ParamValue}},
+		{Name: FieldClientPIDMap, Structured: true, Params: []string{}, Versions: v4Only},
+		{Name: FieldURL, ValueTypes: uriOnly, Params: []string{ParamValue, ParamPID, ParamPreferred, ParamType, ParamMediaType, ParamAltID}},
+		{Name: FieldVersion, Cardinality: CardinalityOne, Params: []string{}},
+
+		// Security Properties
+		{Name: FieldKey, ValueTypes: []ValueType{ValueURI, ValueText}, Params: []string{ParamValue, ParamMediaType, ParamPID, ParamPreferred, ParamAltID, ParamType}},
+
+		// Calendar Properties
+		{Name: FieldFreeOrBusyURL, ValueTypes: uriOnly, Params: []string{ParamValue, ParamPID, ParamPreferred, ParamType, ParamMediaType, ParamAltID}},
+		{Name: FieldCalendarAddressURI, ValueTypes: uriOnly, Params: []string{ParamValue, ParamPID, ParamPreferred, ParamType, ParamMediaType, ParamAltID}},
+		{Name: FieldCalendarURI, ValueTypes: uriOnly, Params: []string{ParamValue, ParamPID, ParamPreferred, ParamType, ParamMediaType, ParamAltID}},
+
+		// RFC 6474
+		{Name: FieldBirthPlace, ValueTypes: textURI, Cardinality: CardinalityAtMostOne, Params: []string{ParamValue, ParamAltID, ParamLanguage}, Versions: v4Only},
+		{Name: FieldDeathPlace, ValueTypes: textURI, Cardinality: CardinalityAtMostOne, Params: []string{ParamValue, ParamAltID, ParamLanguage}, Versions: v4Only},
+		{Name: FieldDeathDate, ValueTypes: []ValueType{ValueDateAndOrTime, ValueText}, Cardinality: CardinalityAtMostOne, Params: []string{ParamValue, ParamAltID, ParamCalendarScale, ParamLanguage}, Versions: v4Only},
+
+		// RFC 6715
+		{Name: FieldExpertise, Params: []string{ParamLevel, ParamIndex, ParamLanguage, ParamPreferred, ParamAltID, ParamType}, Versions: v4Only},
+		{Name: FieldHobby, Params: []string{ParamLevel, ParamIndex, ParamLanguage, ParamPreferred, ParamAltID, ParamType}, Versions: v4Only},
+		{Name: FieldInterest, Params: []string{ParamLevel, ParamIndex, ParamLanguage, ParamPreferred, ParamAltID, ParamType}, Versions: v4Only},
+		{Name: FieldOrgDirectory, ValueTypes: uriOnly, Params: []string{ParamPreferred, ParamIndex, ParamLanguage, ParamPID, ParamAltID, ParamType}, Versions: v4Only},
+
+		// RFC 9554
+		{Name: FieldCreated, ValueTypes: []ValueType{ValueTimestamp}, Cardinality: CardinalityAtMostOne, Params: []string{ParamValue}, Versions: v4Only},
+		{Name: FieldGrammaticalGender, Params: []string{ParamValue, ParamLanguage}, Versions: v4Only},
+		{Name: FieldDefaultLanguage, ValueTypes: []ValueType{ValueLanguageTag}, Cardinality: CardinalityAtMostOne, Params: []string{ParamValue}, Versions: v4Only},
+		{Name: FieldPronouns, Params: []string{ParamValue, ParamLanguage, ParamPreferred, ParamType, ParamAltID}, Versions: v4Only},
+		{Name: FieldSocialProfile, ValueTypes: []ValueType{ValueURI, ValueText}, Params: []string{ParamValue, ParamServiceType, ParamUsername, ParamPID, ParamPreferred, ParamAltID, ParamType}, Versions: v4Only},
+	} {
+		RegisterProperty(info)
+	}
+
+	for _, info := range []*ParamInfo{
+		{Name: ParamLanguage, ValueType: ValueLanguageTag},
+		{Name: ParamValue},
+		{Name: ParamPreferred, ValueType: ValueInteger},
+		{Name: ParamAltID},
+		{Name: ParamPID, List: true},
+		{Name: ParamType, List: true},
+		{Name: ParamMediaType},
+		{Name: ParamCalendarScale},
+		{Name: ParamSortAs, List: true},
+		{Name: ParamGeolocation, ValueType: ValueURI},
+		{Name: ParamTimezone},
+		{Name: ParamLabel, Versions: v4Only},
+		{Name: ParamEncoding, Versions: v3Only},
+		{Name: ParamCharset},
+
+		// RFC 6715
+		{Name: ParamLevel},
+		{Name: ParamIndex, ValueType: ValueInteger},
+
+		// RFC 9554
+		{Name: ParamAuthor, ValueType: ValueURI, AnyProperty: true, Versions: v4Only},
+		{Name: ParamAuthorName, AnyProperty: true, Versions: v4Only},
+		{Name: ParamCreated, ValueType: ValueTimestamp, AnyProperty: true, Versions: v4Only},
+		{Name: ParamDerived, ValueType: ValueBoolean, AnyProperty: true, Versions: v4Only},
+		{Name: ParamPhonetic, Versions: v4Only},
+		{Name: ParamPropID, AnyProperty: true, Versions: v4Only},
+		{Name: ParamScript, Versions: v4Only},
+		{Name: ParamServiceType, Versions: v4Only},
+		{Name: ParamUsername, Versions: v4Only},
+	} {
+		RegisterParam(info)
+	}
+}
@@ -0,0 +1,81 @@
+package vcard
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestLookupProperty(t *testing.T) {
+	info := LookupProperty("fn")
+	if info == nil {
+		t.Fatal("Expected FN to be registered")
+	}
+	if info.Cardinality != CardinalityAtLeastOne {
+		t.Errorf("Expected FN cardinality to be %v, got %v", CardinalityAtLeastOne, info.Cardinality)
+	}
+	if !info.HasParam(ParamLanguage) || !info.HasParam("x-foo") {
+		t.Error("Expected FN to allow LANGUAGE and X- parameters")
+	}
+	if info.HasParam(ParamSortAs) {
+		t.Error("Expected FN to disallow SORT-AS parameter")
+	}
+
+	if info := LookupProperty(FieldKind); info.HasVersion(Version30) || !info.HasVersion(Version40) {
+		t.Error("Expected KIND to only exist in vCard 4.0")
+	}
+	if info := LookupProperty("X-IDONTEXIST"); info != nil {
+		t.Errorf("Expected X-IDONTEXIST not to be registered, got %+v", info)
+	}
+}
+
+func TestRegisterProperty(t *testing.T) {
+	RegisterProperty(&PropertyInfo{
+		Name:       "X-TEST-LIST",
+		ValueTypes: []ValueType{ValueText},
+		List:       true,
+	})
+	defer func() {
+		registry.Lock()
+		delete(registry.props, "X-TEST-LIST")
+		registry.Unlock()
+	}()
+
+	info := LookupProperty("x-test-list")
+	if info == nil || !info.List {
+		t.Fatalf("Expected X-TEST-LIST to be a registered list property, got %+v", info)
+	}
+
+	card := Card{
+		FieldVersion:  {{Value: "4.0"}},
+		"X-TEST-LIST": {{Value: "a,b"}},
+	}
+	var b bytes.Buffer
+	if err := NewEncoder(&b).Encode(card); err != nil {
+		t.Fatal("Expected no error when formatting card, got:", err)
+	}
+	if !strings.Contains(b.String(), "X-TEST-LIST:a,b\r\n") {
+		t.Errorf("Expected list value not to be escaped, got %q", b.String())
+	}
+}
+
+var testFieldValue = []struct {
+	k         string
+	f         *Field
+	formatted string
+}{
+	{FieldNote, &Field{Value: "a, b"}, "a\\, b"},
+	{FieldCategories, &Field{Value: "a,b"}, "a,b"},
+	{FieldPhoto, &Field{Value: "data:image/png;base64,AAAA"}, "data:image/png;base64,AAAA"},
+	{FieldTelephone, &Field{Value: "a,b"}, "a\\,b"},
+	{FieldTelephone, &Field{Value: "tel:a,b", Params: Params{ParamValue: {"uri"}}}, "tel:a,b"},
+	{"X-UNKNOWN", &Field{Value: "a,b\nc"}, "a\\,b\\nc"},
+}
+
+func TestFormatFieldValue(t *testing.T) {
+	for _, test := range testFieldValue {
+		if formatted := formatFieldValue(test.k, test.f); formatted != test.formatted {
+			t.Errorf("formatFieldValue(%q, %q): expected %q, got %q", test.k, test.f.Value, test.formatted, formatted)
+		}
+	}
+}
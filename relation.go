@@ -0,0 +1,192 @@
+package vcard
+
+import (
+	"strings"
+)
+
+// inverseRelations maps relation types to the type the related entity is
+// expected to have in return. Symmetric types map to themselves.
+var inverseRelations = map[string]string{
+	TypeAcquaintance: TypeAcquaintance,
+	TypeFriend:       TypeFriend,
+	TypeMet:          TypeMet,
+	TypeCoWorker:     TypeCoWorker,
+	TypeColleague:    TypeColleague,
+	TypeCoResident:   TypeCoResident,
+	TypeNeighbor:     TypeNeighbor,
+	TypeChild:        TypeParent,
+	TypeParent:       TypeChild,
+	TypeSibling:      TypeSibling,
+	TypeSpouse:       TypeSpouse,
+	TypeKin:          TypeKin,
+	TypeSweetheart:   TypeSweetheart,
+	TypeDate:         TypeDate,
+}
+
+// RelationIssueKind describes an inconsistency in relationships.
+type RelationIssueKind int
+
+const (
+	// RelationDangling means a "urn:uuid:" URI doesn't refer to any card.
+	RelationDangling RelationIssueKind = iota + 1
+	// RelationAsymmetric means the related card doesn't have the inverse
+	// relationship, e.g. a spouse relationship in only one direction.
+	RelationAsymmetric
+)
+
+// A RelationIssue is an inconsistency found in the relationships of a card.
+type RelationIssue struct {
+	Kind RelationIssueKind
+	// UID of the card with the relationship
+	UID     string
+	Related *Related
+	// Type is the relationship type missing on the related card, for
+	// RelationAsymmetric
+	Type string
+}
+
+// A RelationGraph contains the relationships between cards, defined with the
+// RELATED property. URIs are resolved like group members: see
+// AddressBook.Members.
+//
+// A RelationGraph is a snapshot: it isn't updated when the cards are
+// modified.
+type RelationGraph struct {
+	cards []Card
+	byUID map[string]Card
+	byURI map[string][]Card
+}
+
+// NewRelationGraph creates a new relationship graph for a list of cards, e.g.
+// the cards of an address book. Cards without a UID can't be related to.
+func NewRelationGraph(cards []Card) *RelationGraph {
+	g := &RelationGraph{
+		cards: cards,
+		byUID: make(map[string]Card),
+		byURI: indexCardsByURI(cards),
+	}
+	for _, card := range cards {
+		if uid := card.Value(FieldUID); uid != "" {
+			g.byUID[uid] = card
+		}
+	}
+	return g
+}
+
+// Related returns the cards related to the card with the specified UID with
+// the specified relationship type, e.g. TypeEmergency for the emergency
+// contacts or TypeChild for the children. If relType is empty, all related
+// cards are returned. Relationships which don't refer to a card are ignored.
+func (g *RelationGraph) Related(uid, relType string) []Card {
+	card, ok := g.byUID[uid]
+	if !ok {
+		return nil
+	}
+
+	var related []Card
+	seen := make(map[string]bool)
+	for _, r := range card.Related() {
+		if relType != "" && !r.HasType(relType) {
+			continue
+		}
+		for _, other := range g.resolve(r) {
+			if otherUID := other.Value(FieldUID); !seen[otherUID] {
+				seen[otherUID] = true
+				related = append(related, other)
+			}
+		}
+	}
+	return related
+}
+
+// Issues returns the dangling and asymmetric relationships of the cards.
+func (g *RelationGraph) Issues() []RelationIssue {
+	var issues []RelationIssue
+	for _, card := range g.cards {
+		uid := card.Value(FieldUID)
+		for _, r := range card.Related() {
+			targets := g.resolve(r)
+			if len(targets) == 0 {
+				if r.IsURI() && hasPrefixFold(strings.TrimSpace(r.Value), "urn:uuid:") {
+					issues = append(issues, RelationIssue{
+						Kind:    RelationDangling,
+						UID:     uid,
+						Related: r,
+					})
+				}
+				continue
+			}
+			if uid == "" {
+				continue
+			}
+
+			for _, t := range r.Types {
+				inverse, ok := inverseRelations[strings.ToLower(t)]
+				if !ok {
+					continue
+				}
+				for _, other := range targets {
+					if !g.hasRelation(other, uid, inverse) {
+						issues = append(issues, RelationIssue{
+							Kind:    RelationAsymmetric,
+							UID:     uid,
+							Related: r,
+							Type:    inverse,
+						})
+					}
+				}
+			}
+		}
+	}
+	return issues
+}
+
+// hasRelation checks whether card has a relationship of the specified type
+// with the card with the specified UID.
+func (g *RelationGraph) hasRelation(card Card, uid, relType string) bool {
+	for _, r := range card.Related() {
+		if !r.HasType(relType) {
+			continue
+		}
+		for _, other := range g.resolve(r) {
+			if other.Value(FieldUID) == uid {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (g *RelationGraph) resolve(r *Related) []Card {
+	if !r.IsURI() {
+		return nil
+	}
+	uri := strings.TrimSpace(r.Value)
+	if card, ok := g.byUID[uri]; ok {
+		return []Card{card}
+	}
+	return g.byURI[memberKey(uri)]
+}
+
+// indexCardsByURI maps the keys of the URIs referring to cards to the cards:
+// URIs built from their UID, and "mailto:" URIs built from their email
+// addresses. See uidKeys and memberKey.
+func indexCardsByURI(cards []Card) map[string][]Card {
+	m := make(map[string][]Card)
+	for _, card := range cards {
+		if uid := card.Value(FieldUID); uid != "" {
+			for _, k := range uidKeys(uid) {
+				m[k] = append(m[k], card)
+			}
+		}
+		for _, email := range card.Values(FieldEmail) {
+			k := memberKey("mailto:" + email)
+			m[k] = append(m[k], card)
+		}
+	}
+	return m
+}
+
+func hasPrefixFold(s, prefix string) bool {
+	return len(s) >= len(prefix) && strings.EqualFold(s[:len(prefix)], prefix)
+}
@@ -0,0 +1,77 @@
+package vcard
+
+import (
+	"reflect"
+	"testing"
+)
+
+func testRelatedCard(uid string, related ...*Related) Card {
+	card := newAddressBookTestCard(uid, uid)
+	for _, r := range related {
+		card.AddRelated(r)
+	}
+	return card
+}
+
+func testRelated(uri string, types ...string) *Related {
+	return &Related{Field: &Field{Value: uri}, Types: types}
+}
+
+func testRelationGraph() *RelationGraph {
+	return NewRelationGraph([]Card{
+		testRelatedCard("alice",
+			testRelated("urn:uuid:bob", TypeSpouse, TypeEmergency),
+			testRelated("urn:uuid:carol", TypeChild),
+			testRelated("urn:uuid:dave", TypeChild),
+			testRelated("mailto:erin@example.com", TypeEmergency),
+			testRelated("urn:uuid:unknown", TypeFriend),
+			&Related{Field: &Field{Value: "My lawyer", Params: Params{ParamValue: {"text"}}}, Types: []string{TypeAgent}},
+		),
+		testRelatedCard("bob", testRelated("urn:uuid:alice", TypeSpouse)),
+		testRelatedCard("carol", testRelated("urn:uuid:alice", TypeParent)),
+		testRelatedCard("dave"),
+		{FieldUID: {{Value: "urn:uuid:erin"}}, FieldEmail: {{Value: "erin@example.com"}}},
+	})
+}
+
+func TestRelationGraph_Related(t *testing.T) {
+	g := testRelationGraph()
+
+	tests := []struct {
+		uid, relType string
+		want         []string
+	}{
+		{"alice", TypeEmergency, []string{"bob", "urn:uuid:erin"}},
+		{"alice", TypeChild, []string{"carol", "dave"}},
+		{"alice", "", []string{"bob", "carol", "dave", "urn:uuid:erin"}},
+		{"alice", TypeSibling, nil},
+		{"carol", TypeParent, []string{"alice"}},
+		{"unknown", "", nil},
+	}
+	for _, tc := range tests {
+		if got := addressBookUIDs(g.Related(tc.uid, tc.relType)); !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("Related(%q, %q) = %v, want %v", tc.uid, tc.relType, got, tc.want)
+		}
+	}
+}
+
+func TestRelationGraph_Issues(t *testing.T) {
+	issues := testRelationGraph().Issues()
+
+	type issue struct {
+		kind     RelationIssueKind
+		uid, uri string
+		inverse  string
+	}
+	var got []issue
+	for _, i := range issues {
+		got = append(got, issue{i.Kind, i.UID, i.Related.Value, i.Type})
+	}
+	want := []issue{
+		{RelationAsymmetric, "alice", "urn:uuid:dave", TypeParent},
+		{RelationDangling, "alice", "urn:uuid:unknown", ""},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Issues() = %+v, want %+v", got, want)
+	}
+}
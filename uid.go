@@ -0,0 +1,88 @@
+package vcard
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"fmt"
+	"strings"
+	"time"
+)
+
+const uuidURNPrefix = "urn:uuid:"
+
+// NewUIDv4 generates a random UUID URN, e.g.
+// "urn:uuid:03a0e51f-d1aa-4385-8a53-e29025acd8af", as recommended by RFC 6350
+// section 6.7.6.
+func NewUIDv4() (string, error) {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return "", err
+	}
+	return formatUUID(b, 4), nil
+}
+
+// NewUIDv7 generates a time-ordered UUID URN, defined in RFC 9562. UIDs
+// generated later sort after UIDs generated earlier, with millisecond
+// precision.
+func NewUIDv7() (string, error) {
+	return newUIDv7(time.Now())
+}
+
+func newUIDv7(t time.Time) (string, error) {
+	var b [16]byte
+	if _, err := rand.Read(b[6:]); err != nil {
+		return "", err
+	}
+	var ms [8]byte
+	binary.BigEndian.PutUint64(ms[:], uint64(t.UnixNano()/int64(time.Millisecond)))
+	copy(b[:6], ms[2:])
+	return formatUUID(b, 7), nil
+}
+
+func formatUUID(b [16]byte, version byte) string {
+	b[6] = (b[6] & 0x0f) | version<<4
+	b[8] = (b[8] & 0x3f) | 0x80
+	return fmt.Sprintf("%v%x-%x-%x-%x-%x", uuidURNPrefix, b[:4], b[4:6], b[6:8], b[8:10], b[10:])
+}
+
+// NormalizeUID returns the canonical spelling of a UID. UUIDs, with or
+// without the "urn:uuid:" prefix and in any case, are converted to a
+// lowercase "urn:uuid:" URN. Other UIDs are only trimmed.
+func NormalizeUID(uid string) string {
+	uid = strings.TrimSpace(uid)
+	s := uid
+	if hasPrefixFold(s, uuidURNPrefix) {
+		s = s[len(uuidURNPrefix):]
+	}
+	if !isUUID(s) {
+		return uid
+	}
+	return uuidURNPrefix + strings.ToLower(s)
+}
+
+// EqualUID checks whether two UIDs refer to the same entity, after
+// normalization. See NormalizeUID.
+func EqualUID(a, b string) bool {
+	return NormalizeUID(a) == NormalizeUID(b)
+}
+
+// isUUID checks whether s is a UUID in the 8-4-4-4-12 hexadecimal format.
+func isUUID(s string) bool {
+	if len(s) != 36 {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch i {
+		case 8, 13, 18, 23:
+			if c != '-' {
+				return false
+			}
+		default:
+			if !('0' <= c && c <= '9' || 'a' <= c && c <= 'f' || 'A' <= c && c <= 'F') {
+				return false
+			}
+		}
+	}
+	return true
+}
@@ -0,0 +1,71 @@
+package vcard
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNewUIDv4(t *testing.T) {
+	uid, err := NewUIDv4()
+	if err != nil {
+		t.Fatalf("NewUIDv4() = %v", err)
+	}
+	if !strings.HasPrefix(uid, "urn:uuid:") || !isUUID(strings.TrimPrefix(uid, "urn:uuid:")) {
+		t.Fatalf("NewUIDv4() = %q, want a UUID URN", uid)
+	}
+	if uid[23] != '4' {
+		t.Errorf("NewUIDv4() = %q, want version 4", uid)
+	}
+	if other, _ := NewUIDv4(); other == uid {
+		t.Errorf("NewUIDv4() returned the same UID twice")
+	}
+}
+
+func TestNewUIDv7(t *testing.T) {
+	t1 := time.Date(2023, 9, 18, 10, 0, 0, 0, time.UTC)
+	uid1, err := newUIDv7(t1)
+	if err != nil {
+		t.Fatalf("NewUIDv7() = %v", err)
+	}
+	uid2, err := newUIDv7(t1.Add(time.Millisecond))
+	if err != nil {
+		t.Fatalf("NewUIDv7() = %v", err)
+	}
+
+	if !isUUID(strings.TrimPrefix(uid1, "urn:uuid:")) || uid1[23] != '7' {
+		t.Errorf("NewUIDv7() = %q, want a version 7 UUID URN", uid1)
+	}
+	// 1695031200000 milliseconds since the epoch
+	if prefix := "urn:uuid:018aa7bb-8900-7"; !strings.HasPrefix(uid1, prefix) {
+		t.Errorf("NewUIDv7() = %q, want prefix %q", uid1, prefix)
+	}
+	if uid1 >= uid2 {
+		t.Errorf("NewUIDv7() isn't time-ordered: %q >= %q", uid1, uid2)
+	}
+}
+
+func TestNormalizeUID(t *testing.T) {
+	tests := []struct {
+		uid, want string
+	}{
+		{"urn:uuid:03a0e51f-d1aa-4385-8a53-e29025acd8af", "urn:uuid:03a0e51f-d1aa-4385-8a53-e29025acd8af"},
+		{"03A0E51F-D1AA-4385-8A53-E29025ACD8AF", "urn:uuid:03a0e51f-d1aa-4385-8a53-e29025acd8af"},
+		{" URN:UUID:03a0e51f-d1aa-4385-8a53-e29025acd8af ", "urn:uuid:03a0e51f-d1aa-4385-8a53-e29025acd8af"},
+		{"urn:uuid:not-a-uuid", "urn:uuid:not-a-uuid"},
+		{"Alice@Example.com", "Alice@Example.com"},
+		{"03a0e51f-d1aa-4385-8a53-e29025acd8a", "03a0e51f-d1aa-4385-8a53-e29025acd8a"},
+	}
+	for _, tc := range tests {
+		if got := NormalizeUID(tc.uid); got != tc.want {
+			t.Errorf("NormalizeUID(%q) = %q, want %q", tc.uid, got, tc.want)
+		}
+	}
+
+	if !EqualUID("03A0E51F-D1AA-4385-8A53-E29025ACD8AF", "urn:uuid:03a0e51f-d1aa-4385-8a53-e29025acd8af") {
+		t.Errorf("EqualUID() = false for the same UUID")
+	}
+	if EqualUID("alice", "Alice") {
+		t.Errorf("EqualUID() = true for different UIDs")
+	}
+}
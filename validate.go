@@ -0,0 +1,143 @@
+package vcard
+
+import (
+	"sort"
+	"strings"
+)
+
+// A ValidationError describes a property or a parameter which doesn't conform
+// to its description in the registry.
+type ValidationError struct {
+	Property string
+	// Param is empty if the error is about the property itself
+	Param  string
+	Reason string
+}
+
+func (err *ValidationError) Error() string {
+	if err.Param != "" {
+		return "vcard: parameter " + err.Param + " of property " + err.Property + ": " + err.Reason
+	}
+	return "vcard: property " + err.Property + ": " + err.Reason
+}
+
+// ValidationErrors is the list of errors returned by Card.Validate.
+type ValidationErrors []*ValidationError
+
+func (errs ValidationErrors) Error() string {
+	l := make([]string, len(errs))
+	for i, err := range errs {
+		l[i] = err.Error()
+	}
+	return strings.Join(l, "; ")
+}
+
+// Validate checks the card against the registered properties and parameters:
+// the number of instances of each property (see Cardinality), the parameters
+// allowed for each property and the vCard versions defining them. Instances
+// of a property sharing the same ParamAltID value count as one. Unregistered
+// properties and parameters are accepted.
+//
+// If the card is invalid, the returned error is a ValidationErrors.
+func (c Card) Validate() error {
+	var errs ValidationErrors
+	add := func(prop, param, reason string) {
+		errs = append(errs, &ValidationError{Property: prop, Param: param, Reason: reason})
+	}
+
+	version := c.Value(FieldVersion)
+	if version != "" && version != Version30 && version != Version40 {
+		add(FieldVersion, "", "unsupported version "+version)
+		version = ""
+	}
+
+	registry.RLock()
+	props := make([]*PropertyInfo, 0, len(registry.props))
+	for _, info := range registry.props {
+		props = append(props, info)
+	}
+	registry.RUnlock()
+	sort.Slice(props, func(i, j int) bool {
+		return props[i].Name < props[j].Name
+	})
+
+	for _, info := range props {
+		if version != "" && !info.HasVersion(version) {
+			continue
+		}
+		n := countInstances(c[info.Name])
+		switch info.Cardinality {
+		case CardinalityOne:
+			if n != 1 {
+				add(info.Name, "", "must have exactly one instance")
+			}
+		case CardinalityAtLeastOne:
+			if n == 0 {
+				add(info.Name, "", "must have at least one instance")
+			}
+		case CardinalityAtMostOne:
+			if n > 1 {
+				add(info.Name, "", "must have at most one instance")
+			}
+		}
+	}
+
+	keys := make([]string, 0, len(c))
+	for k := range c {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		info := LookupProperty(k)
+		if info != nil && version != "" && !info.HasVersion(version) {
+			add(k, "", "not defined in vCard "+version)
+			continue
+		}
+
+		reported := make(map[string]bool)
+		for _, f := range c[k] {
+			for pk := range f.Params {
+				if reported[pk] {
+					continue
+				}
+				if info != nil && !info.HasParam(pk) {
+					add(k, pk, "not allowed")
+					reported[pk] = true
+				} else if param := LookupParam(pk); param != nil && version != "" && !param.HasVersion(version) {
+					add(k, pk, "not defined in vCard "+version)
+					reported[pk] = true
+				}
+			}
+		}
+	}
+
+	if len(errs) == 0 {
+		return nil
+	}
+	sort.SliceStable(errs, func(i, j int) bool {
+		if errs[i].Property != errs[j].Property {
+			return errs[i].Property < errs[j].Property
+		}
+		return errs[i].Param < errs[j].Param
+	})
+	return errs
+}
+
+// countInstances returns the number of instances of a property. Fields with
+// the same ParamAltID value are alternative representations of the same
+// instance.
+func countInstances(fields []*Field) int {
+	n := 0
+	altIDs := make(map[string]bool)
+	for _, f := range fields {
+		altID := f.Params.Get(ParamAltID)
+		if altID == "" {
+			n++
+		} else if !altIDs[altID] {
+			altIDs[altID] = true
+			n++
+		}
+	}
+	return n
+}
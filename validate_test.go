@@ -0,0 +1,54 @@
+package vcard
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCard_Validate(t *testing.T) {
+	if err := testCard.Validate(); err != nil {
+		t.Errorf("Validate() = %v, want nil", err)
+	}
+	if err := testCardHandmade.Validate(); err != nil {
+		t.Errorf("Validate() = %v, want nil", err)
+	}
+
+	card := Card{
+		FieldVersion: {{Value: "3.0"}},
+		FieldName: {
+			{Value: "Doe;John;;;", Params: Params{ParamSortAs: {"Doe"}, ParamAltID: {"1"}}},
+			{Value: "Doe;Jean;;;", Params: Params{ParamAltID: {"1"}}},
+		},
+		FieldRevision: {{Value: "20230918T100000Z"}, {Value: "20230919T100000Z"}},
+		FieldKind:     {{Value: "individual"}},
+		FieldNote:     {{Value: "note", Params: Params{ParamSortAs: {"x"}, ParamLabel: {"y"}, "X-CUSTOM": {"z"}}}},
+		"X-UNKNOWN":   {{Value: "x", Params: Params{"X-PARAM": {"y"}}}},
+	}
+	err := card.Validate()
+	errs, ok := err.(ValidationErrors)
+	if !ok {
+		t.Fatalf("Validate() = %v, want ValidationErrors", err)
+	}
+
+	var got [][3]string
+	for _, err := range errs {
+		got = append(got, [3]string{err.Property, err.Param, err.Reason})
+	}
+	want := [][3]string{
+		{FieldFormattedName, "", "must have at least one instance"},
+		{FieldKind, "", "not defined in vCard 3.0"},
+		{FieldNote, ParamLabel, "not allowed"},
+		{FieldNote, ParamSortAs, "not allowed"},
+		{FieldRevision, "", "must have at most one instance"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Validate() = %v, want %v", got, want)
+	}
+
+	if err := (Card{FieldVersion: {{Value: "2.1"}}, FieldFormattedName: {{Value: "x"}}}).Validate(); err == nil {
+		t.Errorf("Validate() with unsupported version = nil, want an error")
+	}
+	if err := (Card{FieldFormattedName: {{Value: "x"}}}).Validate(); err == nil {
+		t.Errorf("Validate() without VERSION = nil, want an error")
+	}
+}
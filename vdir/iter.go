@@ -0,0 +1,141 @@
+package vdir
+
+import (
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/emersion/go-vcard"
+)
+
+// An Iterator reads the cards of a store one at a time. Files are only opened
+// when needed. Like in Store, files which can't be decoded are skipped.
+type Iterator struct {
+	dir   string
+	names []string
+
+	f    *os.File
+	r    *fileReader
+	dec  *vcard.Decoder
+	path string
+	card vcard.Card
+	err  error
+}
+
+// Iter returns an iterator over the cards of the store, in file name order.
+// The iterator must be closed after use.
+func (s *Store) Iter() (*Iterator, error) {
+	fis, err := ioutil.ReadDir(s.dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var names []string
+	for _, fi := range fis {
+		if isCardFile(fi) {
+			names = append(names, fi.Name())
+		}
+	}
+	sort.Strings(names)
+	return &Iterator{dir: s.dir, names: names}, nil
+}
+
+// Next advances to the next card. It returns false when there are no more
+// cards or when an error occurred.
+func (it *Iterator) Next() bool {
+	if it.err != nil {
+		return false
+	}
+
+	for {
+		if it.dec == nil {
+			if len(it.names) == 0 {
+				return false
+			}
+			p := filepath.Join(it.dir, it.names[0])
+			it.names = it.names[1:]
+
+			f, err := os.Open(p)
+			if os.IsNotExist(err) {
+				// The file has been removed since the iterator was created
+				continue
+			} else if err != nil {
+				it.err = err
+				return false
+			}
+			it.f = f
+			it.r = &fileReader{f: f}
+			it.dec = vcard.NewDecoder(it.r)
+			it.path = p
+		}
+
+		card, err := it.dec.Decode()
+		if err == io.EOF {
+			it.closeFile()
+			continue
+		} else if err != nil && it.r.err != nil {
+			it.err = it.r.err
+			it.closeFile()
+			return false
+		} else if err != nil {
+			// The rest of the file can't be decoded
+			it.closeFile()
+			continue
+		}
+		it.card = card
+		return true
+	}
+}
+
+// Card returns the current card.
+func (it *Iterator) Card() vcard.Card {
+	return it.card
+}
+
+// Path returns the path of the file containing the current card.
+func (it *Iterator) Path() string {
+	return it.path
+}
+
+// Err returns the error which stopped the iteration, if any.
+func (it *Iterator) Err() error {
+	return it.err
+}
+
+// Close releases the resources used by the iterator.
+func (it *Iterator) Close() error {
+	it.names = nil
+	if it.f == nil {
+		return nil
+	}
+	err := it.f.Close()
+	it.f = nil
+	it.r = nil
+	it.dec = nil
+	return err
+}
+
+func (it *Iterator) closeFile() {
+	if it.f != nil {
+		it.f.Close()
+	}
+	it.f = nil
+	it.r = nil
+	it.dec = nil
+}
+
+// fileReader records read errors, to tell them apart from decoding errors.
+type fileReader struct {
+	f   *os.File
+	err error
+}
+
+func (r *fileReader) Read(b []byte) (int, error) {
+	n, err := r.f.Read(b)
+	if err != nil && err != io.EOF {
+		r.err = err
+	}
+	return n, err
+}
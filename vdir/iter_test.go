@@ -0,0 +1,72 @@
+package vdir
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/emersion/go-vcard"
+)
+
+func TestIterator(t *testing.T) {
+	s, cleanup := newTestStore(t)
+	defer cleanup()
+
+	for _, uid := range []string{"b", "a"} {
+		if _, err := s.Put(newTestCard(uid, uid), ""); err != nil {
+			t.Fatalf("Put() = %v", err)
+		}
+	}
+	writeTestFile(t, filepath.Join(s.Dir(), "notes.txt"), "not a card", time.Now())
+
+	it, err := s.Iter()
+	if err != nil {
+		t.Fatalf("Iter() = %v", err)
+	}
+	defer it.Close()
+
+	var uids, names []string
+	for it.Next() {
+		uids = append(uids, it.Card().Value(vcard.FieldUID))
+		names = append(names, filepath.Base(it.Path()))
+	}
+	if err := it.Err(); err != nil {
+		t.Fatalf("Err() = %v", err)
+	}
+	if want := []string{"a", "b"}; !reflect.DeepEqual(uids, want) {
+		t.Errorf("UIDs = %v, want %v", uids, want)
+	}
+	if want := []string{"a.vcf", "b.vcf"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("paths = %v, want %v", names, want)
+	}
+}
+
+func TestIterator_invalid(t *testing.T) {
+	s, cleanup := newTestStore(t)
+	defer cleanup()
+
+	for _, uid := range []string{"a", "c"} {
+		if _, err := s.Put(newTestCard(uid, uid), ""); err != nil {
+			t.Fatalf("Put() = %v", err)
+		}
+	}
+	writeTestFile(t, filepath.Join(s.Dir(), "b.vcf"), "BEGIN:VCARD\r\nFN\r\n", time.Now())
+
+	it, err := s.Iter()
+	if err != nil {
+		t.Fatalf("Iter() = %v", err)
+	}
+	defer it.Close()
+
+	var uids []string
+	for it.Next() {
+		uids = append(uids, it.Card().Value(vcard.FieldUID))
+	}
+	if err := it.Err(); err != nil {
+		t.Fatalf("Err() = %v", err)
+	}
+	if want := []string{"a", "c"}; !reflect.DeepEqual(uids, want) {
+		t.Errorf("UIDs = %v, want %v", uids, want)
+	}
+}
@@ -0,0 +1,401 @@
+// Package vdir implements a card store backed by a directory of vCard files,
+// one file per contact.
+//
+// The layout is compatible with the vdir storage format used by vdirsyncer:
+// files have the ".vcf" extension, are updated atomically by writing a
+// temporary file and renaming it, and hidden files are ignored.
+package vdir
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/emersion/go-vcard"
+)
+
+var (
+	// ErrNotFound is returned when a card doesn't exist in the store.
+	ErrNotFound = errors.New("vdir: card not found")
+	// ErrETagMismatch is returned when a card was modified concurrently.
+	ErrETagMismatch = errors.New("vdir: ETag mismatch")
+)
+
+// An Object describes a card file.
+type Object struct {
+	UID string
+	// Path is the path of the file.
+	Path    string
+	ModTime time.Time
+	Size    int64
+	// ETag is derived from the file contents.
+	ETag string
+}
+
+// Changes lists the cards changed since the last call to Store.Changes.
+type Changes struct {
+	// Updated contains the UIDs of created and modified cards.
+	Updated []string
+	// Deleted contains the UIDs of deleted cards.
+	Deleted []string
+}
+
+// A Store is a directory of card files. It is safe for concurrent use.
+//
+// The store keeps track of the files it has seen. Files whose modification
+// time and size didn't change aren't read again. Files which can't be decoded
+// are ignored.
+type Store struct {
+	dir string
+
+	mutex sync.Mutex
+	// files indexed by name
+	files map[string]*Object
+	// UID to ETag map as of the last call to Changes
+	snapshot map[string]string
+}
+
+// Open opens a store. The directory must exist.
+func Open(dir string) (*Store, error) {
+	fi, err := os.Stat(dir)
+	if err != nil {
+		return nil, err
+	}
+	if !fi.IsDir() {
+		return nil, errors.New("vdir: not a directory")
+	}
+
+	s := &Store{dir: dir, files: make(map[string]*Object)}
+	objs, err := s.scan()
+	if err != nil {
+		return nil, err
+	}
+	s.snapshot = etagsByUID(objs)
+	return s, nil
+}
+
+// Dir returns the directory of the store.
+func (s *Store) Dir() string {
+	return s.dir
+}
+
+// List returns the objects in the store, sorted by UID.
+func (s *Store) List() ([]Object, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	objs, err := s.scan()
+	if err != nil {
+		return nil, err
+	}
+
+	l := make([]Object, 0, len(objs))
+	for _, obj := range objs {
+		l = append(l, *obj)
+	}
+	sort.Slice(l, func(i, j int) bool {
+		return l[i].UID < l[j].UID
+	})
+	return l, nil
+}
+
+// Get reads the card with the specified UID.
+func (s *Store) Get(uid string) (vcard.Card, *Object, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	obj, err := s.lookup(uid)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	b, err := ioutil.ReadFile(obj.Path)
+	if os.IsNotExist(err) {
+		return nil, nil, ErrNotFound
+	} else if err != nil {
+		return nil, nil, err
+	}
+	card, err := vcard.NewDecoder(bytes.NewReader(b)).Decode()
+	if err != nil {
+		return nil, nil, fmt.Errorf("vdir: failed to decode %v: %v", obj.Path, err)
+	}
+
+	cur := *obj
+	cur.ETag = etag(b)
+	return card, &cur, nil
+}
+
+// Put creates or replaces a card. The card must have a UID. If ifMatch isn't
+// empty, the card is only replaced if its current ETag is ifMatch.
+func (s *Store) Put(card vcard.Card, ifMatch string) (*Object, error) {
+	uid := card.Value(vcard.FieldUID)
+	if uid == "" {
+		return nil, errors.New("vdir: card has no UID")
+	}
+
+	var buf bytes.Buffer
+	if err := vcard.NewEncoder(&buf).Encode(card); err != nil {
+		return nil, err
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	name := fileName(uid)
+	obj, err := s.lookup(uid)
+	if err == nil {
+		name = filepath.Base(obj.Path)
+	} else if err != ErrNotFound {
+		return nil, err
+	}
+	if ifMatch != "" && (obj == nil || obj.ETag != ifMatch) {
+		return nil, ErrETagMismatch
+	}
+
+	p := filepath.Join(s.dir, name)
+	if err := writeFile(p, buf.Bytes()); err != nil {
+		return nil, err
+	}
+	fi, err := os.Stat(p)
+	if err != nil {
+		return nil, err
+	}
+
+	obj = &Object{
+		UID:     uid,
+		Path:    p,
+		ModTime: fi.ModTime(),
+		Size:    fi.Size(),
+		ETag:    etag(buf.Bytes()),
+	}
+	s.files[name] = obj
+	s.snapshot[uid] = obj.ETag
+	cur := *obj
+	return &cur, nil
+}
+
+// Delete removes the card with the specified UID. If ifMatch isn't empty, the
+// card is only removed if its current ETag is ifMatch.
+func (s *Store) Delete(uid, ifMatch string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	obj, err := s.lookup(uid)
+	if err != nil {
+		return err
+	}
+	if ifMatch != "" && obj.ETag != ifMatch {
+		return ErrETagMismatch
+	}
+
+	if err := os.Remove(obj.Path); os.IsNotExist(err) {
+		return ErrNotFound
+	} else if err != nil {
+		return err
+	}
+	delete(s.files, filepath.Base(obj.Path))
+	delete(s.snapshot, uid)
+	return nil
+}
+
+// Changes returns the cards modified by other programs since the store was
+// opened or since the last call to Changes. Modifications made with Put and
+// Delete aren't reported.
+func (s *Store) Changes() (*Changes, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	objs, err := s.scan()
+	if err != nil {
+		return nil, err
+	}
+
+	cur := etagsByUID(objs)
+	var changes Changes
+	for uid, etag := range cur {
+		if prev, ok := s.snapshot[uid]; !ok || prev != etag {
+			changes.Updated = append(changes.Updated, uid)
+		}
+	}
+	for uid := range s.snapshot {
+		if _, ok := cur[uid]; !ok {
+			changes.Deleted = append(changes.Deleted, uid)
+		}
+	}
+	sort.Strings(changes.Updated)
+	sort.Strings(changes.Deleted)
+	s.snapshot = cur
+	return &changes, nil
+}
+
+// lookup returns the object for the specified UID, refreshing the list of
+// files if necessary.
+func (s *Store) lookup(uid string) (*Object, error) {
+	// Fast path: the file is named after the UID and didn't change
+	if obj, ok := s.files[fileName(uid)]; ok && obj.UID == uid {
+		if fi, err := os.Stat(obj.Path); err == nil && !changed(obj, fi) {
+			return obj, nil
+		}
+	}
+
+	objs, err := s.scan()
+	if err != nil {
+		return nil, err
+	}
+	for _, obj := range objs {
+		if obj.UID == uid {
+			return obj, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+// scan lists the files of the store. Files which changed since the last scan
+// are read again.
+func (s *Store) scan() ([]*Object, error) {
+	fis, err := ioutil.ReadDir(s.dir)
+	if err != nil {
+		return nil, err
+	}
+
+	files := make(map[string]*Object, len(fis))
+	objs := make([]*Object, 0, len(fis))
+	seen := make(map[string]bool, len(fis))
+	for _, fi := range fis {
+		name := fi.Name()
+		if !isCardFile(fi) {
+			continue
+		}
+
+		obj, ok := s.files[name]
+		if !ok || changed(obj, fi) {
+			obj, err = readObject(filepath.Join(s.dir, name), fi)
+			if os.IsNotExist(err) {
+				continue
+			} else if err != nil {
+				return nil, err
+			}
+		}
+		files[name] = obj
+		if obj.UID == "" {
+			// The file can't be decoded
+			continue
+		}
+		if !seen[obj.UID] {
+			seen[obj.UID] = true
+			objs = append(objs, obj)
+		}
+	}
+	s.files = files
+	return objs, nil
+}
+
+// readObject reads a card file. The UID of the returned object is empty if the
+// file can't be decoded.
+func readObject(p string, fi os.FileInfo) (*Object, error) {
+	b, err := ioutil.ReadFile(p)
+	if err != nil {
+		return nil, err
+	}
+	obj := &Object{
+		Path:    p,
+		ModTime: fi.ModTime(),
+		Size:    fi.Size(),
+		ETag:    etag(b),
+	}
+	card, err := vcard.NewDecoder(bytes.NewReader(b)).Decode()
+	if err != nil {
+		return obj, nil
+	}
+
+	obj.UID = card.Value(vcard.FieldUID)
+	if obj.UID == "" {
+		// Fallback to the file name, like vdirsyncer does
+		obj.UID = strings.TrimSuffix(fi.Name(), "."+vcard.Extension)
+	}
+	return obj, nil
+}
+
+func isCardFile(fi os.FileInfo) bool {
+	name := fi.Name()
+	return fi.Mode().IsRegular() && !strings.HasPrefix(name, ".") && strings.HasSuffix(name, "."+vcard.Extension)
+}
+
+func changed(obj *Object, fi os.FileInfo) bool {
+	return !obj.ModTime.Equal(fi.ModTime()) || obj.Size != fi.Size()
+}
+
+// writeFile atomically replaces the file p.
+func writeFile(p string, b []byte) error {
+	f, err := ioutil.TempFile(filepath.Dir(p), "."+filepath.Base(p)+".")
+	if err != nil {
+		return err
+	}
+	tmp := f.Name()
+
+	if _, err := f.Write(b); err != nil {
+		f.Close()
+		os.Remove(tmp)
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		os.Remove(tmp)
+		return err
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	if err := os.Rename(tmp, p); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return nil
+}
+
+// fileName returns the file name for a card UID. UIDs which aren't safe to
+// use in a file name are hashed.
+func fileName(uid string) string {
+	safe := !strings.HasPrefix(uid, ".")
+	for _, r := range uid {
+		if !isSafeFileNameRune(r) {
+			safe = false
+			break
+		}
+	}
+	if !safe {
+		sum := sha256.Sum256([]byte(uid))
+		uid = hex.EncodeToString(sum[:16])
+	}
+	return uid + "." + vcard.Extension
+}
+
+func isSafeFileNameRune(r rune) bool {
+	switch {
+	case 'a' <= r && r <= 'z', 'A' <= r && r <= 'Z', '0' <= r && r <= '9':
+		return true
+	case r == '-' || r == '_' || r == '.' || r == '@':
+		return true
+	default:
+		return false
+	}
+}
+
+func etag(b []byte) string {
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:16])
+}
+
+func etagsByUID(objs []*Object) map[string]string {
+	m := make(map[string]string, len(objs))
+	for _, obj := range objs {
+		m[obj.UID] = obj.ETag
+	}
+	return m
+}
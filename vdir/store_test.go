@@ -0,0 +1,214 @@
+package vdir
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/emersion/go-vcard"
+)
+
+func newTestStore(t *testing.T) (*Store, func()) {
+	dir, err := ioutil.TempDir("", "go-vcard-vdir-")
+	if err != nil {
+		t.Fatalf("TempDir() = %v", err)
+	}
+	s, err := Open(dir)
+	if err != nil {
+		os.RemoveAll(dir)
+		t.Fatalf("Open() = %v", err)
+	}
+	return s, func() { os.RemoveAll(dir) }
+}
+
+func newTestCard(uid, fn string) vcard.Card {
+	return vcard.Card{
+		vcard.FieldVersion:       {{Value: "4.0"}},
+		vcard.FieldUID:           {{Value: uid}},
+		vcard.FieldFormattedName: {{Value: fn}},
+	}
+}
+
+func writeTestFile(t *testing.T, p, s string, modTime time.Time) {
+	if err := ioutil.WriteFile(p, []byte(s), 0644); err != nil {
+		t.Fatalf("WriteFile() = %v", err)
+	}
+	if err := os.Chtimes(p, modTime, modTime); err != nil {
+		t.Fatalf("Chtimes() = %v", err)
+	}
+}
+
+func TestStore(t *testing.T) {
+	s, cleanup := newTestStore(t)
+	defer cleanup()
+
+	alice := newTestCard("alice@example.org", "Alice")
+	obj, err := s.Put(alice, "")
+	if err != nil {
+		t.Fatalf("Put() = %v", err)
+	}
+	if want := filepath.Join(s.Dir(), "alice@example.org.vcf"); obj.Path != want {
+		t.Errorf("Put(): path = %v, want %v", obj.Path, want)
+	}
+
+	card, got, err := s.Get("alice@example.org")
+	if err != nil {
+		t.Fatalf("Get() = %v", err)
+	}
+	if !reflect.DeepEqual(card, alice) {
+		t.Errorf("Get() = %v, want %v", card, alice)
+	}
+	if got.ETag != obj.ETag {
+		t.Errorf("Get(): ETag = %v, want %v", got.ETag, obj.ETag)
+	}
+
+	if _, err := s.Put(newTestCard("alice@example.org", "Alice L."), "wrong"); err != ErrETagMismatch {
+		t.Errorf("Put() with wrong ETag = %v, want ErrETagMismatch", err)
+	}
+	updated, err := s.Put(newTestCard("alice@example.org", "Alice L."), obj.ETag)
+	if err != nil {
+		t.Fatalf("Put() with ETag = %v", err)
+	}
+	if updated.ETag == obj.ETag {
+		t.Error("Put(): ETag didn't change after update")
+	}
+
+	bob := newTestCard("bob/../1", "Bob")
+	obj, err = s.Put(bob, "")
+	if err != nil {
+		t.Fatalf("Put() = %v", err)
+	}
+	if filepath.Dir(obj.Path) != s.Dir() {
+		t.Errorf("Put(): unsafe UID not hashed: %v", obj.Path)
+	}
+
+	l, err := s.List()
+	if err != nil {
+		t.Fatalf("List() = %v", err)
+	}
+	if len(l) != 2 || l[0].UID != "alice@example.org" || l[1].UID != "bob/../1" {
+		t.Errorf("List() = %+v", l)
+	}
+
+	if err := s.Delete("bob/../1", ""); err != nil {
+		t.Fatalf("Delete() = %v", err)
+	}
+	if _, _, err := s.Get("bob/../1"); err != ErrNotFound {
+		t.Errorf("Get() on deleted card = %v, want ErrNotFound", err)
+	}
+	if err := s.Delete("bob/../1", ""); err != ErrNotFound {
+		t.Errorf("Delete() on deleted card = %v, want ErrNotFound", err)
+	}
+
+	fis, err := ioutil.ReadDir(s.Dir())
+	if err != nil {
+		t.Fatalf("ReadDir() = %v", err)
+	}
+	if len(fis) != 1 {
+		t.Errorf("store contains %v files, want 1", len(fis))
+	}
+}
+
+func TestStore_Changes(t *testing.T) {
+	s, cleanup := newTestStore(t)
+	defer cleanup()
+
+	for _, uid := range []string{"a", "b", "c"} {
+		if _, err := s.Put(newTestCard(uid, uid), ""); err != nil {
+			t.Fatalf("Put() = %v", err)
+		}
+	}
+
+	changes, err := s.Changes()
+	if err != nil {
+		t.Fatalf("Changes() = %v", err)
+	}
+	if len(changes.Updated) != 0 || len(changes.Deleted) != 0 {
+		t.Errorf("Changes() after Put() = %+v, want none", changes)
+	}
+
+	// Modify the store from another program. Files are named arbitrarily.
+	modTime := time.Now().Add(time.Hour)
+	card := "BEGIN:VCARD\r\nVERSION:4.0\r\nUID:b\r\nFN:B\r\nEND:VCARD\r\n"
+	writeTestFile(t, filepath.Join(s.Dir(), "b.vcf"), card, modTime)
+	card = "BEGIN:VCARD\r\nVERSION:4.0\r\nUID:d\r\nFN:d\r\nEND:VCARD\r\n"
+	writeTestFile(t, filepath.Join(s.Dir(), "other-name.vcf"), card, modTime)
+	writeTestFile(t, filepath.Join(s.Dir(), ".hidden.vcf"), card, modTime)
+	if err := os.Remove(filepath.Join(s.Dir(), "c.vcf")); err != nil {
+		t.Fatalf("Remove() = %v", err)
+	}
+
+	changes, err = s.Changes()
+	if err != nil {
+		t.Fatalf("Changes() = %v", err)
+	}
+	want := &Changes{Updated: []string{"b", "d"}, Deleted: []string{"c"}}
+	if !reflect.DeepEqual(changes, want) {
+		t.Errorf("Changes() = %+v, want %+v", changes, want)
+	}
+
+	card2, obj, err := s.Get("d")
+	if err != nil {
+		t.Fatalf("Get() = %v", err)
+	}
+	if card2.Value(vcard.FieldFormattedName) != "d" || filepath.Base(obj.Path) != "other-name.vcf" {
+		t.Errorf("Get() = %v, %+v", card2, obj)
+	}
+	if _, err := s.Put(newTestCard("d", "D"), obj.ETag); err != nil {
+		t.Fatalf("Put() = %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(s.Dir(), "d.vcf")); !os.IsNotExist(err) {
+		t.Errorf("Put() created a new file instead of replacing the existing one")
+	}
+
+	changes, err = s.Changes()
+	if err != nil {
+		t.Fatalf("Changes() = %v", err)
+	}
+	if len(changes.Updated) != 0 || len(changes.Deleted) != 0 {
+		t.Errorf("Changes() = %+v, want none", changes)
+	}
+}
+
+func TestStore_invalidFiles(t *testing.T) {
+	dir, err := ioutil.TempDir("", "go-vcard-vdir-")
+	if err != nil {
+		t.Fatalf("TempDir() = %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	modTime := time.Now()
+	writeTestFile(t, filepath.Join(dir, "empty.vcf"), "", modTime)
+	writeTestFile(t, filepath.Join(dir, "garbage.vcf"), "BEGIN:VCARD\r\nnot a vCard", modTime)
+	writeTestFile(t, filepath.Join(dir, "a.vcf"), "BEGIN:VCARD\r\nVERSION:4.0\r\nUID:a\r\nFN:A\r\nEND:VCARD\r\n", modTime)
+
+	s, err := Open(dir)
+	if err != nil {
+		t.Fatalf("Open() = %v", err)
+	}
+	objs, err := s.List()
+	if err != nil {
+		t.Fatalf("List() = %v", err)
+	}
+	if len(objs) != 1 || objs[0].UID != "a" {
+		t.Errorf("List() = %+v, want only a", objs)
+	}
+	if _, err := s.Put(newTestCard("b", "B"), ""); err != nil {
+		t.Fatalf("Put() = %v", err)
+	}
+
+	// Fix the invalid file
+	card := "BEGIN:VCARD\r\nVERSION:4.0\r\nUID:c\r\nFN:C\r\nEND:VCARD\r\n"
+	writeTestFile(t, filepath.Join(dir, "garbage.vcf"), card, modTime.Add(time.Hour))
+	changes, err := s.Changes()
+	if err != nil {
+		t.Fatalf("Changes() = %v", err)
+	}
+	want := &Changes{Updated: []string{"c"}}
+	if !reflect.DeepEqual(changes, want) {
+		t.Errorf("Changes() = %+v, want %+v", changes, want)
+	}
+}